@@ -0,0 +1,180 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Archive formats understood by -archive
+const (
+	archiveZip   = "zip"
+	archiveTar   = "tar"
+	archiveTarGz = "tar.gz"
+)
+
+// archiveMemberSep separates an archive path from a member path in reports
+const archiveMemberSep = "!"
+
+// archiveKind returns the archive format of path judged by its name, or an
+// empty string if it is not a supported archive
+func archiveKind(path string) string {
+	name := strings.ToLower(path)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return archiveZip
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return archiveTarGz
+	case strings.HasSuffix(name, ".tar"):
+		return archiveTar
+	}
+	return ""
+}
+
+// processArchive checks the regular file members of an archive without
+// unpacking it. Members are recorded as "<archive>!<member path>".
+func processArchive(path, display, kind string, opts Options, result *RepoResult) {
+	visit := func(name string, r io.Reader) error {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("failed to read archive member %s: %w", name, err)
+		}
+		processArchiveMember(display+archiveMemberSep+name, name, data, opts, result)
+		return nil
+	}
+
+	var err error
+	if kind == archiveZip {
+		err = walkZip(path, visit)
+	} else {
+		err = walkTar(path, kind == archiveTarGz, visit)
+	}
+	if err != nil {
+		result.addError(display, err)
+	}
+}
+
+// processArchiveMember checks one member's content, applying the same
+// name-based skips as files on disk
+func processArchiveMember(relPath, name string, data []byte, opts Options, result *RepoResult) {
+	if reason := opts.pathSkipReason(name); reason != "" {
+		result.addSkipped(relPath, reason)
+		return
+	}
+
+	check, _ := checkBytes(data, opts)
+	if check.generated {
+		result.addSkipped(relPath, "generated-code marker")
+		result.Generated = append(result.Generated, relPath)
+		return
+	}
+	if check.skippedContent {
+		result.addSkipped(relPath, skipContentReason)
+		return
+	}
+
+	check.size = int64(len(data))
+	recordCheck(relPath, check, opts, result)
+}
+
+// gzipMagic starts every gzip stream
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// processArchiveStream checks the members of a tar stream read from r,
+// such as tar czf - src piped to -archive-stdin. Gzip compression is
+// detected from the content. Members are recorded by their path in the
+// archive and nothing is written.
+func processArchiveStream(r io.Reader, opts Options) (*RepoResult, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(len(gzipMagic))
+
+	result := newRepoResult(opts)
+	err := readTar(br, bytes.Equal(magic, gzipMagic), func(name string, r io.Reader) error {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("failed to read archive member %s: %w", name, err)
+		}
+		processArchiveMember(name, name, data, opts, result)
+		return result.stopReason(opts)
+	})
+	if err != nil {
+		return partialResult(result, err, opts)
+	}
+
+	result.finish(opts)
+	return result, nil
+}
+
+// walkZip calls visit for each regular file in a zip archive
+func walkZip(path string, visit func(name string, r io.Reader) error) error {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		if !f.Mode().IsRegular() {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open archive member %s: %w", f.Name, err)
+		}
+		err = visit(f.Name, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkTar calls visit for each regular file in a tar archive, optionally
+// gzip-compressed
+func walkTar(path string, gzipped bool, visit func(name string, r io.Reader) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer f.Close()
+
+	return readTar(f, gzipped, visit)
+}
+
+// readTar calls visit for each regular file in the tar stream r,
+// optionally gzip-compressed
+func readTar(r io.Reader, gzipped bool, visit func(name string, r io.Reader) error) error {
+	if gzipped {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return fmt.Errorf("failed to open archive: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := visit(strings.TrimPrefix(hdr.Name, "./"), tr); err != nil {
+			return err
+		}
+	}
+}
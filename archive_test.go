@@ -0,0 +1,238 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// archiveMembers is the content used for each test archive
+var archiveMembers = []struct {
+	name    string
+	content string
+}{
+	{name: "docs/ok.md", content: "ok\n"},
+	{name: "docs/missing.md", content: "missing"},
+	{name: "images/logo.png", content: "\x89PNG"},
+}
+
+// writeZip creates a zip archive of archiveMembers
+func writeZip(t *testing.T, path string) {
+	t.Helper()
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("アーカイブの作成に失敗: %v", err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for _, m := range archiveMembers {
+		w, err := zw.Create(m.name)
+		if err != nil {
+			t.Fatalf("アーカイブメンバーの作成に失敗: %v", err)
+		}
+		if _, err := io.WriteString(w, m.content); err != nil {
+			t.Fatalf("アーカイブメンバーの書き込みに失敗: %v", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("アーカイブの作成に失敗: %v", err)
+	}
+}
+
+// writeTar creates a tar archive of archiveMembers, gzipped if requested
+func writeTar(t *testing.T, path string, gzipped bool) {
+	t.Helper()
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("アーカイブの作成に失敗: %v", err)
+	}
+	defer f.Close()
+
+	var w io.Writer = f
+	var gz *gzip.Writer
+	if gzipped {
+		gz = gzip.NewWriter(f)
+		w = gz
+	}
+
+	tw := tar.NewWriter(w)
+	if err := tw.WriteHeader(&tar.Header{Name: "docs/", Typeflag: tar.TypeDir, Mode: 0o755}); err != nil {
+		t.Fatalf("アーカイブメンバーの作成に失敗: %v", err)
+	}
+	for _, m := range archiveMembers {
+		hdr := &tar.Header{Name: m.name, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(m.content))}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("アーカイブメンバーの作成に失敗: %v", err)
+		}
+		if _, err := io.WriteString(tw, m.content); err != nil {
+			t.Fatalf("アーカイブメンバーの書き込みに失敗: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("アーカイブの作成に失敗: %v", err)
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			t.Fatalf("アーカイブの作成に失敗: %v", err)
+		}
+	}
+}
+
+func TestArchiveKind(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{path: "docs.zip", expected: archiveZip},
+		{path: "docs.TAR", expected: archiveTar},
+		{path: "docs.tar.gz", expected: archiveTarGz},
+		{path: "docs.tgz", expected: archiveTarGz},
+		{path: "docs.gz", expected: ""},
+		{path: "docs.md", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := archiveKind(tt.path); got != tt.expected {
+				t.Errorf("archiveKind(%q) = %q, expected %q", tt.path, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestProcessPathsArchive(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		write func(t *testing.T, path string)
+	}{
+		{name: "zip", file: "bundle.zip", write: writeZip},
+		{name: "tar", file: "bundle.tar", write: func(t *testing.T, path string) { writeTar(t, path, false) }},
+		{name: "tar.gz", file: "bundle.tar.gz", write: func(t *testing.T, path string) { writeTar(t, path, true) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			tt.write(t, path)
+
+			result, err := processPaths([]string{path}, Options{Archive: true})
+			if err != nil {
+				t.Fatalf("processPaths()でエラーが発生: %v", err)
+			}
+
+			display := filepath.ToSlash(path)
+			if result.Total != 2 || result.Skipped != 1 {
+				t.Errorf("件数が期待値と異なります: total %d, skipped %d", result.Total, result.Skipped)
+			}
+			expected := []string{display + "!docs/missing.md"}
+			if !slices.Equal(result.Problematic, expected) {
+				t.Errorf("problematicが期待値と異なります: got %v, expected %v", result.Problematic, expected)
+			}
+		})
+	}
+}
+
+func TestExecuteArchiveDirectory(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "missing.txt"), []byte("missing"), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+	reportPath := filepath.Join(t.TempDir(), "report.json")
+
+	// ディレクトリは-archiveを付けても通常どおりルートからの相対パスで表示する
+	cfg, err := parseArgs([]string{"-archive", "-format", "json", "-report-file", reportPath, tempDir})
+	if err != nil {
+		t.Fatalf("parseArgs()でエラーが発生: %v", err)
+	}
+	if code := execute(cfg); code != 1 {
+		t.Fatalf("終了コード = %d, expected 1", code)
+	}
+
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatalf("レポートファイルの読み込みに失敗: %v", err)
+	}
+	var decoded RepoResult
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("レポートファイルがJSONとして不正です: %v", err)
+	}
+	if expected := []string{"missing.txt"}; !slices.Equal(decoded.Problematic, expected) {
+		t.Errorf("problematicが期待値と異なります: got %v, expected %v", decoded.Problematic, expected)
+	}
+}
+
+func TestProcessPathsArchiveDisabled(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bundle.zip")
+	writeZip(t, path)
+
+	result, err := processPaths([]string{path}, Options{})
+	if err != nil {
+		t.Fatalf("processPaths()でエラーが発生: %v", err)
+	}
+	if result.Total != 0 || result.Skipped != 1 {
+		t.Errorf("-archiveなしではアーカイブがスキップされるべきです: total %d, skipped %d", result.Total, result.Skipped)
+	}
+}
+
+func TestProcessPathsCorruptArchive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broken.tar.gz")
+	if err := os.WriteFile(path, []byte("not gzip"), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+
+	result, err := processPaths([]string{path}, Options{Archive: true})
+	if err != nil {
+		t.Fatalf("processPaths()でエラーが発生: %v", err)
+	}
+	if len(result.Errors) != 1 {
+		t.Errorf("壊れたアーカイブはエラーとして記録されるべきです: %v", result.Errors)
+	}
+}
+
+func TestProcessArchiveStream(t *testing.T) {
+	for _, gzipped := range []bool{false, true} {
+		t.Run(fmt.Sprintf("gzip=%v", gzipped), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "bundle.tar")
+			writeTar(t, path, gzipped)
+			f, err := os.Open(path)
+			if err != nil {
+				t.Fatalf("アーカイブのオープンに失敗: %v", err)
+			}
+			defer f.Close()
+
+			result, err := processArchiveStream(f, Options{})
+			if err != nil {
+				t.Fatalf("processArchiveStream()でエラーが発生: %v", err)
+			}
+
+			if result.Total != 2 || result.Skipped != 1 {
+				t.Errorf("件数が期待値と異なります: total %d, skipped %d", result.Total, result.Skipped)
+			}
+			expected := []string{"docs/missing.md"}
+			if !slices.Equal(result.Problematic, expected) {
+				t.Errorf("problematicが期待値と異なります: got %v, expected %v", result.Problematic, expected)
+			}
+			if code := exitCode(result, Options{}); code != 1 {
+				t.Errorf("終了コードが期待値と異なります: got %d, expected 1", code)
+			}
+		})
+	}
+}
+
+func TestProcessArchiveStreamCorrupt(t *testing.T) {
+	if _, err := processArchiveStream(strings.NewReader("\x1f\x8bnot gzip"), Options{}); err == nil {
+		t.Error("壊れたストリームでエラーが発生しませんでした")
+	}
+}
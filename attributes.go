@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Attribute states in a .gitattributes line
+const (
+	attrSet   = "set"
+	attrUnset = "unset"
+)
+
+// attrRule is one pattern line of a .gitattributes file
+type attrRule struct {
+	pattern *globPattern
+	// attrs maps an attribute name to attrSet, attrUnset or its value.
+	// An empty string marks an attribute reset to unspecified with "!".
+	attrs map[string]string
+}
+
+// fileAttributes are the attributes that matter for the newline check
+type fileAttributes struct {
+	text string
+	eol  string
+}
+
+// gitAttributes resolves .gitattributes settings for files, reading each
+// directory's file once
+type gitAttributes struct {
+	ignoreCase bool
+	dirs       map[string][]attrRule
+}
+
+// newGitAttributes returns an empty .gitattributes resolver. With
+// ignoreCase the patterns match paths in either case.
+func newGitAttributes(ignoreCase bool) *gitAttributes {
+	return &gitAttributes{ignoreCase: ignoreCase, dirs: map[string][]attrRule{}}
+}
+
+// parseAttrLine parses one line of a .gitattributes file. Comments and
+// blank lines yield a nil rule.
+func parseAttrLine(line string, ignoreCase bool) (*attrRule, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+		return nil, nil
+	}
+
+	pattern, err := compileGlob(fields[0], ignoreCase)
+	if err != nil {
+		return nil, err
+	}
+
+	rule := &attrRule{pattern: pattern, attrs: map[string]string{}}
+	for _, field := range fields[1:] {
+		switch {
+		case field == "binary":
+			// binary is a macro for -diff -merge -text
+			rule.attrs["text"] = attrUnset
+		case strings.HasPrefix(field, "-"):
+			rule.attrs[field[1:]] = attrUnset
+		case strings.HasPrefix(field, "!"):
+			rule.attrs[field[1:]] = ""
+		case strings.Contains(field, "="):
+			name, value, _ := strings.Cut(field, "=")
+			rule.attrs[name] = value
+		default:
+			rule.attrs[field] = attrSet
+		}
+	}
+
+	return rule, nil
+}
+
+// rulesFor returns the parsed rules of dir's .gitattributes file
+func (g *gitAttributes) rulesFor(dir string) ([]attrRule, error) {
+	if rules, ok := g.dirs[dir]; ok {
+		return rules, nil
+	}
+
+	var rules []attrRule
+	f, err := os.Open(filepath.Join(dir, ".gitattributes"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read .gitattributes: %w", err)
+	}
+	if err == nil {
+		defer f.Close()
+
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			rule, err := parseAttrLine(scanner.Text(), g.ignoreCase)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", filepath.Join(dir, ".gitattributes"), err)
+			}
+			if rule != nil {
+				rules = append(rules, *rule)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read .gitattributes: %w", err)
+		}
+	}
+
+	g.dirs[dir] = rules
+	return rules, nil
+}
+
+// repoDirs returns the directories from the one containing abs up to the
+// repository top, the nearest directory containing .git, or the
+// filesystem root when there is none
+func repoDirs(abs string) []string {
+	var dirs []string
+	for dir := filepath.Dir(abs); ; dir = filepath.Dir(dir) {
+		dirs = append(dirs, dir)
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			break
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			break
+		}
+	}
+	return dirs
+}
+
+// lookup resolves the attributes for path. Files are read from the
+// repository top (the nearest directory containing .git, or the
+// filesystem root) down to the file's directory, so deeper files and
+// later lines take precedence.
+func (g *gitAttributes) lookup(path string) (fileAttributes, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fileAttributes{}, fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	dirs := repoDirs(abs)
+
+	attrs := map[string]string{}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		rules, err := g.rulesFor(dirs[i])
+		if err != nil {
+			return fileAttributes{}, err
+		}
+
+		rel, err := filepath.Rel(dirs[i], abs)
+		if err != nil {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+
+		for _, rule := range rules {
+			if !rule.pattern.match(rel) {
+				continue
+			}
+			for name, value := range rule.attrs {
+				attrs[name] = value
+			}
+		}
+	}
+
+	return fileAttributes{text: attrs["text"], eol: attrs["eol"]}, nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseAttrLine(t *testing.T) {
+	tests := []struct {
+		name         string
+		line         string
+		expectedNil  bool
+		expectedText string
+		expectedEOL  string
+	}{
+		{name: "コメント", line: "# comment", expectedNil: true},
+		{name: "空行", line: "   ", expectedNil: true},
+		{name: "binaryマクロ", line: "*.dat binary", expectedText: attrUnset},
+		{name: "textとeol", line: "*.sh text eol=lf", expectedText: attrSet, expectedEOL: "lf"},
+		{name: "-text", line: "*.bin -text", expectedText: attrUnset},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := parseAttrLine(tt.line, false)
+			if err != nil {
+				t.Fatalf("parseAttrLine()でエラーが発生: %v", err)
+			}
+			if tt.expectedNil {
+				if rule != nil {
+					t.Errorf("nilが期待されましたが、ルールが返されました: %+v", rule)
+				}
+				return
+			}
+			if rule.attrs["text"] != tt.expectedText || rule.attrs["eol"] != tt.expectedEOL {
+				t.Errorf("text=%q, eol=%q, expected text=%q, eol=%q",
+					rule.attrs["text"], rule.attrs["eol"], tt.expectedText, tt.expectedEOL)
+			}
+		})
+	}
+}
+
+func TestProcessRepositoryGitAttributes(t *testing.T) {
+	tempDir := t.TempDir()
+
+	files := map[string]string{
+		".git/HEAD":          "ref: refs/heads/main\n",
+		".gitattributes":     "*.dat binary\n*.sh text eol=lf\n*.bat eol=crlf\n*.svg text\n",
+		"sub/.gitattributes": "*.sh -text\n",
+		"data.dat":           "looks like text",
+		"build.sh":           "echo hi\r\n",
+		"run.bat":            "echo hi\n",
+		"icon.svg":           "<svg/>",
+		"sub/ignored.sh":     "echo hi",
+		"plain.txt":          "plain",
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("ディレクトリの作成に失敗: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	result, err := processRepository(tempDir, Options{Fix: true, GitAttributes: newGitAttributes(false)})
+	if err != nil {
+		t.Fatalf("processRepository()でエラーが発生: %v", err)
+	}
+
+	expected := map[string]string{
+		"data.dat":       "looks like text",
+		"build.sh":       "echo hi\n",
+		"run.bat":        "echo hi\r\n",
+		"icon.svg":       "<svg/>\n",
+		"sub/ignored.sh": "echo hi",
+		"plain.txt":      "plain\n",
+	}
+	for name, want := range expected {
+		content, err := os.ReadFile(filepath.Join(tempDir, name))
+		if err != nil {
+			t.Fatalf("ファイルの読み込みに失敗: %v", err)
+		}
+		if string(content) != want {
+			t.Errorf("%s の内容が期待値と異なります。actual: %q, expected: %q", name, string(content), want)
+		}
+	}
+
+	if len(result.Fixed) != 4 {
+		t.Errorf("修正したファイル数 = %d, expected 4: %v", len(result.Fixed), result.Fixed)
+	}
+}
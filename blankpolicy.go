@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Policies of -blank-line-policy for blank lines before the final newline
+const (
+	blankLineAllow = "allow"
+	blankLineDeny  = "deny"
+)
+
+// blankLineDeniedReason is recorded for files failing a deny policy
+const blankLineDeniedReason = "blank line before final newline"
+
+// parseBlankLinePolicy builds the extension mapping from entries such as
+// ".md=deny,.txt=allow"
+func parseBlankLinePolicy(entries string) (map[string]string, error) {
+	policy := map[string]string{}
+	for _, entry := range strings.Split(entries, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		ext, value, ok := strings.Cut(entry, "=")
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		value = strings.ToLower(strings.TrimSpace(value))
+		if !ok || ext == "" {
+			return nil, fmt.Errorf("invalid -blank-line-policy entry %q: expected .ext=deny or .ext=allow", entry)
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+
+		switch value {
+		case blankLineAllow, blankLineDeny:
+			policy[ext] = value
+		default:
+			return nil, fmt.Errorf("invalid -blank-line-policy entry %q: unknown policy %q", entry, value)
+		}
+	}
+
+	return policy, nil
+}
+
+// blankLinePolicy returns the policy for blank lines at the end of
+// relPath, or "" for the default of reporting them without failing
+func (o Options) blankLinePolicy(relPath string) string {
+	return o.BlankLinePolicy[strings.ToLower(filepath.Ext(relPath))]
+}
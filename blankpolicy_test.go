@@ -0,0 +1,104 @@
+package main
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParseBlankLinePolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected map[string]string
+		wantErr  bool
+	}{
+		{name: "空", input: "", expected: map[string]string{}},
+		{name: "複数", input: ".md=deny, txt=ALLOW", expected: map[string]string{".md": blankLineDeny, ".txt": blankLineAllow}},
+		{name: "不明なポリシー", input: ".md=warn", wantErr: true},
+		{name: "拡張子なし", input: "=deny", wantErr: true},
+		{name: "=なし", input: ".md", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := parseBlankLinePolicy(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("エラーが発生しませんでした: %v", policy)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseBlankLinePolicy()でエラーが発生: %v", err)
+			}
+			if !maps.Equal(policy, tt.expected) {
+				t.Errorf("got %v, expected %v", policy, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBlankLinePolicy(t *testing.T) {
+	files := map[string]string{
+		"doc.md":   "# doc\n\n",
+		"note.txt": "note\n\n",
+		"main.go":  "package main\n\n",
+	}
+
+	tests := []struct {
+		name        string
+		opts        Options
+		problematic []string
+		blankLines  []string
+		fixed       []string
+	}{
+		{
+			name:       "ポリシーなし",
+			blankLines: []string{"doc.md", "main.go", "note.txt"},
+		},
+		{
+			name:        "denyとallow",
+			opts:        Options{BlankLinePolicy: map[string]string{".md": blankLineDeny, ".txt": blankLineAllow}},
+			problematic: []string{"doc.md"},
+			blankLines:  []string{"doc.md", "main.go"},
+		},
+		{
+			name:       "空行を修正",
+			opts:       Options{FixBlankLines: true, BlankLinePolicy: map[string]string{".md": blankLineDeny}},
+			blankLines: []string{"doc.md", "main.go", "note.txt"},
+			fixed:      []string{"doc.md", "main.go", "note.txt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+					t.Fatalf("テストファイルの作成に失敗: %v", err)
+				}
+			}
+
+			result, err := processRepository(dir, tt.opts)
+			if err != nil {
+				t.Fatalf("処理に失敗: %v", err)
+			}
+
+			for _, c := range []struct {
+				label         string
+				got, expected []string
+			}{
+				{"problematic", result.Problematic, tt.problematic},
+				{"trailing_blank_lines", result.TrailingBlankLines, tt.blankLines},
+				{"fixed", result.Fixed, tt.fixed},
+			} {
+				if len(c.got) != len(c.expected) || (len(c.got) > 0 && !slices.Equal(c.got, c.expected)) {
+					t.Errorf("%s: got %v, expected %v", c.label, c.got, c.expected)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// cacheVersion is bumped whenever the cache file layout changes
+const cacheVersion = 7
+
+// cacheEntry is the last known result for a file
+type cacheEntry struct {
+	Size              int64  `json:"size"`
+	ModTime           int64  `json:"mtime"`
+	OK                bool   `json:"ok"`
+	NoLineTerminators bool   `json:"no_line_terminators,omitempty"`
+	LastLine          int    `json:"last_line,omitempty"`
+	WrongEOL          bool   `json:"wrong_eol,omitempty"`
+	ExtraNewlines     bool   `json:"extra_newlines,omitempty"`
+	FewNewlines       bool   `json:"few_newlines,omitempty"`
+	BlankLinesAdded   int    `json:"blank_lines_added,omitempty"`
+	Binary            bool   `json:"binary,omitempty"`
+	BlankLines        bool   `json:"blank_lines,omitempty"`
+	TrailingSpace     bool   `json:"trailing_whitespace,omitempty"`
+	UTF8BOM           bool   `json:"utf8_bom,omitempty"`
+	FinalEOL          string `json:"final_eol,omitempty"`
+	// Options is the checkOptions digest of the options the file was
+	// checked with
+	Options string `json:"options"`
+}
+
+// check returns the cached result as a fileCheck. Every field that
+// recordCheck reads is kept, so that a cached file is reported exactly
+// as when it was read.
+func (e cacheEntry) check() fileCheck {
+	return fileCheck{
+		ok:                 e.OK,
+		binary:             e.Binary,
+		size:               e.Size,
+		noLineTerminators:  e.NoLineTerminators,
+		lastLine:           e.LastLine,
+		wrongEOL:           e.WrongEOL,
+		extraNewlines:      e.ExtraNewlines,
+		fewNewlines:        e.FewNewlines,
+		blankLinesAdded:    e.BlankLinesAdded,
+		blankLines:         e.BlankLines,
+		trailingWhitespace: e.TrailingSpace,
+		utf8BOM:            e.UTF8BOM,
+		finalEOL:           e.FinalEOL,
+	}
+}
+
+// needsRead reports whether the file must be read again despite being
+// unchanged, because the enabled fixes would change it
+func (e cacheEntry) needsRead(opts Options) bool {
+	if !opts.writesFiles() && opts.Quarantine == "" {
+		return false
+	}
+	return !e.OK || (e.BlankLines && opts.FixBlankLines) || (e.TrailingSpace && opts.FixWhitespace) || (e.UTF8BOM && opts.NoBOM && opts.Fix)
+}
+
+// fileCache remembers results between runs so that unchanged files don't
+// have to be read again
+type fileCache struct {
+	path    string
+	Version int                   `json:"version"`
+	Files   map[string]cacheEntry `json:"files"`
+}
+
+// loadCache reads the cache at path. A missing or outdated cache file
+// yields an empty cache.
+func loadCache(path string) (*fileCache, error) {
+	cache := &fileCache{path: path, Version: cacheVersion, Files: map[string]cacheEntry{}}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache: %w", err)
+	}
+
+	var stored fileCache
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("failed to parse cache %s: %w", path, err)
+	}
+	if stored.Version == cacheVersion && stored.Files != nil {
+		cache.Files = stored.Files
+	}
+
+	return cache, nil
+}
+
+// cacheKey returns the key a file is stored under
+func cacheKey(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// checkOptions returns a digest of the options that change the result of
+// checking a file. opts are those of the file, after .gitattributes and
+// per-extension newline styles are applied.
+func checkOptions(opts Options) string {
+	var controls controlSet
+	if opts.TextControls != nil {
+		controls = *opts.TextControls
+	}
+	var generated, skipContent string
+	if opts.GeneratedPattern != nil {
+		generated = opts.GeneratedPattern.String()
+	}
+	if opts.SkipContent != nil {
+		skipContent = opts.SkipContent.String()
+	}
+
+	key := fmt.Sprintf("eol=%q single=%v final=%d force-text=%v custom-binary=%v controls=%v encoding=%v preserve=%v generated=%q skip-content=%q blank-lines=%v",
+		opts.EOL, opts.SingleFinalNewline, opts.FinalNewlines, opts.ForceText, opts.IsBinary != nil, controls,
+		opts.Encoding, opts.PreserveTrailingContent, generated, skipContent, opts.BlankLinePolicy)
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:8])
+}
+
+// lookup returns the cached entry for path if the file is unchanged and
+// was checked with the same options
+func (c *fileCache) lookup(path string, info os.FileInfo, opts Options) (cacheEntry, bool) {
+	entry, ok := c.Files[cacheKey(path)]
+	if !ok || entry.Size != info.Size() || entry.ModTime != info.ModTime().UnixNano() || entry.Options != checkOptions(opts) {
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+// store records the result of checking path under opts
+func (c *fileCache) store(path string, info os.FileInfo, check fileCheck, opts Options) {
+	c.Files[cacheKey(path)] = cacheEntry{
+		Size:              info.Size(),
+		ModTime:           info.ModTime().UnixNano(),
+		OK:                check.ok,
+		NoLineTerminators: check.noLineTerminators,
+		LastLine:          check.lastLine,
+		WrongEOL:          check.wrongEOL,
+		ExtraNewlines:     check.extraNewlines,
+		FewNewlines:       check.fewNewlines,
+		BlankLinesAdded:   check.blankLinesAdded,
+		Binary:            check.binary,
+		BlankLines:        check.blankLines,
+		TrailingSpace:     check.trailingWhitespace,
+		UTF8BOM:           check.utf8BOM,
+		FinalEOL:          check.finalEOL,
+		Options:           checkOptions(opts),
+	}
+}
+
+// save writes the cache back to its file
+func (c *fileCache) save() error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache: %w", err)
+	}
+
+	if err := os.WriteFile(c.path, data, defaultFileMode); err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestProcessRepositoryCache(t *testing.T) {
+	tempDir := t.TempDir()
+	cachePath := filepath.Join(t.TempDir(), "cache.json")
+
+	files := map[string]string{
+		"ok.txt":      "ok\n",
+		"missing.txt": "missing",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	runWithCache := func() *RepoResult {
+		cache, err := loadCache(cachePath)
+		if err != nil {
+			t.Fatalf("キャッシュの読み込みに失敗: %v", err)
+		}
+
+		result, err := processRepository(tempDir, Options{Cache: cache})
+		if err != nil {
+			t.Fatalf("processRepository()でエラーが発生: %v", err)
+		}
+
+		if err := cache.save(); err != nil {
+			t.Fatalf("キャッシュの保存に失敗: %v", err)
+		}
+		return result
+	}
+
+	// 初回はキャッシュなし
+	if result := runWithCache(); result.Cached != 0 {
+		t.Errorf("初回実行でキャッシュが使われています: %d", result.Cached)
+	}
+
+	// 2回目は未変更のファイルがすべてキャッシュされ、問題のあるファイルも報告される
+	result := runWithCache()
+	if result.Cached != 2 {
+		t.Errorf("キャッシュされたファイル数 = %d, expected 2", result.Cached)
+	}
+	if len(result.Problematic) != 1 || result.Problematic[0] != "missing.txt" {
+		t.Errorf("キャッシュされた問題のあるファイルが報告されていません: %v", result.Problematic)
+	}
+
+	// 変更したファイルはキャッシュが無効になる
+	if err := os.WriteFile(filepath.Join(tempDir, "missing.txt"), []byte("now fixed\n"), 0o644); err != nil {
+		t.Fatalf("テストファイルの更新に失敗: %v", err)
+	}
+
+	result = runWithCache()
+	if result.Cached != 1 {
+		t.Errorf("キャッシュされたファイル数 = %d, expected 1", result.Cached)
+	}
+	if len(result.Problematic) != 0 {
+		t.Errorf("更新したファイルが再チェックされていません: %v", result.Problematic)
+	}
+}
+
+func TestProcessRepositoryCacheOptions(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "a.txt"), []byte("a\n\n\n"), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+	cachePath := filepath.Join(t.TempDir(), "cache.json")
+
+	run := func(opts Options) *RepoResult {
+		cache, err := loadCache(cachePath)
+		if err != nil {
+			t.Fatalf("キャッシュの読み込みに失敗: %v", err)
+		}
+		opts.Cache = cache
+		result, err := processRepository(tempDir, opts)
+		if err != nil {
+			t.Fatalf("processRepository()でエラーが発生: %v", err)
+		}
+		if err := cache.save(); err != nil {
+			t.Fatalf("キャッシュの保存に失敗: %v", err)
+		}
+		return result
+	}
+
+	run(Options{})
+
+	// 異なるオプションでは別のオプションでキャッシュした結果を使わない
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{name: "-single-final-newline", opts: Options{SingleFinalNewline: true}},
+		{name: "-final-newlines", opts: Options{FinalNewlines: 2}},
+		{name: "CRLF", opts: Options{EOL: eolCRLF}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := run(tt.opts)
+			if result.Cached != 0 {
+				t.Errorf("別のオプションのキャッシュが使われました: %d", result.Cached)
+			}
+			if len(result.Problematic) != 1 {
+				t.Errorf("problematicが期待値と異なります: %v", result.Problematic)
+			}
+		})
+	}
+
+	// 同じオプションではキャッシュを使う
+	run(Options{SingleFinalNewline: true})
+	if result := run(Options{SingleFinalNewline: true}); result.Cached != 1 || len(result.Problematic) != 1 {
+		t.Errorf("キャッシュの結果が期待値と異なります: cached=%d, problematic=%v", result.Cached, result.Problematic)
+	}
+}
+
+func TestProcessRepositoryCacheSameResult(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"ok.txt":       "ok\n",
+		"missing.txt":  "line 1\nmissing",
+		"multiple.txt": "multiple\n\n\n",
+		"few.md":       "few\n",
+		"run.bat":      "echo\n",
+		"space.txt":    "space  \n",
+		"blank.txt":    "blank\n\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{name: "改行の種類と数", opts: Options{SingleFinalNewline: true, ExtEOL: map[string]string{".bat": eolCRLF}, Strict: true}},
+		{name: "改行の不足", opts: Options{FinalNewlines: 2, CountLinesAdded: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cachePath := filepath.Join(t.TempDir(), "cache.json")
+			run := func() *RepoResult {
+				cache, err := loadCache(cachePath)
+				if err != nil {
+					t.Fatalf("キャッシュの読み込みに失敗: %v", err)
+				}
+				opts := tt.opts
+				opts.Cache = cache
+				result, err := processRepository(tempDir, opts)
+				if err != nil {
+					t.Fatalf("processRepository()でエラーが発生: %v", err)
+				}
+				if err := cache.save(); err != nil {
+					t.Fatalf("キャッシュの保存に失敗: %v", err)
+				}
+				return result
+			}
+
+			// キャッシュを使った2回目も初回と同じ結果になる
+			cold := run()
+			warm := run()
+			if warm.Cached != len(files) {
+				t.Errorf("キャッシュされたファイル数 = %d, expected %d", warm.Cached, len(files))
+			}
+			if !reflect.DeepEqual(cold.Files, warm.Files) {
+				t.Errorf("ファイルごとの結果が異なります:\ncold: %v\nwarm: %v", cold.Files, warm.Files)
+			}
+			if !reflect.DeepEqual(cold.Categories, warm.Categories) {
+				t.Errorf("カテゴリが異なります:\ncold: %v\nwarm: %v", cold.Categories, warm.Categories)
+			}
+			if !reflect.DeepEqual(cold.Problematic, warm.Problematic) || !reflect.DeepEqual(cold.LastLines, warm.LastLines) {
+				t.Errorf("問題のあるファイルが異なります:\ncold: %v %v\nwarm: %v %v", cold.Problematic, cold.LastLines, warm.Problematic, warm.LastLines)
+			}
+			if cold.LinesAdded != warm.LinesAdded || cold.FilesChanged != warm.FilesChanged {
+				t.Errorf("追加行数が異なります: cold=%d/%d, warm=%d/%d", cold.FilesChanged, cold.LinesAdded, warm.FilesChanged, warm.LinesAdded)
+			}
+		})
+	}
+}
+
+func TestLoadCacheMissingFile(t *testing.T) {
+	cache, err := loadCache(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("存在しないキャッシュファイルでエラーが発生: %v", err)
+	}
+	if len(cache.Files) != 0 {
+		t.Errorf("空のキャッシュが期待されましたが、%d件のエントリがあります", len(cache.Files))
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"maps"
+	"slices"
+)
+
+// Problem categories of the JSON report. mixed_eol holds the files whose
+// final newline is not the style configured for them.
+const (
+	categoryMissingNewline   = "missing_newline"
+	categoryMultipleNewlines = "multiple_trailing_newlines"
+	categoryMixedEOL         = "mixed_eol"
+	categoryTrailingSpace    = "trailing_whitespace"
+	categoryBlankLines       = "trailing_blank_lines"
+)
+
+// categoryReasons maps the reasons recorded for problematic files to
+// their category
+var categoryReasons = map[string]string{
+	"missing final newline":     categoryMissingNewline,
+	"too few final newlines":    categoryMissingNewline,
+	"multiple final newlines":   categoryMultipleNewlines,
+	"wrong final newline style": categoryMixedEOL,
+	blankLineDeniedReason:       categoryBlankLines,
+}
+
+// enabledCategories returns the problem categories the options check for
+func enabledCategories(opts Options) []string {
+	categories := []string{categoryMissingNewline}
+	if opts.Strict || opts.FixWhitespace {
+		categories = append(categories, categoryTrailingSpace)
+	}
+	if opts.SingleFinalNewline || opts.FinalNewlines > 0 {
+		categories = append(categories, categoryMultipleNewlines)
+	}
+	if opts.EOL != "" || opts.ExtEOL != nil || opts.GitAttributes != nil {
+		categories = append(categories, categoryMixedEOL)
+	}
+	if slices.Contains(slices.Collect(maps.Values(opts.BlankLinePolicy)), blankLineDeny) {
+		categories = append(categories, categoryBlankLines)
+	}
+	return categories
+}
+
+// categorize groups the files left with a problem by category. Every
+// category enabled for the run is present, empty or not. Problematic
+// files fall into exactly one of the newline categories; trailing
+// whitespace is listed with -strict unless it was removed.
+func (r *RepoResult) categorize(opts Options) {
+	r.Categories = make(map[string][]string)
+	for _, category := range enabledCategories(opts) {
+		r.Categories[category] = []string{}
+	}
+
+	for _, file := range r.Files {
+		if file.Status != statusMissing {
+			continue
+		}
+		if category, ok := categoryReasons[file.Reason]; ok {
+			r.Categories[category] = append(r.Categories[category], file.Path)
+		}
+	}
+	if opts.Strict && !opts.FixWhitespace {
+		r.Categories[categoryTrailingSpace] = append(r.Categories[categoryTrailingSpace], r.TrailingWhitespace...)
+	}
+
+	if !opts.WalkOrder {
+		for _, list := range r.Categories {
+			slices.Sort(list)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestCategories(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"ok.txt":       "ok\n",
+		"missing.txt":  "missing",
+		"multiple.txt": "multiple\n\n\n",
+		"space.txt":    "space  \n",
+		"run.bat":      "echo\n",
+		"blank.md":     "blank\n\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		opts     Options
+		expected map[string][]string
+	}{
+		{
+			name: "デフォルト",
+			expected: map[string][]string{
+				categoryMissingNewline: {"missing.txt"},
+			},
+		},
+		{
+			name: "-strict",
+			opts: Options{Strict: true, SingleFinalNewline: true},
+			expected: map[string][]string{
+				categoryMissingNewline:   {"missing.txt"},
+				categoryTrailingSpace:    {"space.txt"},
+				categoryMultipleNewlines: {"blank.md", "multiple.txt"},
+			},
+		},
+		{
+			name: "複数の改行と改行コード",
+			opts: Options{SingleFinalNewline: true, ExtEOL: map[string]string{".bat": eolCRLF}},
+			expected: map[string][]string{
+				categoryMissingNewline:   {"missing.txt"},
+				categoryMultipleNewlines: {"blank.md", "multiple.txt"},
+				categoryMixedEOL:         {"run.bat"},
+			},
+		},
+		{
+			name: "末尾の空行を禁止",
+			opts: Options{BlankLinePolicy: map[string]string{".md": blankLineDeny}},
+			expected: map[string][]string{
+				categoryMissingNewline: {"missing.txt"},
+				categoryBlankLines:     {"blank.md"},
+			},
+		},
+		{
+			name: "修正後",
+			opts: Options{Fix: true, FixWhitespace: true, SingleFinalNewline: true},
+			expected: map[string][]string{
+				categoryMissingNewline:   {},
+				categoryTrailingSpace:    {},
+				categoryMultipleNewlines: {},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			work := t.TempDir()
+			if err := os.CopyFS(work, os.DirFS(dir)); err != nil {
+				t.Fatalf("テストファイルのコピーに失敗: %v", err)
+			}
+
+			result, err := processRepository(work, tt.opts)
+			if err != nil {
+				t.Fatalf("処理に失敗: %v", err)
+			}
+
+			if len(result.Categories) != len(tt.expected) {
+				t.Errorf("カテゴリ数が期待値と異なります: got %v, expected %v", result.Categories, tt.expected)
+			}
+			for category, expected := range tt.expected {
+				got, ok := result.Categories[category]
+				if !ok || !slices.Equal(got, expected) {
+					t.Errorf("%s: got %v, expected %v", category, got, expected)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,783 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// errUsage is returned by parseArgs when the flag package has already
+// reported a problem with the arguments
+var errUsage = errors.New("invalid usage")
+
+// cliConfig holds the parsed command line
+type cliConfig struct {
+	opts           Options
+	noLock         bool
+	lockWait       time.Duration
+	timeout        time.Duration
+	modifiedWithin time.Duration
+	readStdin      bool
+	listedPaths    bool
+	stdinFormat    string
+	stdinFix       bool
+	stdinCheck     bool
+	archiveStdin   bool
+	staged         bool
+	trackedOnly    bool
+	interactive    bool
+	confirm        bool
+	showVersion    bool
+	noRC           bool
+	init           bool
+	force          bool
+	allowDirty     bool
+	expandEnv      bool
+	explainSkip    string
+	tracePath      string
+	selfCheck      bool
+	selfCheckDir   string
+	cpuProfile     string
+	memProfile     string
+	args           []string
+	// roots holds the options of each path when several are given, each
+	// with its own .newlinerc
+	roots []root
+
+	// Raw flag values validated by parseArgs
+	skipGenerated    bool
+	generatedPattern string
+	skipContentRegex string
+	fileMode         string
+	cacheFile        string
+	outputPatch      string
+	textControlBytes string
+	minFileSize      string
+	skipCommand      string
+	skipDirs         string
+	noDefaultSkip    bool
+	eolByExtension   bool
+	eolMap           string
+	blankLinePolicy  string
+	require          string
+	rejectCRLFEOF    bool
+	summaryTemplate  string
+	pathMap          string
+	encoding         string
+	sortOutput       bool
+	strict           bool
+
+	respectGitAttributes bool
+	respectGitIgnore     bool
+	respectIgnoreFiles   bool
+}
+
+// newFlagSet defines the command-line flags, storing their values in cfg
+func newFlagSet(cfg *cliConfig) *flag.FlagSet {
+	fs := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	fs.Usage = func() { printUsage(fs.Output(), fs) }
+
+	opts := &cfg.opts
+	fs.BoolVar(&opts.Fix, "fix", false, "Fix files that don't end with newline")
+	fs.BoolVar(&opts.Fix, "fix-missing", false, "Same as -fix")
+	fs.StringVar(&cfg.encoding, "encoding", "", "Declared encoding of checked files: utf-8, latin1 or shift-jis (default: UTF-8 with UTF-16 autodetection)")
+	fs.BoolVar(&cfg.eolByExtension, "eol-by-extension", false, "Require CRLF for .bat, .cmd, .ps1 and .sln files and LF for shell scripts")
+	fs.StringVar(&cfg.eolMap, "eol-map", "", "Comma-separated .ext=lf|crlf|any overrides of the -eol-by-extension defaults (implies -eol-by-extension)")
+	fs.BoolVar(&cfg.rejectCRLFEOF, "reject-crlf-eof", false, "Require the final newline to be a single LF, reporting files ending in CRLF or CR and fixing them to LF")
+	fs.BoolVar(&opts.SingleFinalNewline, "single-final-newline", false, "Require exactly one final newline, collapsing several into one with -fix")
+	fs.IntVar(&opts.FinalNewlines, "final-newlines", 0, "Require exactly N newlines at the end, e.g. 2 for a blank last line, adding or removing newlines with -fix (0: at least one)")
+	fs.BoolVar(&opts.NoBOM, "no-bom", false, "Report text files starting with a UTF-8 byte order mark, stripping it with -fix")
+	fs.BoolVar(&cfg.strict, "strict", false, "Turn on -single-final-newline, and with -fix also -fix-blank-lines and -fix-whitespace; check runs fail on trailing whitespace and blank lines")
+	fs.StringVar(&cfg.require, "require", "", "Comma-separated files that must exist in each checked directory, e.g. LICENSE,README.md; missing ones exit with 3")
+	fs.StringVar(&cfg.blankLinePolicy, "blank-line-policy", "", "Comma-separated .ext=deny|allow policies for blank lines before the final newline: deny fails the check, allow doesn't report them, e.g. .md=deny")
+	fs.BoolVar(&opts.FixBlankLines, "fix-blank-lines", false, "Remove blank lines after the last line of content")
+	fs.BoolVar(&opts.FixWhitespace, "fix-whitespace", false, "Remove spaces and tabs at the end of the last line of content")
+	fs.BoolVar(&cfg.confirm, "confirm", false, "Check first and ask once before fixing, showing how many files and directories would change")
+	fs.BoolVar(&cfg.interactive, "interactive", false, "Ask before fixing each file (implies -fix)")
+	fs.StringVar(&opts.Format, "format", formatText, "Report format: text, json, junit, sarif, csv, tap or list (the problematic paths only)")
+	fs.StringVar(&opts.ReportFile, "report-file", "", "Write the report to this file instead of stdout")
+	fs.StringVar(&opts.OutputEncoding, "output-encoding", outputLF, "Line endings and BOM of the -report-file report: lf, crlf, lf-bom or crlf-bom (the checked files are not affected)")
+	fs.StringVar(&opts.ReportFormat, "report-format", "", "Format of the -report-file report, letting -format apply to stdout (default: same as -format)")
+	fs.StringVar(&cfg.summaryTemplate, "summary-template", "", "Go text/template executed against the result in place of the text summary, e.g. '{{.Total}} checked, {{len .Problematic}} bad'")
+	fs.StringVar(&opts.SummaryStream, "summary-stream", streamStdout, "Where the summary goes: stdout, or stderr to keep stdout for the report data alone (other formats then also get a text summary on stderr)")
+	fs.StringVar(&opts.Summary, "summary", summaryCombined, "How to summarize several paths: combined, per-root or both (per-root summaries followed by the combined one)")
+	fs.StringVar(&opts.PathStyle, "paths", pathsRootRel, "How to display paths: root-rel (relative to the checked directory), abs or cwd-rel (relative to the working directory)")
+	fs.StringVar(&opts.PathPrefix, "path-prefix", "", "Prepend this directory to every reported path, e.g. myrepo for myrepo/src/foo.go")
+	fs.StringVar(&cfg.pathMap, "path-map", "", "File of \"<displayed path> <actual path>\" lines renaming reported paths, like .mailmap")
+	fs.BoolVar(&opts.CountLinesAdded, "count-lines-added", false, "Report the files changed and lines added by the fix, or by -fix in check mode")
+	fs.BoolVar(&opts.ReportClean, "report-clean", false, "In check mode, also list the files that already end with newline")
+	fs.BoolVar(&opts.EOLMajority, "eol-majority", false, "Report whether LF or CRLF final newlines are the majority and list the files using the other style (check only)")
+	fs.BoolVar(&opts.Timing, "timing", false, "Time each file and list the slowest ones after the summary")
+	fs.IntVar(&opts.TimingTop, "timing-top", defaultTimingTop, "Number of files listed by -timing (0 lists all)")
+	fs.IntVar(&opts.MaxReport, "max-report", 0, "List at most N problematic files in the text report, followed by how many more there are (0 lists all; -report-file gets the full list)")
+	fs.BoolVar(&opts.Verbose, "verbose", false, "Show a per-category breakdown in the text report")
+	fs.BoolVar(&opts.DiffExit, "diff-exit", false, "Exit with status 1 if -fix changed any file")
+	fs.BoolVar(&opts.DetailedExit, "detailed-exit", false, fmt.Sprintf("Exit with %d plus bits for missing newlines (%d), trailing whitespace or blank lines (%d), errors (%d), byte order marks (%d) and missing -require files (%d)",
+		exitDetailedBase, exitMissingBit, exitTrailingBit, exitErrorBit, exitBOMBit, exitRequiredBit))
+	fs.BoolVar(&opts.WarnOnly, "warn-only", false, "Report files missing newline but exit with status 0")
+	fs.BoolVar(&cfg.skipGenerated, "skip-generated", false, "Skip files with a generated-code marker in their first lines")
+	fs.StringVar(&cfg.skipContentRegex, "skip-content-regex", "", "Skip files whose first 4 KiB match this regular expression, e.g. '^// AUTOGENERATED'")
+	fs.StringVar(&cfg.generatedPattern, "generated-pattern", defaultGeneratedPattern, "Regular expression identifying generated-code marker lines")
+	fs.StringVar(&cfg.fileMode, "file-mode", "0644", "Octal permissions for written files whose original mode is unknown")
+	fs.StringVar(&cfg.outputPatch, "output-patch", "", "With -fix, write the fixes as a unified diff to this file instead of modifying files")
+	fs.StringVar(&opts.Quarantine, "quarantine", "", "Write fixed copies of problematic files under this directory, leaving originals untouched")
+	fs.StringVar(&cfg.textControlBytes, "text-control-bytes", "", "Comma-separated control byte values treated as text by binary detection (default 9,10,11,12,13)")
+	fs.BoolVar(&opts.NoExtSkip, "no-ext-skip", false, "Check files with binary extensions such as .png too, skipping only those whose content looks binary")
+	fs.BoolVar(&opts.PreserveTrailingContent, "preserve-trailing-content", false, "Report text files ending in an incomplete UTF-8 sequence, which suggests truncation, as errors instead of appending a newline")
+	fs.BoolVar(&opts.ErrorOnBinaryContent, "error-on-binary-content", false, "Report files whose content looks binary as errors instead of skipping them, exiting 1")
+	fs.StringVar(&cfg.skipDirs, "skip-dir", "", "Comma-separated directory names to never walk into, in addition to the defaults")
+	fs.BoolVar(&cfg.noDefaultSkip, "no-default-skip-dirs", false, "Walk into "+strings.Join(defaultSkipDirs, ", ")+" unless named by -skip-dir")
+	fs.BoolVar(&opts.ConfineToRoot, "confine-to-root", true, "Skip symlinks that resolve outside the checked directory, listing them with -verbose; -confine-to-root=false checks them")
+	fs.BoolVar(&opts.SkipSymlinks, "skip-symlinks", false, "Skip symbolic links instead of checking their targets (links found while walking are checked but never fixed)")
+	fs.StringVar(&cfg.skipCommand, "skip-command", "", "Command run with each file path; exit status 0 skips the file, 1 checks it")
+	fs.StringVar(&cfg.minFileSize, "min-file-size", "", "Skip non-empty files smaller than this size, e.g. 16 or 1K")
+	fs.DurationVar(&cfg.modifiedWithin, "modified-within", 0, "Only check files modified within this duration, e.g. 10m (0 checks all files)")
+	fs.BoolVar(&opts.FullScan, "full-scan", false, "Read whole files even when the extension is a known text type")
+	fs.BoolVar(&cfg.respectGitAttributes, "respect-gitattributes", false, "Honor text, binary and eol settings from .gitattributes files")
+	fs.BoolVar(&cfg.respectGitIgnore, "respect-gitignore", false, "Skip files excluded by .gitignore files")
+	fs.BoolVar(&cfg.respectIgnoreFiles, "respect-ignore-files", false, "Skip files excluded by .gitignore, .ignore and .rgignore files")
+	fs.BoolVar(&opts.IgnoreCase, "ignore-case", false, "Match .gitignore, .gitattributes and -skip-dir patterns case-insensitively, as on macOS and Windows")
+	fs.BoolVar(&cfg.sortOutput, "sort", true, "Sort reported files by relative path (-sort=false keeps processing order)")
+	fs.BoolVar(&cfg.sortOutput, "depth-first", true, "Same as -sort")
+	fs.StringVar(&cfg.cacheFile, "cache", "", "Cache results in this file and skip unchanged files on later runs")
+	fs.BoolVar(&opts.Archive, "archive", false, "Check the text files inside .zip, .tar and .tar.gz arguments without unpacking them (check mode only)")
+	fs.BoolVar(&cfg.archiveStdin, "archive-stdin", false, "Check the text files in a tar or tar.gz stream read from stdin, reporting them by their path in the archive (check mode only)")
+	fs.IntVar(&opts.MaxErrors, "max-errors", 0, "Stop once more than N files failed to be read or written, reporting the partial summary (0: never stop)")
+	fs.DurationVar(&cfg.timeout, "timeout", 0, "Stop with an error and the partial summary if the run takes longer than this, e.g. 2m (0: no limit)")
+	fs.IntVar(&opts.Retries, "retries", defaultRetries, "Retry reads and writes failing with transient errors such as EIO up to N times")
+	fs.BoolVar(&cfg.selfCheck, "self-check", false, "Check the tool's own checkout in -self-check-dir, for the project's CI")
+	fs.StringVar(&cfg.selfCheckDir, "self-check-dir", defaultSelfCheckDir, "Directory checked by -self-check")
+	fs.StringVar(&cfg.tracePath, "trace-path", "", "Print each decision made about `path`, as JSON with -format json, without changing anything")
+	fs.StringVar(&cfg.explainSkip, "explain-skip", "", "Print whether `path` would be checked or skipped and why, without changing anything")
+	fs.StringVar(&cfg.cpuProfile, "cpuprofile", "", "Write a CPU profile of the run to `file`")
+	fs.StringVar(&cfg.memProfile, "memprofile", "", "Write a heap profile taken at the end of the run to `file`")
+	fs.BoolVar(&cfg.noLock, "no-lock", false, "Don't take the advisory lock file during -fix runs")
+	fs.DurationVar(&cfg.lockWait, "lock-wait", 0, "How long to wait for another -fix run's lock (0 fails immediately)")
+	fs.BoolVar(&cfg.readStdin, "stdin", false, "Read file and directory paths to check from stdin")
+	fs.StringVar(&cfg.stdinFormat, "stdin-format", stdinFormatLines, "Format of the -stdin path list: lines (one path per line) or json (an array of paths or of objects with a \"path\" field)")
+	fs.BoolVar(&cfg.trackedOnly, "tracked-only", false, "Check only the files tracked by git, as listed by git ls-files, instead of walking the directory")
+	fs.BoolVar(&cfg.staged, "staged", false, "Check the content staged in the git index instead of the working tree")
+	fs.BoolVar(&cfg.expandEnv, "expand-env", false, "Expand $VAR and ${VAR} in path arguments, failing on unset variables")
+	fs.BoolVar(&cfg.noRC, "no-rc", false, "Don't read the "+rcFileName+" file found upward from the checked directory")
+	fs.BoolVar(&cfg.init, "init", false, "Write a "+rcFileName+" describing every flag into the current directory and exit")
+	fs.BoolVar(&cfg.force, "force", false, "Let -init overwrite an existing "+rcFileName+" and fixes run on a git tree with uncommitted changes")
+	fs.BoolVar(&cfg.allowDirty, "allow-dirty", false, "Fix files even when the git tree has uncommitted changes")
+	fs.BoolVar(&cfg.showVersion, "version", false, "Print version information and exit")
+	fs.BoolVar(&cfg.stdinFix, "stdin-fix", false, "Read content from stdin and write the fixed content to stdout")
+	fs.BoolVar(&cfg.stdinCheck, "stdin-check", false, "Read content from stdin and print {\"ends_with_newline\": ..., \"last_line\": N} to stdout")
+
+	return fs
+}
+
+// printUsage writes the usage message followed by the flag defaults
+func printUsage(w io.Writer, fs *flag.FlagSet) {
+	name := fs.Name()
+	fmt.Fprintf(w, "Usage: %s [flags] [--] <repository_path>\n", name)
+	fmt.Fprintf(w, "       %s check|fix|list|version [flags] [--] <repository_path>\n", name)
+	fmt.Fprintf(w, "       %s [flags] <path>... | @<response_file>\n", name)
+	fmt.Fprintf(w, "       %s -stdin [flags] < paths\n", name)
+	fmt.Fprintf(w, "       %s -stdin-fix < input > output\n", name)
+	fmt.Fprintf(w, "       %s -stdin-check < input\n", name)
+	fmt.Fprintf(w, "       %s -archive-stdin [flags] < archive.tar.gz\n", name)
+	fmt.Fprintf(w, "       %s -init [-force]\n", name)
+	fmt.Fprintf(w, "       %s -staged [flags] [<repository_path>]\n", name)
+	fs.PrintDefaults()
+}
+
+// subcommands map the optional first argument to the flags it stands for
+var subcommands = map[string][]string{
+	"check":   nil,
+	"fix":     {"-fix"},
+	"list":    {"-format", formatList},
+	"version": {"-version"},
+}
+
+// expandSubcommand replaces a leading subcommand with its flags. Flags
+// after it still apply, so "fix -format json" and "-fix -format json"
+// are the same.
+func expandSubcommand(args []string) []string {
+	if len(args) == 0 {
+		return args
+	}
+	flags, ok := subcommands[args[0]]
+	if !ok {
+		return args
+	}
+	return append(slices.Clone(flags), args[1:]...)
+}
+
+// parseArgs parses and validates the command-line arguments, which may
+// start with a subcommand. As with the flag package, "--" ends the flags
+// so that later arguments beginning with a dash are treated as paths.
+// When several paths are given, the command line is parsed again for
+// each of them with its own .newlinerc.
+func parseArgs(args []string) (*cliConfig, error) {
+	args = expandSubcommand(args)
+	cfg, err := parseConfig(args, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cfg.args) > 1 && !cfg.noRC {
+		for _, path := range cfg.args {
+			rootCfg, err := parseConfig(args, path)
+			if err != nil {
+				return nil, err
+			}
+			cfg.roots = append(cfg.roots, root{path: path, opts: rootCfg.opts})
+		}
+	}
+
+	// -explain-skip and -trace-path must not rewrite the cache, so it is
+	// not loaded at all
+	if cfg.cacheFile != "" && cfg.explainSkip == "" && cfg.tracePath == "" {
+		cache, err := loadCache(cfg.cacheFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.opts.Cache = cache
+	}
+
+	return cfg, nil
+}
+
+// parseConfig parses the command line with the .newlinerc found from
+// rootPath, or from rcStartDir of the paths when rootPath is empty
+func parseConfig(args []string, rootPath string) (*cliConfig, error) {
+	cfg := &cliConfig{}
+
+	fs := newFlagSet(cfg)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, err
+		}
+		return nil, errUsage
+	}
+	args = fs.Args()
+	if cfg.expandEnv {
+		expanded, err := expandEnvPaths(args)
+		if err != nil {
+			return nil, err
+		}
+		args = expanded
+	}
+	cfg.listedPaths = slices.ContainsFunc(args, isResponseFile)
+	args, err := expandResponseFiles(args)
+	if err != nil {
+		return nil, err
+	}
+	cfg.args = args
+	if !cfg.noRC {
+		rcDir := rcStartDir(args)
+		if rootPath != "" {
+			rcDir = rcStartDir([]string{rootPath})
+		}
+		if err := applyRCFile(fs, rcDir); err != nil {
+			return nil, err
+		}
+	}
+	cfg.opts.WalkOrder = !cfg.sortOutput
+	cfg.opts.SkipDirs = skipDirSet(!cfg.noDefaultSkip, cfg.skipDirs)
+	if cfg.respectGitAttributes {
+		cfg.opts.GitAttributes = newGitAttributes(cfg.opts.IgnoreCase)
+	}
+	switch {
+	case cfg.respectIgnoreFiles:
+		cfg.opts.Ignore = newIgnoreRules(allIgnoreFiles, cfg.opts.IgnoreCase)
+	case cfg.respectGitIgnore:
+		cfg.opts.Ignore = newIgnoreRules(gitIgnoreFiles, cfg.opts.IgnoreCase)
+	}
+
+	if cfg.interactive {
+		if cfg.readStdin || cfg.stdinFix {
+			return nil, errors.New("-interactive reads answers from stdin and cannot be combined with -stdin or -stdin-fix")
+		}
+		cfg.opts.Fix = true
+		cfg.opts.Confirm = newPrompter(os.Stdin, os.Stderr).confirm
+	}
+
+	if cfg.strict {
+		if err := applyStrict(fs, &cfg.opts); err != nil {
+			return nil, err
+		}
+	}
+
+	if !isValidFormat(cfg.opts.Format) {
+		return nil, fmt.Errorf("unknown format %q", cfg.opts.Format)
+	}
+	if !isValidFormat(cfg.opts.ReportFormat) {
+		return nil, fmt.Errorf("unknown report format %q", cfg.opts.ReportFormat)
+	}
+	if !isValidStdinFormat(cfg.stdinFormat) {
+		return nil, fmt.Errorf("unknown -stdin-format %q", cfg.stdinFormat)
+	}
+	if !isValidSummaryStream(cfg.opts.SummaryStream) {
+		return nil, fmt.Errorf("unknown -summary-stream %q", cfg.opts.SummaryStream)
+	}
+	if !isValidSummaryMode(cfg.opts.Summary) {
+		return nil, fmt.Errorf("unknown -summary mode %q", cfg.opts.Summary)
+	}
+	if !isValidPathStyle(cfg.opts.PathStyle) {
+		return nil, fmt.Errorf("unknown -paths style %q", cfg.opts.PathStyle)
+	}
+	if cfg.opts.ReportFormat != "" && cfg.opts.ReportFile == "" {
+		return nil, errors.New("-report-format requires -report-file")
+	}
+	if !isValidOutputEncoding(cfg.opts.OutputEncoding) {
+		return nil, fmt.Errorf("unknown -output-encoding %q", cfg.opts.OutputEncoding)
+	}
+	if cfg.opts.OutputEncoding != outputLF && cfg.opts.ReportFile == "" {
+		return nil, errors.New("-output-encoding requires -report-file")
+	}
+
+	mode, err := parseFileMode(cfg.fileMode)
+	if err != nil {
+		return nil, err
+	}
+	cfg.opts.FileMode = mode
+
+	if cfg.skipGenerated {
+		pattern, err := regexp.Compile(cfg.generatedPattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -generated-pattern: %w", err)
+		}
+		cfg.opts.GeneratedPattern = pattern
+	}
+
+	if cfg.skipContentRegex != "" {
+		pattern, err := regexp.Compile(cfg.skipContentRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -skip-content-regex: %w", err)
+		}
+		cfg.opts.SkipContent = pattern
+	}
+
+	if cfg.encoding != "" {
+		enc, err := parseEncoding(cfg.encoding)
+		if err != nil {
+			return nil, err
+		}
+		cfg.opts.Encoding = enc
+	}
+
+	if cfg.summaryTemplate != "" {
+		tmpl, err := parseSummaryTemplate(cfg.summaryTemplate)
+		if err != nil {
+			return nil, err
+		}
+		cfg.opts.SummaryTemplate = tmpl
+	}
+
+	if cfg.pathMap != "" {
+		m, err := loadPathMap(cfg.pathMap)
+		if err != nil {
+			return nil, err
+		}
+		cfg.opts.PathMap = m
+	}
+
+	if cfg.rejectCRLFEOF {
+		if cfg.eolByExtension || cfg.eolMap != "" {
+			return nil, errors.New("-reject-crlf-eof requires LF for every file and cannot be combined with -eol-by-extension or -eol-map")
+		}
+		cfg.opts.EOL = eolLF
+	}
+
+	if cfg.eolByExtension || cfg.eolMap != "" {
+		mapping, err := parseExtEOL(cfg.eolMap)
+		if err != nil {
+			return nil, err
+		}
+		cfg.opts.ExtEOL = mapping
+	}
+
+	if cfg.require != "" {
+		required, err := parseRequired(cfg.require)
+		if err != nil {
+			return nil, err
+		}
+		cfg.opts.Required = required
+	}
+
+	if cfg.blankLinePolicy != "" {
+		policy, err := parseBlankLinePolicy(cfg.blankLinePolicy)
+		if err != nil {
+			return nil, err
+		}
+		cfg.opts.BlankLinePolicy = policy
+	}
+
+	if cfg.skipCommand != "" {
+		command, err := newSkipCommand(cfg.skipCommand)
+		if err != nil {
+			return nil, err
+		}
+		cfg.opts.SkipCommand = command
+	}
+
+	if cfg.minFileSize != "" {
+		size, err := parseSize(cfg.minFileSize)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -min-file-size: %w", err)
+		}
+		cfg.opts.MinFileSize = size
+	}
+
+	if cfg.opts.EOLMajority && cfg.opts.writesFiles() {
+		return nil, errors.New("-eol-majority only reports final newline styles and cannot be combined with -fix, -fix-blank-lines or -fix-whitespace")
+	}
+
+	if cfg.opts.Archive && cfg.opts.writesFiles() {
+		return nil, errors.New("-archive only checks archives and cannot be combined with -fix, -fix-blank-lines or -fix-whitespace")
+	}
+
+	if cfg.trackedOnly && (cfg.staged || cfg.readStdin || cfg.stdinFix || cfg.opts.Archive || len(cfg.args) > 1) {
+		return nil, errors.New("-tracked-only takes a single directory and cannot be combined with -staged, -stdin, -stdin-fix or -archive")
+	}
+
+	if cfg.selfCheck && (len(cfg.args) > 0 || cfg.opts.writesFiles() || cfg.readStdin || cfg.stdinFix || cfg.staged) {
+		return nil, errors.New("-self-check only verifies -self-check-dir and cannot be combined with paths, -fix, -stdin, -stdin-fix or -staged")
+	}
+
+	if cfg.archiveStdin && (len(cfg.args) > 0 || cfg.opts.writesFiles() || cfg.readStdin || cfg.stdinFix || cfg.stdinCheck || cfg.staged) {
+		return nil, errors.New("-archive-stdin only checks the stream on stdin and cannot be combined with paths, -fix, -stdin, -stdin-fix, -stdin-check or -staged")
+	}
+
+	if cfg.init && len(cfg.args) > 0 {
+		return nil, errors.New("-init writes " + rcFileName + " into the current directory and takes no paths")
+	}
+
+	if cfg.confirm {
+		if !cfg.opts.writesFiles() {
+			return nil, errors.New("-confirm requires -fix, -fix-blank-lines or -fix-whitespace")
+		}
+		if cfg.interactive || cfg.readStdin || cfg.stdinFix || cfg.staged || cfg.selfCheck {
+			return nil, errors.New("-confirm reads its answer from stdin and cannot be combined with -interactive, -stdin, -stdin-fix, -staged or -self-check")
+		}
+	}
+
+	if cfg.stdinCheck && (cfg.opts.writesFiles() || cfg.stdinFix || cfg.readStdin) {
+		return nil, errors.New("-stdin-check only checks and cannot be combined with -fix, -stdin or -stdin-fix")
+	}
+
+	if cfg.outputPatch != "" {
+		if !cfg.opts.writesFiles() {
+			return nil, errors.New("-output-patch requires -fix, -fix-blank-lines or -fix-whitespace")
+		}
+		if cfg.staged {
+			return nil, errors.New("-output-patch cannot be combined with -staged, which fixes the index")
+		}
+		cfg.opts.Patch = newPatchSet(cfg.outputPatch)
+	}
+
+	if cfg.opts.Quarantine != "" && cfg.staged {
+		return nil, errors.New("-quarantine cannot be combined with -staged, which fixes the index")
+	}
+
+	if cfg.opts.FinalNewlines < 0 {
+		return nil, fmt.Errorf("invalid -final-newlines %d: must be at least 1", cfg.opts.FinalNewlines)
+	}
+
+	if cfg.opts.MaxErrors < 0 {
+		return nil, fmt.Errorf("invalid -max-errors %d: must not be negative", cfg.opts.MaxErrors)
+	}
+
+	if cfg.opts.MaxReport < 0 {
+		return nil, fmt.Errorf("invalid -max-report %d: must not be negative", cfg.opts.MaxReport)
+	}
+	if cfg.opts.TimingTop < 0 {
+		return nil, fmt.Errorf("invalid -timing-top %d: must not be negative", cfg.opts.TimingTop)
+	}
+
+	if cfg.timeout < 0 {
+		return nil, fmt.Errorf("invalid -timeout %s: must not be negative", cfg.timeout)
+	}
+
+	if cfg.opts.Retries < 0 {
+		return nil, fmt.Errorf("invalid -retries %d: must not be negative", cfg.opts.Retries)
+	}
+
+	if cfg.modifiedWithin < 0 {
+		return nil, fmt.Errorf("invalid -modified-within %s: must not be negative", cfg.modifiedWithin)
+	}
+	if cfg.modifiedWithin > 0 {
+		cfg.opts.ModifiedSince = time.Now().Add(-cfg.modifiedWithin)
+	}
+
+	if cfg.textControlBytes != "" {
+		controls, err := parseControlSet(cfg.textControlBytes)
+		if err != nil {
+			return nil, err
+		}
+		cfg.opts.TextControls = controls
+	}
+
+	return cfg, nil
+}
+
+// run executes the parsed command line and returns the exit code
+func run(cfg *cliConfig) int {
+	stopProfiling, err := startProfiling(cfg.cpuProfile, cfg.memProfile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	code, finished := executeWithTimeout(cfg)
+
+	if err := stopProfiling(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	if !finished {
+		// The abandoned run may still be updating the cache
+		return code
+	}
+
+	if cfg.opts.Patch != nil {
+		if err := cfg.opts.Patch.save(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			code = 1
+		}
+	}
+
+	if cfg.opts.Cache != nil {
+		if err := cfg.opts.Cache.save(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+
+	return code
+}
+
+// timeoutGrace is how long a run past -timeout may take to stop by
+// itself, e.g. while finishing the current file, before it is abandoned
+const timeoutGrace = 5 * time.Second
+
+// executeWithTimeout runs execute under -timeout. The run normally stops
+// itself between files and reports its partial summary; one stuck in a
+// single operation, such as a read from a hung network filesystem, is
+// abandoned after timeoutGrace and the returned flag is false. Fixes are
+// written through writeFile, so abandoning one mid-write leaves the file
+// as it was.
+func executeWithTimeout(cfg *cliConfig) (int, bool) {
+	if cfg.timeout <= 0 {
+		return execute(cfg), true
+	}
+
+	cause := fmt.Errorf("%w: -timeout %s passed before every file was checked", errTimeout, cfg.timeout)
+	ctx, cancel := context.WithTimeoutCause(context.Background(), cfg.timeout, cause)
+	defer cancel()
+	cfg.opts.Context = ctx
+
+	done := make(chan int, 1)
+	go func() { done <- execute(cfg) }()
+
+	select {
+	case code := <-done:
+		return code, true
+	case <-time.After(cfg.timeout + timeoutGrace):
+		fmt.Fprintf(os.Stderr, "Error: %v; the run is stuck and was abandoned\n", cause)
+		return 1, false
+	}
+}
+
+// execute dispatches to the mode selected on the command line
+func execute(cfg *cliConfig) int {
+	opts := cfg.opts
+	args := cfg.args
+
+	if cfg.showVersion {
+		fmt.Println(versionString())
+		return 0
+	}
+
+	if cfg.init {
+		return runInit(cfg.force, os.Stdout)
+	}
+
+	if cfg.explainSkip != "" {
+		return explainSkip(cfg.explainSkip, opts, os.Stdout)
+	}
+
+	if cfg.tracePath != "" {
+		return runTracePath(cfg.tracePath, opts, os.Stdout)
+	}
+
+	if cfg.selfCheck {
+		return runSelfCheck(cfg)
+	}
+
+	if cfg.stdinFix && len(args) == 0 {
+		return runStdinFix(os.Stdin, os.Stdout, opts)
+	}
+
+	if cfg.stdinCheck && len(args) == 0 {
+		return runStdinCheck(os.Stdin, os.Stdout, opts)
+	}
+
+	// Fixes on a tree with uncommitted changes would be hard to review
+	// apart from them. -staged fixes the index and -output-patch leaves
+	// the tree alone. -quarantine alone doesn't write in place, but with
+	// -fix the originals are still rewritten. Paths listed on stdin or in
+	// an @file are picked on purpose, typically the changed files
+	// themselves, as in git diff --name-only | check-new-line -stdin -fix.
+	if opts.writesFiles() && opts.Patch == nil && !cfg.staged && !cfg.readStdin && !cfg.listedPaths && !cfg.allowDirty && !cfg.force {
+		for _, dir := range fixDirs(cfg) {
+			if err := dirtyTree(dir); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return 1
+			}
+		}
+	}
+
+	// Keep concurrent fix runs from writing the same tree, however the
+	// paths are given
+	if opts.writesFiles() && !cfg.noLock {
+		release, err := acquireLocks(fixDirs(cfg), cfg.lockWait)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		defer func() {
+			if err := release(); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+		}()
+	}
+
+	if cfg.readStdin && len(args) == 0 {
+		return runStdinPaths(os.Stdin, cfg)
+	}
+
+	if cfg.archiveStdin {
+		result, err := processArchiveStream(os.Stdin, opts)
+		return report(result, err, opts)
+	}
+
+	if cfg.staged && len(args) <= 1 {
+		return runStaged(cfg)
+	}
+
+	// Ask before anything is written, whichever way the paths are processed
+	if cfg.confirm && len(args) > 0 {
+		ok, err := confirmFixes(cfg, os.Stdin, os.Stderr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		if !ok {
+			fmt.Fprintln(os.Stderr, "No files were changed")
+			return 0
+		}
+	}
+
+	if len(cfg.roots) > 0 {
+		result, err := processRoots(cfg.roots, opts)
+		return report(result, err, opts)
+	}
+	// Archives are not directories or plain files, so an archive given
+	// with -archive goes through processPaths
+	if len(args) > 1 || (opts.Archive && len(args) == 1 && archiveKind(args[0]) != "") {
+		result, err := processPaths(args, opts)
+		return report(result, err, opts)
+	}
+
+	if len(args) == 0 {
+		printUsage(os.Stderr, newFlagSet(&cliConfig{}))
+		return 1
+	}
+
+	repoPath := args[0]
+
+	// Check if path exists
+	info, err := os.Stat(repoPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	// Process repository
+	var result *RepoResult
+	show := true
+	switch {
+	case info.IsDir() && cfg.trackedOnly:
+		result, err = processTracked(repoPath, opts)
+	case info.IsDir():
+		result, err = processRepository(repoPath, opts)
+	default:
+		result, show, err = processSingleFile(repoPath, opts)
+	}
+	if err == nil && !show {
+		return exitCode(result, opts)
+	}
+
+	return report(result, err, opts)
+}
+
+// runStaged checks the git index of the repository named on the command
+// line, or of the current directory
+func runStaged(cfg *cliConfig) int {
+	opts := cfg.opts
+	dir := "."
+	if len(cfg.args) == 1 {
+		dir = cfg.args[0]
+	}
+
+	result, err := processStaged(dir, opts)
+
+	return report(result, err, opts)
+}
+
+// report writes the result of a completed run and returns the exit code
+func report(result *RepoResult, err error, opts Options) int {
+	// A run stopped by -max-errors still reports what it got through
+	if result != nil {
+		if werr := writeResult(result, opts); err == nil {
+			err = werr
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	return exitCode(result, opts)
+}
+
+// processSingleFile processes one explicitly named file. Since an
+// explicit target that is skipped would otherwise produce a confusing
+// clean summary, the skip reason is reported on stderr instead and the
+// returned flag tells the caller not to write a report. The file is
+// displayed as given, cleaned, since a path relative to the file itself
+// would only be ".". Like in a walk, only the part below the root, here
+// the file's name, decides whether it is hidden.
+func processSingleFile(path string, opts Options) (*RepoResult, bool, error) {
+	display := filepath.ToSlash(filepath.Clean(path))
+
+	if reason := opts.pathSkipReason(filepath.Base(display)); reason != "" {
+		fmt.Fprintf(os.Stderr, "Skipped %s: %s\n", display, reason)
+		return newRepoResult(opts), false, nil
+	}
+
+	result, err := processPaths([]string{path}, opts)
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch {
+	case len(result.Binary) > 0 && !opts.ErrorOnBinaryContent:
+		fmt.Fprintf(os.Stderr, "Skipped %s: content looks binary\n", display)
+	case len(result.Generated) > 0:
+		fmt.Fprintf(os.Stderr, "Skipped %s: generated-code marker\n", display)
+	}
+
+	return result, true, nil
+}
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		expectedFix  bool
+		expectedArgs []string
+	}{
+		{
+			name:         "通常のパス",
+			args:         []string{"-fix", "src"},
+			expectedFix:  true,
+			expectedArgs: []string{"src"},
+		},
+		{
+			name:         "--の後のダッシュで始まるパス",
+			args:         []string{"-fix", "--", "-weird"},
+			expectedFix:  true,
+			expectedArgs: []string{"-weird"},
+		},
+		{
+			name:         "--の後のフラグ形式の引数",
+			args:         []string{"--", "-fix"},
+			expectedFix:  false,
+			expectedArgs: []string{"-fix"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := parseArgs(tt.args)
+			if err != nil {
+				t.Fatalf("parseArgs()でエラーが発生: %v", err)
+			}
+
+			if cfg.opts.Fix != tt.expectedFix {
+				t.Errorf("Fix = %v, expected %v", cfg.opts.Fix, tt.expectedFix)
+			}
+			if len(cfg.args) != len(tt.expectedArgs) || (len(cfg.args) > 0 && cfg.args[0] != tt.expectedArgs[0]) {
+				t.Errorf("args = %v, expected %v", cfg.args, tt.expectedArgs)
+			}
+		})
+	}
+}
+
+func TestParseArgsSubcommands(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		expectedFix  bool
+		format       string
+		version      bool
+		expectedArgs []string
+	}{
+		{name: "check", args: []string{"check", "src"}, format: formatText, expectedArgs: []string{"src"}},
+		{name: "fix", args: []string{"fix", "src"}, expectedFix: true, format: formatText, expectedArgs: []string{"src"}},
+		{name: "fixと他のフラグ", args: []string{"fix", "-format", "json", "src"}, expectedFix: true, format: formatJSON, expectedArgs: []string{"src"}},
+		{name: "list", args: []string{"list", "src"}, format: formatList, expectedArgs: []string{"src"}},
+		{name: "version", args: []string{"version"}, format: formatText, version: true},
+		{name: "従来のフラグ", args: []string{"-fix", "src"}, expectedFix: true, format: formatText, expectedArgs: []string{"src"}},
+		{name: "サブコマンドと同名のパス", args: []string{"--", "fix"}, format: formatText, expectedArgs: []string{"fix"}},
+		{name: "2番目の引数はパス", args: []string{"check", "fix"}, format: formatText, expectedArgs: []string{"fix"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := parseArgs(tt.args)
+			if err != nil {
+				t.Fatalf("parseArgs()でエラーが発生: %v", err)
+			}
+
+			if cfg.opts.Fix != tt.expectedFix || cfg.opts.Format != tt.format || cfg.showVersion != tt.version {
+				t.Errorf("オプションが期待値と異なります: fix=%v format=%q version=%v", cfg.opts.Fix, cfg.opts.Format, cfg.showVersion)
+			}
+			if !slices.Equal(cfg.args, tt.expectedArgs) {
+				t.Errorf("args = %v, expected %v", cfg.args, tt.expectedArgs)
+			}
+		})
+	}
+}
+
+func TestParseArgsDoubleDash(t *testing.T) {
+	tests := []struct {
+		name   string
+		single []string
+		double []string
+	}{
+		{name: "真偽値", single: []string{"-fix", "."}, double: []string{"--fix", "."}},
+		{name: "=で値を指定", single: []string{"-format=json", "."}, double: []string{"--format=json", "."}},
+		{name: "空白で値を指定", single: []string{"-format", "json", "."}, double: []string{"--format", "json", "."}},
+		{name: "サイズ", single: []string{"-min-file-size", "1K", "."}, double: []string{"--min-file-size=1K", "."}},
+		{name: "時間", single: []string{"-timeout=2s", "."}, double: []string{"--timeout", "2s", "."}},
+		{name: "真偽値を明示", single: []string{"-fix=false", "."}, double: []string{"--fix=false", "."}},
+		{name: "混在", single: []string{"-fix", "-format", "json", "."}, double: []string{"--fix", "-format=json", "."}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			single, err := parseArgs(tt.single)
+			if err != nil {
+				t.Fatalf("parseArgs(%v)でエラーが発生: %v", tt.single, err)
+			}
+			double, err := parseArgs(tt.double)
+			if err != nil {
+				t.Fatalf("parseArgs(%v)でエラーが発生: %v", tt.double, err)
+			}
+
+			if single.opts.Fix != double.opts.Fix || single.opts.Format != double.opts.Format ||
+				single.opts.MinFileSize != double.opts.MinFileSize || single.timeout != double.timeout {
+				t.Errorf("オプションが一致しません: %v と %v", tt.single, tt.double)
+			}
+			if len(double.args) != 1 || double.args[0] != "." {
+				t.Errorf("args = %v, expected [.]", double.args)
+			}
+		})
+	}
+}
+
+func TestParseArgsSortAlias(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		walkOrder bool
+	}{
+		{name: "デフォルト", args: []string{"."}, walkOrder: false},
+		{name: "-sort=false", args: []string{"-sort=false", "."}, walkOrder: true},
+		{name: "-depth-first=false", args: []string{"-depth-first=false", "."}, walkOrder: true},
+		{name: "-depth-first", args: []string{"-sort=false", "-depth-first", "."}, walkOrder: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := parseArgs(tt.args)
+			if err != nil {
+				t.Fatalf("parseArgs()でエラーが発生: %v", err)
+			}
+			if cfg.opts.WalkOrder != tt.walkOrder {
+				t.Errorf("WalkOrder = %v, expected %v", cfg.opts.WalkOrder, tt.walkOrder)
+			}
+		})
+	}
+}
+
+func TestParseArgsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "未対応の形式", args: []string{"-format", "xml", "."}},
+		{name: "不正なファイルモード", args: []string{"-file-mode", "999", "."}},
+		{name: "不正な正規表現", args: []string{"-skip-generated", "-generated-pattern", "(", "."}},
+		{name: "未対応のレポート形式", args: []string{"-report-file", "r.out", "-report-format", "xml", "."}},
+		{name: "レポートファイルなしのレポート形式", args: []string{"-report-format", "sarif", "."}},
+		{name: "不正なサイズ", args: []string{"-min-file-size", "10XB", "."}},
+		{name: "負の期間", args: []string{"-modified-within", "-5m", "."}},
+		{name: "不正な制御文字", args: []string{"-text-control-bytes", "9,x", "."}},
+		{name: "負のリトライ回数", args: []string{"-retries", "-1", "."}},
+		{name: "負のエラー上限", args: []string{"-max-errors", "-1", "."}},
+		{name: "追跡ファイルと複数のパス", args: []string{"-tracked-only", "a", "b"}},
+		{name: "追跡ファイルとステージ", args: []string{"-tracked-only", "-staged"}},
+		{name: "隔離とステージ", args: []string{"-quarantine", "q", "-staged"}},
+		{name: "負の表示件数", args: []string{"-timing", "-timing-top", "-1", "."}},
+		{name: "負の改行数", args: []string{"-final-newlines", "-1", "."}},
+		{name: "修正なしのパッチ出力", args: []string{"-output-patch", "fixes.diff", "."}},
+		{name: "パス付きの-init", args: []string{"-init", "."}},
+		{name: "不正な空行ポリシー", args: []string{"-blank-line-policy", ".md=warn", "."}},
+		{name: "-archive-stdinと-fix", args: []string{"-archive-stdin", "-fix"}},
+		{name: "-archive-stdinとパス", args: []string{"-archive-stdin", "."}},
+		{name: "-reject-crlf-eofと-eol-by-extension", args: []string{"-reject-crlf-eof", "-eol-by-extension", "."}},
+		{name: "負の-max-report", args: []string{"-max-report", "-1", "."}},
+		{name: "不明なサマリーの出力先", args: []string{"-summary-stream", "stdin", "."}},
+		{name: "-eol-majorityと-fix", args: []string{"-eol-majority", "-fix", "."}},
+		{name: "親ディレクトリの-require", args: []string{"-require", "../LICENSE", "."}},
+		{name: "不明な出力エンコーディング", args: []string{"-output-encoding", "utf-16", "-report-file", "r.txt", "."}},
+		{name: "-report-fileのない-output-encoding", args: []string{"-output-encoding", "crlf", "."}},
+		{name: "負のタイムアウト", args: []string{"-timeout", "-1s", "."}},
+		{name: "標準入力のチェックと修正", args: []string{"-stdin-check", "-fix"}},
+		{name: "未対応の標準入力形式", args: []string{"-stdin", "-stdin-format", "csv"}},
+		{name: "修正なしの一括確認", args: []string{"-confirm", "."}},
+		{name: "一括確認と対話モード", args: []string{"-confirm", "-interactive", "."}},
+		{name: "未対応のサマリー形式", args: []string{"-summary", "tree", "."}},
+		{name: "不正な内容の正規表現", args: []string{"-skip-content-regex", "(", "."}},
+		{name: "セルフチェックとパス", args: []string{"-self-check", "."}},
+		{name: "セルフチェックと修正", args: []string{"-self-check", "-fix"}},
+		{name: "未対応のエンコーディング", args: []string{"-encoding", "ebcdic", "."}},
+		{name: "未対応のパス形式", args: []string{"-paths", "home-rel", "."}},
+		{name: "アーカイブの修正", args: []string{"-archive", "-fix", "bundle.zip"}},
+		{name: "不正なEOLマッピング", args: []string{"-eol-map", ".sh=cr", "."}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseArgs(tt.args); err == nil {
+				t.Errorf("parseArgs(%v)でエラーが発生しませんでした", tt.args)
+			}
+		})
+	}
+}
+
+func TestRunDashPrefixedPath(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Chdir(tempDir)
+
+	if err := os.MkdirAll("-weird", 0o755); err != nil {
+		t.Fatalf("ディレクトリの作成に失敗: %v", err)
+	}
+	testFile := filepath.Join("-weird", "file.txt")
+	if err := os.WriteFile(testFile, []byte("no newline"), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+
+	cfg, err := parseArgs([]string{"-fix", "-no-lock", "--", "-weird"})
+	if err != nil {
+		t.Fatalf("parseArgs()でエラーが発生: %v", err)
+	}
+
+	if code := run(cfg); code != 0 {
+		t.Errorf("run() = %d, expected 0", code)
+	}
+
+	content, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("テストファイルの読み込みに失敗: %v", err)
+	}
+	if string(content) != "no newline\n" {
+		t.Errorf("ダッシュで始まるディレクトリ内のファイルが修正されていません: %q", string(content))
+	}
+}
+
+func TestProcessSingleFile(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Chdir(tempDir)
+
+	files := map[string]string{
+		"notes.txt":         "no newline",
+		"image.png":         "fake png",
+		"data.txt":          string([]byte{0x00, 0x01, 0x02}),
+		".env":              "no newline",
+		".work/proj/a.txt":  "no newline",
+		".work/proj/.b.txt": "no newline",
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatalf("ディレクトリの作成に失敗: %v", err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name          string
+		path          string
+		expectedTotal int
+		expectedFixed int
+	}{
+		{name: "テキストファイル", path: "notes.txt", expectedTotal: 1, expectedFixed: 1},
+		{name: "バイナリ拡張子", path: "image.png", expectedTotal: 0, expectedFixed: 0},
+		{name: "バイナリの内容", path: "data.txt", expectedTotal: 1, expectedFixed: 0},
+		{name: "隠しファイル", path: ".env", expectedTotal: 0, expectedFixed: 0},
+		{name: "隠しディレクトリ内のファイル", path: ".work/proj/a.txt", expectedTotal: 1, expectedFixed: 1},
+		{name: "隠しディレクトリ内の隠しファイル", path: ".work/proj/.b.txt", expectedTotal: 0, expectedFixed: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _, err := processSingleFile(tt.path, Options{Fix: true})
+			if err != nil {
+				t.Fatalf("processSingleFile()でエラーが発生: %v", err)
+			}
+			if result.Total != tt.expectedTotal || len(result.Fixed) != tt.expectedFixed {
+				t.Errorf("total=%d, fixed=%d, expected total=%d, fixed=%d",
+					result.Total, len(result.Fixed), tt.expectedTotal, tt.expectedFixed)
+			}
+		})
+	}
+}
+
+func TestProcessSingleFileDisplayPath(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Chdir(tempDir)
+
+	if err := os.Mkdir("sub", 0o755); err != nil {
+		t.Fatalf("ディレクトリの作成に失敗: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join("sub", "notes.txt"), []byte("no newline"), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+	absPath := filepath.Join(tempDir, "sub", "notes.txt")
+
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{name: "指定したまま", path: "sub/notes.txt", expected: "sub/notes.txt"},
+		{name: "カレントディレクトリ付き", path: "./sub/notes.txt", expected: "sub/notes.txt"},
+		{name: "冗長なパス", path: "sub/../sub/notes.txt", expected: "sub/notes.txt"},
+		{name: "絶対パス", path: absPath, expected: filepath.ToSlash(absPath)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _, err := processSingleFile(tt.path, Options{})
+			if err != nil {
+				t.Fatalf("processSingleFile()でエラーが発生: %v", err)
+			}
+			if len(result.Problematic) != 1 || result.Problematic[0] != tt.expected {
+				t.Errorf("表示されるパスが期待値と異なります: got %v, expected %q", result.Problematic, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExecuteWithTimeout(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "a.txt"), []byte("ok\n"), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+
+	cfg, err := parseArgs([]string{"-timeout", "1ns", tempDir})
+	if err != nil {
+		t.Fatalf("parseArgs()でエラーが発生: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+
+	code, finished := executeWithTimeout(cfg)
+	if !finished {
+		t.Fatal("実行が打ち切られました")
+	}
+	if code != 1 {
+		t.Errorf("タイムアウト時の終了コード = %d, expected 1", code)
+	}
+}
+
+func TestDetailedExitUsage(t *testing.T) {
+	usage := newFlagSet(&cliConfig{}).Lookup("detailed-exit").Usage
+
+	// 終了コードのすべてのビットが説明されている
+	for _, bit := range []int{exitMissingBit, exitTrailingBit, exitErrorBit, exitBOMBit, exitRequiredBit} {
+		if !strings.Contains(usage, fmt.Sprintf("(%d)", bit)) {
+			t.Errorf("ビット%dが説明にありません: %s", bit, usage)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// confinedReason is the skip reason for links -confine-to-root leaves alone
+const confinedReason = "symlink outside the root"
+
+// escapesRoot reports whether the symlink at path resolves to a file
+// outside root. Links that can't be resolved are left for the read to
+// report.
+func escapesRoot(root, path string) bool {
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return false
+	}
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return false
+	}
+	if realRoot, err = filepath.Abs(realRoot); err != nil {
+		return false
+	}
+	if target, err = filepath.Abs(target); err != nil {
+		return false
+	}
+
+	rel, err := filepath.Rel(realRoot, target)
+	return err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// confineSymlink skips relPath when it is a symlink resolving outside
+// root and ConfineToRoot is set. It returns false when the file has been
+// recorded as skipped.
+func confineSymlink(root, path, relPath string, info os.FileInfo, opts Options, result *RepoResult) bool {
+	if !opts.ConfineToRoot || info.Mode()&os.ModeSymlink == 0 || !escapesRoot(root, path) {
+		return true
+	}
+	result.addSkipped(relPath, confinedReason)
+	result.Confined = append(result.Confined, relPath)
+	return false
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProcessRepositoryConfineToRoot(t *testing.T) {
+	tempDir := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "target.txt")
+	if err := os.WriteFile(outside, []byte("no newline"), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+	inside := filepath.Join(tempDir, "inside.txt")
+	if err := os.WriteFile(inside, []byte("no newline"), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+	if err := os.Symlink(outside, filepath.Join(tempDir, "outside-link.txt")); err != nil {
+		t.Skipf("シンボリックリンクを作成できません: %v", err)
+	}
+	if err := os.Symlink("inside.txt", filepath.Join(tempDir, "inside-link.txt")); err != nil {
+		t.Fatalf("シンボリックリンクの作成に失敗: %v", err)
+	}
+
+	opts := Options{ConfineToRoot: true, Verbose: true}
+	result, err := processRepository(tempDir, opts)
+	if err != nil {
+		t.Fatalf("processRepository()でエラーが発生: %v", err)
+	}
+
+	if len(result.Confined) != 1 || result.Confined[0] != "outside-link.txt" {
+		t.Errorf("ルート外へのリンクがスキップされていません: %v", result.Confined)
+	}
+	if result.Total != 2 {
+		t.Errorf("チェックしたファイル数 = %d, expected 2", result.Total)
+	}
+
+	var buf bytes.Buffer
+	writeTextReport(&buf, result, opts)
+	if !strings.Contains(buf.String(), "Symlinks outside the root skipped: 1\n  - outside-link.txt") {
+		t.Errorf("スキップしたリンクが表示されていません:\n%s", buf.String())
+	}
+}
+
+func TestParseArgsConfineToRoot(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected bool
+	}{
+		{name: "デフォルトで有効", args: []string{"."}, expected: true},
+		{name: "無効化", args: []string{"-confine-to-root=false", "."}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := parseArgs(tt.args)
+			if err != nil {
+				t.Fatalf("parseArgs()でエラーが発生: %v", err)
+			}
+			if cfg.opts.ConfineToRoot != tt.expected {
+				t.Errorf("ConfineToRoot = %v, expected %v", cfg.opts.ConfineToRoot, tt.expected)
+			}
+		})
+	}
+}
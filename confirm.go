@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// previewOptions returns opts for the check pass -confirm runs before a
+// fix run: the same checks, with every write turned off
+func previewOptions(opts Options) Options {
+	opts.Fix, opts.FixBlankLines, opts.FixWhitespace = false, false, false
+	opts.Quarantine = ""
+	opts.Patch = nil
+	opts.Confirm = nil
+	opts.Cache = nil
+	opts.Timing = false
+	return opts
+}
+
+// previewFixes checks the paths of cfg without changing anything
+func previewFixes(cfg *cliConfig) (*RepoResult, error) {
+	opts := previewOptions(cfg.opts)
+	switch {
+	case len(cfg.roots) > 0:
+		roots := make([]root, len(cfg.roots))
+		for i, r := range cfg.roots {
+			roots[i] = root{path: r.path, opts: previewOptions(r.opts)}
+		}
+		return processRoots(roots, opts)
+	case cfg.trackedOnly:
+		return processTracked(cfg.args[0], opts)
+	default:
+		return processPaths(cfg.args, opts)
+	}
+}
+
+// pendingFixes lists the files of a preview result that the fixes enabled
+// in opts would rewrite
+func pendingFixes(result *RepoResult, opts Options) []string {
+	var lists [][]string
+	if opts.Fix {
+		lists = append(lists, result.Problematic)
+	}
+	if opts.FixBlankLines {
+		lists = append(lists, result.TrailingBlankLines)
+	}
+	if opts.FixWhitespace {
+		lists = append(lists, result.TrailingWhitespace)
+	}
+	if opts.Fix && opts.NoBOM {
+		lists = append(lists, result.UTF8BOM)
+	}
+
+	seen := map[string]bool{}
+	var files []string
+	for _, list := range lists {
+		for _, file := range list {
+			if !seen[file] {
+				seen[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+	return files
+}
+
+// confirmFixes runs the check pass of -confirm and asks on out whether to
+// go ahead with the fixes, reading the answer from in. It doesn't ask
+// when there is nothing to fix. Any answer other than yes, including end
+// of input, declines.
+func confirmFixes(cfg *cliConfig, in io.Reader, out io.Writer) (bool, error) {
+	result, err := previewFixes(cfg)
+	if err != nil {
+		return false, err
+	}
+
+	files := pendingFixes(result, cfg.opts)
+	if len(files) == 0 {
+		return true, nil
+	}
+
+	dirs := map[string]bool{}
+	for _, file := range files {
+		dirs[path.Dir(file)] = true
+	}
+	fmt.Fprintf(out, "About to fix %d files across %d directories. Continue? [y/N]: ", len(files), len(dirs))
+
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && answer == "" {
+		fmt.Fprintln(out)
+		return false, nil
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfirmFixes(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		answer   string
+		expected bool
+		prompt   string
+	}{
+		{
+			name:     "はい",
+			files:    map[string]string{"a/one.txt": "one", "b/two.txt": "two", "b/ok.txt": "ok\n"},
+			answer:   "y\n",
+			expected: true,
+			prompt:   "About to fix 2 files across 2 directories",
+		},
+		{
+			name:     "いいえ",
+			files:    map[string]string{"a/one.txt": "one"},
+			answer:   "n\n",
+			expected: false,
+			prompt:   "About to fix 1 files across 1 directories",
+		},
+		{
+			name:     "入力の終わり",
+			files:    map[string]string{"a/one.txt": "one"},
+			answer:   "",
+			expected: false,
+			prompt:   "About to fix 1 files",
+		},
+		{
+			name:     "修正するファイルがなければ確認しない",
+			files:    map[string]string{"a/ok.txt": "ok\n"},
+			answer:   "",
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				path := filepath.Join(dir, filepath.FromSlash(name))
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatalf("ディレクトリの作成に失敗: %v", err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+					t.Fatalf("テストファイルの作成に失敗: %v", err)
+				}
+			}
+
+			cfg, err := parseArgs([]string{"-no-rc", "-fix", "-confirm", dir})
+			if err != nil {
+				t.Fatalf("parseArgs()でエラーが発生: %v", err)
+			}
+
+			var out bytes.Buffer
+			ok, err := confirmFixes(cfg, strings.NewReader(tt.answer), &out)
+			if err != nil {
+				t.Fatalf("confirmFixes()でエラーが発生: %v", err)
+			}
+			if ok != tt.expected {
+				t.Errorf("confirmFixes() = %v, expected %v", ok, tt.expected)
+			}
+			if !strings.Contains(out.String(), tt.prompt) || (tt.prompt == "" && out.Len() > 0) {
+				t.Errorf("確認の表示が期待値と異なります: %q", out.String())
+			}
+
+			// 確認の前のチェックではファイルを変更しない
+			content, err := os.ReadFile(filepath.Join(dir, "a", "one.txt"))
+			if err == nil && string(content) != "one" {
+				t.Errorf("確認の前にファイルが変更されました: %q", string(content))
+			}
+		})
+	}
+}
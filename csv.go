@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// csvHeader names the columns of the CSV report
+var csvHeader = []string{"path", "status", "reason", "bytes", "last_line"}
+
+// writeCSVReport writes one row per processed file, checked, skipped or
+// failed, for spreadsheets. Sizes are only known for checked files and
+// line numbers only for files missing their final newline; the cells
+// are empty otherwise.
+func writeCSVReport(w io.Writer, result *RepoResult) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	for _, file := range result.Files {
+		size, line := "", ""
+		if file.Status != statusSkipped && file.Status != statusError {
+			size = strconv.FormatInt(file.Bytes, 10)
+		}
+		if file.Line > 0 {
+			line = strconv.Itoa(file.Line)
+		}
+		if err := cw.Write([]string{file.Path, file.Status, file.Reason, size, line}); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
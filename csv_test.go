@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestWriteCSVReport(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"ok.txt":         "ok\n",
+		`a,"quoted".txt`: "line1\nline2",
+		"image.png":      "\x89PNG",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	result, err := processRepository(dir, Options{})
+	if err != nil {
+		t.Fatalf("processRepository()でエラーが発生: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := writeReport(&buf, result, Options{Format: formatCSV}); err != nil {
+		t.Fatalf("writeReport()でエラーが発生: %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("CSVの解析に失敗: %v\n%s", err, buf.String())
+	}
+
+	expected := [][]string{
+		csvHeader,
+		{`a,"quoted".txt`, statusMissing, "missing final newline", "11", "2"},
+		{"image.png", statusSkipped, "binary extension .png", "", ""},
+		{"ok.txt", statusOK, "", "3", ""},
+	}
+	if len(records) != len(expected) {
+		t.Fatalf("行数が期待値と異なります: got %v, expected %v", records, expected)
+	}
+	for i := range expected {
+		if !slices.Equal(records[i], expected[i]) {
+			t.Errorf("%d行目が期待値と異なります: got %q, expected %q", i, records[i], expected[i])
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// fixDirs returns the directories whose git trees a fix run of cfg
+// writes to: those of the paths named, or the working directory
+func fixDirs(cfg *cliConfig) []string {
+	if len(cfg.args) == 0 {
+		return []string{"."}
+	}
+
+	dirs := make([]string, 0, len(cfg.args))
+	for _, arg := range cfg.args {
+		if info, err := os.Stat(arg); err == nil && !info.IsDir() {
+			arg = filepath.Dir(arg)
+		}
+		dirs = append(dirs, arg)
+	}
+	return dirs
+}
+
+// dirtyTree returns an error when dir is inside a git work tree with
+// uncommitted changes to tracked files, so that fixes don't mix with
+// them. Without git, or outside a work tree, there is nothing to guard.
+func dirtyTree(dir string) error {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil
+	}
+	if _, err := git(dir, nil, "rev-parse", "--is-inside-work-tree"); err != nil {
+		return nil
+	}
+
+	out, err := git(dir, nil, "status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return err
+	}
+	if len(out) > 0 {
+		return fmt.Errorf("%s has uncommitted changes; commit or stash them so that the fix diff only holds newline changes, or pass -allow-dirty", dir)
+	}
+	return nil
+}
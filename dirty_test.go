@@ -0,0 +1,142 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// commitAll commits everything staged in dir
+func commitAll(t *testing.T, dir string) {
+	t.Helper()
+
+	if _, err := git(dir, nil, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "init"); err != nil {
+		t.Fatalf("git commitに失敗: %v", err)
+	}
+}
+
+func TestDirtyTree(t *testing.T) {
+	dir := initGitRepo(t)
+	stageFile(t, dir, "a.txt", "a")
+	commitAll(t, dir)
+
+	if err := dirtyTree(dir); err != nil {
+		t.Errorf("コミット済みのツリーがdirtyと判定されました: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "untracked.txt"), []byte("u"), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+	if err := dirtyTree(dir); err != nil {
+		t.Errorf("未追跡のファイルだけでdirtyと判定されました: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("changed"), 0o644); err != nil {
+		t.Fatalf("ファイルの書き込みに失敗: %v", err)
+	}
+	if err := dirtyTree(dir); err == nil {
+		t.Error("変更のあるツリーがdirtyと判定されませんでした")
+	}
+
+	if err := dirtyTree(t.TempDir()); err != nil {
+		t.Errorf("gitリポジトリ外でエラーが発生: %v", err)
+	}
+}
+
+func TestExecuteFixDirtyTree(t *testing.T) {
+	dir := initGitRepo(t)
+	stageFile(t, dir, "a.txt", "a")
+	commitAll(t, dir)
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("changed"), 0o644); err != nil {
+		t.Fatalf("ファイルの書き込みに失敗: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		code     int
+		expected string
+	}{
+		{name: "dirtyなツリーでは修正しない", args: []string{"-no-lock", "-fix", dir}, code: 1, expected: "changed"},
+		{name: "-quarantineと-fixでも修正しない", args: []string{"-no-lock", "-fix", "-quarantine", filepath.Join(t.TempDir(), "q"), dir}, code: 1, expected: "changed"},
+		{name: "-allow-dirty", args: []string{"-no-lock", "-fix", "-allow-dirty", dir}, code: 0, expected: "changed\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := parseArgs(tt.args)
+			if err != nil {
+				t.Fatalf("parseArgs()でエラーが発生: %v", err)
+			}
+			if code := execute(cfg); code != tt.code {
+				t.Errorf("終了コードが期待値と異なります: got %d, expected %d", code, tt.code)
+			}
+
+			data, err := os.ReadFile(filepath.Join(dir, "a.txt"))
+			if err != nil {
+				t.Fatalf("ファイルの読み込みに失敗: %v", err)
+			}
+			if string(data) != tt.expected {
+				t.Errorf("内容が期待値と異なります: got %q, expected %q", data, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExecuteFixListedPathsInDirtyTree(t *testing.T) {
+	dir := initGitRepo(t)
+	stageFile(t, dir, "a.txt", "a")
+	commitAll(t, dir)
+	t.Chdir(dir)
+	list := filepath.Join(t.TempDir(), "list.txt")
+	if err := os.WriteFile(list, []byte("a.txt\n"), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		args  []string
+		stdin string
+	}{
+		// git diff --name-only | check-new-line -stdin -fix
+		{name: "標準入力", args: []string{"-stdin", "-fix"}, stdin: "a.txt\n"},
+		{name: "@ファイル", args: []string{"-fix", "@" + list}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.WriteFile("a.txt", []byte("changed"), 0o644); err != nil {
+				t.Fatalf("ファイルの書き込みに失敗: %v", err)
+			}
+
+			r, w, err := os.Pipe()
+			if err != nil {
+				t.Fatalf("パイプの作成に失敗: %v", err)
+			}
+			go func() {
+				w.WriteString(tt.stdin)
+				w.Close()
+			}()
+			defer r.Close()
+			stdin := os.Stdin
+			os.Stdin = r
+			defer func() { os.Stdin = stdin }()
+
+			cfg, err := parseArgs(tt.args)
+			if err != nil {
+				t.Fatalf("parseArgs()でエラーが発生: %v", err)
+			}
+			if code := execute(cfg); code != 0 {
+				t.Errorf("終了コード = %d, expected 0", code)
+			}
+
+			data, err := os.ReadFile("a.txt")
+			if err != nil {
+				t.Fatalf("ファイルの読み込みに失敗: %v", err)
+			}
+			if string(data) != "changed\n" {
+				t.Errorf("一覧で指定したファイルが修正されていません: %q", data)
+			}
+		})
+	}
+}
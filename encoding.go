@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// textEncodings are the -encoding names and the encodings they select
+var textEncodings = map[string]encoding.Encoding{
+	"utf-8":      unicode.UTF8,
+	"utf8":       unicode.UTF8,
+	"latin1":     charmap.ISO8859_1,
+	"latin-1":    charmap.ISO8859_1,
+	"iso-8859-1": charmap.ISO8859_1,
+	"shift-jis":  japanese.ShiftJIS,
+	"shift_jis":  japanese.ShiftJIS,
+	"sjis":       japanese.ShiftJIS,
+}
+
+// parseEncoding looks up an -encoding name
+func parseEncoding(name string) (encoding.Encoding, error) {
+	enc, ok := textEncodings[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown -encoding %q: must be utf-8, latin1 or shift-jis", name)
+	}
+	return enc, nil
+}
+
+// checkEncoded checks content declared to be in opts.Encoding. The text is
+// decoded to UTF-8, checked and fixed there, and re-encoded. Content that
+// doesn't survive a round trip is not text in that encoding and is
+// treated as binary rather than risk rewriting it.
+func checkEncoded(data []byte, opts Options) (fileCheck, []byte) {
+	decoded, err := opts.Encoding.NewDecoder().Bytes(data)
+	if err != nil {
+		return fileCheck{ok: true, binary: true}, data
+	}
+	if encoded, err := opts.Encoding.NewEncoder().Bytes(decoded); err != nil || !bytes.Equal(encoded, data) {
+		return fileCheck{ok: true, binary: true}, data
+	}
+
+	check, fixed := checkText(decoded, opts)
+	if bytes.Equal(fixed, decoded) {
+		return check, data
+	}
+
+	encoded, err := opts.Encoding.NewEncoder().Bytes(fixed)
+	if err != nil {
+		// Only ASCII newlines and spaces are added or removed, which
+		// every supported encoding can represent
+		return fileCheck{ok: true, binary: true}, data
+	}
+	return check, encoded
+}
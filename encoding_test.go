@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestParseEncoding(t *testing.T) {
+	for _, name := range []string{"utf-8", "UTF8", "latin1", "iso-8859-1", "shift-jis", "Shift_JIS", "sjis"} {
+		if _, err := parseEncoding(name); err != nil {
+			t.Errorf("parseEncoding(%q)でエラーが発生: %v", name, err)
+		}
+	}
+	if _, err := parseEncoding("ebcdic"); err == nil {
+		t.Errorf("未対応のエンコーディングでエラーが発生しませんでした")
+	}
+}
+
+func TestCheckBytesEncoding(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding string
+		opts     Options
+		data     string
+		ok       bool
+		binary   bool
+		fixed    string
+	}{
+		// "日本語" in Shift-JIS; the trail byte 0x5c is a backslash in ASCII
+		{name: "Shift-JIS改行あり", encoding: "shift-jis", data: "\x93\xfa\x96\x7b\x8c\xea\n", ok: true, fixed: "\x93\xfa\x96\x7b\x8c\xea\n"},
+		{name: "Shift-JIS改行なし", encoding: "shift-jis", opts: Options{Fix: true}, data: "\x83\x5c", ok: false, fixed: "\x83\x5c\n"},
+		{name: "Shift-JIS末尾の空白", encoding: "shift-jis", opts: Options{FixWhitespace: true}, data: "\x83\x5c  \n", ok: true, fixed: "\x83\x5c\n"},
+		{name: "Shift-JISとして不正", encoding: "shift-jis", data: "\x81\n\xff", ok: true, binary: true, fixed: "\x81\n\xff"},
+		{name: "Latin-1改行なし", encoding: "latin1", opts: Options{Fix: true}, data: "caf\xe9", ok: false, fixed: "caf\xe9\n"},
+		{name: "UTF-8ではUTF-16を自動判定しない", encoding: "utf-8", data: "\xff\xfet\x00", ok: true, binary: true, fixed: "\xff\xfet\x00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc, err := parseEncoding(tt.encoding)
+			if err != nil {
+				t.Fatalf("parseEncoding()でエラーが発生: %v", err)
+			}
+			opts := tt.opts
+			opts.Encoding = enc
+
+			check, fixed := checkBytes([]byte(tt.data), opts)
+			if check.ok != tt.ok || check.binary != tt.binary {
+				t.Errorf("チェック結果が期待値と異なります: got ok=%v binary=%v, expected ok=%v binary=%v", check.ok, check.binary, tt.ok, tt.binary)
+			}
+			if string(fixed) != tt.fixed {
+				t.Errorf("修正内容が期待値と異なります: got %q, expected %q", fixed, tt.fixed)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// expandEnvPaths expands $VAR and ${VAR} in each path argument for
+// -expand-env. An unset variable is an error rather than an empty
+// string, which would quietly turn $REPO_ROOT/src into /src.
+func expandEnvPaths(args []string) ([]string, error) {
+	expanded := make([]string, len(args))
+	for i, arg := range args {
+		var missing string
+		expanded[i] = os.Expand(arg, func(name string) string {
+			value, ok := os.LookupEnv(name)
+			if !ok && missing == "" {
+				missing = name
+			}
+			return value
+		})
+		if missing != "" {
+			return nil, fmt.Errorf("-expand-env: $%s in %q is not set", missing, arg)
+		}
+	}
+	return expanded, nil
+}
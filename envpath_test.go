@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExpandEnvPaths(t *testing.T) {
+	t.Setenv("NEWLINE_TEST_ROOT", "/repo")
+	t.Setenv("NEWLINE_TEST_EMPTY", "")
+	os.Unsetenv("NEWLINE_TEST_UNSET")
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected []string
+		wantErr  string
+	}{
+		{name: "$VAR", args: []string{"$NEWLINE_TEST_ROOT/src"}, expected: []string{"/repo/src"}},
+		{name: "${VAR}", args: []string{"${NEWLINE_TEST_ROOT}/src", "docs"}, expected: []string{"/repo/src", "docs"}},
+		{name: "空の変数", args: []string{"a${NEWLINE_TEST_EMPTY}b"}, expected: []string{"ab"}},
+		{name: "未設定の変数", args: []string{"$NEWLINE_TEST_UNSET/src"}, wantErr: "NEWLINE_TEST_UNSET"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandEnvPaths(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("期待したエラーが発生しませんでした: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expandEnvPaths()でエラーが発生: %v", err)
+			}
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("got %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseArgsExpandEnv(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("NEWLINE_TEST_ROOT", dir)
+
+	cfg, err := parseArgs([]string{"-expand-env", "$NEWLINE_TEST_ROOT"})
+	if err != nil {
+		t.Fatalf("parseArgs()でエラーが発生: %v", err)
+	}
+	if !slices.Equal(cfg.args, []string{dir}) {
+		t.Errorf("args = %v, expected [%s]", cfg.args, dir)
+	}
+
+	// -expand-envなしではそのまま渡す
+	literal := filepath.Join(dir, "$NEWLINE_TEST_ROOT")
+	cfg, err = parseArgs([]string{literal})
+	if err != nil {
+		t.Fatalf("parseArgs()でエラーが発生: %v", err)
+	}
+	if !slices.Equal(cfg.args, []string{literal}) {
+		t.Errorf("args = %v, expected [%s]", cfg.args, literal)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import "bytes"
+
+// eofTail describes what follows the last non-whitespace byte of a file
+type eofTail struct {
+	// content is the end of the content, just past its last
+	// non-whitespace byte
+	content int
+	// spaces is the end of the spaces and tabs trailing the last content
+	// line. It equals content when there are none.
+	spaces int
+	// blankLines counts the empty or whitespace-only lines after the last
+	// content line
+	blankLines int
+}
+
+// inspectTail finds the trailing whitespace and blank lines of data
+func inspectTail(data []byte) eofTail {
+	tail := eofTail{content: len(bytes.TrimRight(data, " \t\r\n"))}
+
+	// Whitespace-only files have no content line to trail
+	tail.spaces = tail.content
+	if tail.content > 0 {
+		rest := data[tail.content:]
+		if i := bytes.IndexAny(rest, "\r\n"); i >= 0 {
+			tail.spaces += i
+		} else {
+			tail.spaces += len(rest)
+		}
+	}
+
+	after := data[tail.spaces:]
+	tail.blankLines = bytes.Count(after, []byte("\n"))
+	if tail.blankLines > 0 && bytes.HasSuffix(after, []byte("\n")) {
+		tail.blankLines--
+	}
+	return tail
+}
+
+// cleanTail removes the trailing blank lines and whitespace enabled by
+// FixBlankLines and FixWhitespace. A missing final newline is left for
+// checkBytes to report, and data is returned as is when nothing changes.
+func cleanTail(data []byte, opts Options) []byte {
+	tail := inspectTail(data)
+
+	kept := data[:tail.spaces]
+	if opts.FixWhitespace {
+		kept = data[:tail.content]
+	}
+
+	// Keep the line terminator of the last content line, and the empty
+	// lines FinalNewlines asks for
+	end := data[tail.spaces:]
+	if keep := max(opts.finalNewlines(), 1); opts.FixBlankLines && tail.blankLines >= keep {
+		cut := 0
+		for range keep {
+			i := bytes.IndexByte(end[cut:], '\n')
+			if i < 0 {
+				break
+			}
+			cut += i + 1
+		}
+		end = end[:cut]
+	}
+
+	if len(kept)+len(end) == len(data) {
+		return data
+	}
+
+	cleaned := make([]byte, 0, len(kept)+len(end))
+	cleaned = append(cleaned, kept...)
+	return append(cleaned, end...)
+}
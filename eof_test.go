@@ -0,0 +1,121 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestCheckBytesTail(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       string
+		ok         bool
+		blankLines bool
+		whitespace bool
+	}{
+		{name: "正常", data: "text\n", ok: true},
+		{name: "空行が続く", data: "text\n\n\n", ok: true, blankLines: true},
+		{name: "空白のみの行が続く", data: "text\n  \n", ok: true, blankLines: true},
+		{name: "末尾の空白", data: "text  \n", ok: true, whitespace: true},
+		{name: "改行なしで末尾の空白", data: "text\t", whitespace: true},
+		{name: "改行なしで空白のみの行", data: "text\n  ", blankLines: true},
+		{name: "CRLFの空行", data: "text\r\n\r\n", ok: true, blankLines: true},
+		{name: "空白と空行", data: "text \n\n", ok: true, blankLines: true, whitespace: true},
+		{name: "改行のみ", data: "\n", ok: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check, _ := checkBytes([]byte(tt.data), Options{})
+			if check.ok != tt.ok || check.blankLines != tt.blankLines || check.trailingWhitespace != tt.whitespace {
+				t.Errorf("checkBytes(%q) = ok:%v blankLines:%v whitespace:%v, expected ok:%v blankLines:%v whitespace:%v",
+					tt.data, check.ok, check.blankLines, check.trailingWhitespace, tt.ok, tt.blankLines, tt.whitespace)
+			}
+			if check.cleaned {
+				t.Errorf("修正を指定していないのに内容が変更されました")
+			}
+		})
+	}
+}
+
+func TestCleanTail(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		opts     Options
+		expected string
+	}{
+		{name: "空行を削除", data: "text\n\n\n", opts: Options{FixBlankLines: true}, expected: "text\n"},
+		{name: "CRLFの空行を削除", data: "text\r\n\r\n", opts: Options{FixBlankLines: true}, expected: "text\r\n"},
+		{name: "空白のみの行を削除", data: "text\n  ", opts: Options{FixBlankLines: true}, expected: "text\n"},
+		{name: "空白を削除", data: "text \t\n", opts: Options{FixWhitespace: true}, expected: "text\n"},
+		{name: "空白のみ削除し空行は残す", data: "text \n\n", opts: Options{FixWhitespace: true}, expected: "text\n\n"},
+		{name: "空行のみ削除し空白は残す", data: "text \n\n", opts: Options{FixBlankLines: true}, expected: "text \n"},
+		{name: "両方を削除", data: "text \n\n", opts: Options{FixBlankLines: true, FixWhitespace: true}, expected: "text\n"},
+		{name: "改行がない場合は追加しない", data: "text  ", opts: Options{FixWhitespace: true}, expected: "text"},
+		{name: "修正なし", data: "text \n\n", opts: Options{}, expected: "text \n\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(cleanTail([]byte(tt.data), tt.opts)); got != tt.expected {
+				t.Errorf("cleanTail(%q) = %q, expected %q", tt.data, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestProcessRepositoryFixTail(t *testing.T) {
+	tempDir := t.TempDir()
+
+	files := map[string]string{
+		"blank.txt":   "text\n\n\n",
+		"space.txt":   "text  \n",
+		"missing.txt": "text\n  ",
+		"nonl.txt":    "text",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	// 空行の修正のみを有効にし、改行の追加と空白の削除は行わない
+	opts := Options{FixBlankLines: true, ReportFile: filepath.Join(t.TempDir(), "report.txt")}
+	result, err := processRepository(tempDir, opts)
+	if err != nil {
+		t.Fatalf("processRepository()でエラーが発生: %v", err)
+	}
+
+	// 空白のみの最終行を削除すると改行で終わるようになる
+	if !slices.Equal(result.Fixed, []string{"blank.txt", "missing.txt"}) {
+		t.Errorf("fixedが期待値と異なります: %v", result.Fixed)
+	}
+	if !slices.Equal(result.TrailingBlankLines, []string{"blank.txt", "missing.txt"}) {
+		t.Errorf("trailing_blank_linesが期待値と異なります: %v", result.TrailingBlankLines)
+	}
+	if !slices.Equal(result.Problematic, []string{"nonl.txt"}) {
+		t.Errorf("problematicが期待値と異なります: %v", result.Problematic)
+	}
+	if !slices.Equal(result.TrailingWhitespace, []string{"space.txt"}) {
+		t.Errorf("trailing_whitespaceが期待値と異なります: %v", result.TrailingWhitespace)
+	}
+
+	expected := map[string]string{
+		"blank.txt":   "text\n",
+		"space.txt":   "text  \n",
+		"missing.txt": "text\n",
+		"nonl.txt":    "text",
+	}
+	for name, want := range expected {
+		data, err := os.ReadFile(filepath.Join(tempDir, name))
+		if err != nil {
+			t.Fatalf("ファイルの読み込みに失敗: %v", err)
+		}
+		if string(data) != want {
+			t.Errorf("%sの内容が期待値と異なります: got %q, expected %q", name, data, want)
+		}
+	}
+}
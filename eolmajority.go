@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// EOLMajority describes which final newline style most checked files use
+// and which files use the other one
+type EOLMajority struct {
+	// Majority is eolLF or eolCRLF, and empty when neither style is used
+	// by more files than the other
+	Majority string `json:"majority"`
+	LF       int    `json:"lf_files"`
+	CRLF     int    `json:"crlf_files"`
+	// Deviants lists the files ending in the minority style
+	Deviants []string `json:"deviants"`
+}
+
+// majorityEOL tallies the final newline style of the checked files,
+// before any fix. Files without a final newline have no style and are
+// left out.
+func majorityEOL(files []FileResult) *EOLMajority {
+	m := &EOLMajority{Deviants: []string{}}
+	for _, file := range files {
+		switch file.EOL {
+		case eolLF:
+			m.LF++
+		case eolCRLF:
+			m.CRLF++
+		}
+	}
+
+	switch {
+	case m.LF > m.CRLF:
+		m.Majority = eolLF
+	case m.CRLF > m.LF:
+		m.Majority = eolCRLF
+	default:
+		return m
+	}
+	for _, file := range files {
+		if file.EOL != "" && file.EOL != m.Majority {
+			m.Deviants = append(m.Deviants, file.Path)
+		}
+	}
+	return m
+}
+
+// writeEOLMajority writes the majority final newline style and the files
+// deviating from it
+func writeEOLMajority(w io.Writer, m *EOLMajority, opts Options) {
+	if m.Majority == "" {
+		fmt.Fprintf(w, "\nNo final newline majority: %d LF, %d CRLF\n", m.LF, m.CRLF)
+		return
+	}
+
+	minority := eolCRLF
+	if m.Majority == eolCRLF {
+		minority = eolLF
+	}
+	fmt.Fprintf(w, "\nFinal newline majority: %s (%d LF, %d CRLF)\n", strings.ToUpper(m.Majority), m.LF, m.CRLF)
+	if len(m.Deviants) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "Files ending in %s: %d\n", strings.ToUpper(minority), len(m.Deviants))
+	files, more := limitList(m.Deviants, opts.MaxReport)
+	for _, file := range files {
+		fmt.Fprintf(w, "  - %s\n", file)
+	}
+	writeMore(w, more)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestEOLMajority(t *testing.T) {
+	tests := []struct {
+		name             string
+		files            map[string]string
+		expectedMajority string
+		expectedDeviants []string
+	}{
+		{
+			name: "LFが多数派",
+			files: map[string]string{
+				"a.txt": "a\n",
+				"b.txt": "b\r\n",
+				"c.txt": "c\n",
+				"d.txt": "d",
+			},
+			expectedMajority: eolLF,
+			expectedDeviants: []string{"b.txt"},
+		},
+		{
+			name: "CRLFが多数派",
+			files: map[string]string{
+				"a.txt": "a\r\n",
+				"b.txt": "b\r\n",
+				"c.txt": "line1\r\nc\n",
+			},
+			expectedMajority: eolCRLF,
+			expectedDeviants: []string{"c.txt"},
+		},
+		{
+			name: "同数",
+			files: map[string]string{
+				"a.txt": "a\n",
+				"b.txt": "b\r\n",
+			},
+			expectedMajority: "",
+			expectedDeviants: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+					t.Fatalf("テストファイルの作成に失敗: %v", err)
+				}
+			}
+
+			result, err := processRepository(dir, Options{EOLMajority: true})
+			if err != nil {
+				t.Fatalf("processRepository()でエラーが発生: %v", err)
+			}
+			if result.EOLMajority == nil {
+				t.Fatal("EOLMajorityが設定されていません")
+			}
+			if result.EOLMajority.Majority != tt.expectedMajority {
+				t.Errorf("多数派 = %q, expected %q", result.EOLMajority.Majority, tt.expectedMajority)
+			}
+			if !slices.Equal(result.EOLMajority.Deviants, tt.expectedDeviants) {
+				t.Errorf("逸脱ファイル = %v, expected %v", result.EOLMajority.Deviants, tt.expectedDeviants)
+			}
+		})
+	}
+}
+
+func TestEOLMajorityCached(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{"a.txt": "a\r\n", "b.txt": "b\r\n", "c.txt": "c\n"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	// 2回目はキャッシュから改行の種類を復元する
+	cachePath := filepath.Join(t.TempDir(), "cache.json")
+	for run := 1; run <= 2; run++ {
+		cache, err := loadCache(cachePath)
+		if err != nil {
+			t.Fatalf("キャッシュの読み込みに失敗: %v", err)
+		}
+		result, err := processRepository(dir, Options{EOLMajority: true, Cache: cache})
+		if err != nil {
+			t.Fatalf("%d回目のprocessRepository()でエラーが発生: %v", run, err)
+		}
+		if err := cache.save(); err != nil {
+			t.Fatalf("キャッシュの保存に失敗: %v", err)
+		}
+		if run == 2 && result.Cached != 3 {
+			t.Errorf("キャッシュされたファイル数 = %d, expected 3", result.Cached)
+		}
+		if got := result.EOLMajority; got.Majority != eolCRLF || !slices.Equal(got.Deviants, []string{"c.txt"}) {
+			t.Errorf("%d回目の結果が期待値と異なります: %+v", run, got)
+		}
+	}
+}
+
+func TestWriteEOLMajority(t *testing.T) {
+	result := &RepoResult{EOLMajority: &EOLMajority{Majority: eolLF, LF: 3, CRLF: 2, Deviants: []string{"a.txt", "b.txt"}}}
+
+	var buf bytes.Buffer
+	writeEOLMajority(&buf, result.EOLMajority, Options{MaxReport: 1})
+	output := buf.String()
+	for _, want := range []string{"Final newline majority: LF (3 LF, 2 CRLF)", "Files ending in CRLF: 2", "  - a.txt", "... and 1 more"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("出力に %q が含まれていません:\n%s", want, output)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// eolAny in an -eol-map entry removes the requirement for an extension
+const eolAny = "any"
+
+// defaultExtEOL maps extensions to the final newline their tools expect
+var defaultExtEOL = map[string]string{
+	".bat":  eolCRLF,
+	".cmd":  eolCRLF,
+	".ps1":  eolCRLF,
+	".sln":  eolCRLF,
+	".sh":   eolLF,
+	".bash": eolLF,
+	".zsh":  eolLF,
+}
+
+// parseExtEOL builds the extension mapping from the defaults overridden
+// by entries such as ".bat=crlf,.py=lf,.ps1=any"
+func parseExtEOL(overrides string) (map[string]string, error) {
+	mapping := map[string]string{}
+	for ext, eol := range defaultExtEOL {
+		mapping[ext] = eol
+	}
+
+	for _, entry := range strings.Split(overrides, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		ext, eol, ok := strings.Cut(entry, "=")
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		eol = strings.ToLower(strings.TrimSpace(eol))
+		if !ok || ext == "" {
+			return nil, fmt.Errorf("invalid -eol-map entry %q: expected .ext=lf, .ext=crlf or .ext=any", entry)
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+
+		switch eol {
+		case eolLF, eolCRLF:
+			mapping[ext] = eol
+		case eolAny:
+			delete(mapping, ext)
+		default:
+			return nil, fmt.Errorf("invalid -eol-map entry %q: unknown newline %q", entry, eol)
+		}
+	}
+
+	return mapping, nil
+}
+
+// applyExtEOL sets the required final newline for relPath from its
+// extension, unless one was already chosen, e.g. by .gitattributes
+func applyExtEOL(relPath string, opts *Options) {
+	if opts.ExtEOL == nil || opts.EOL != "" {
+		return
+	}
+	if eol, ok := opts.ExtEOL[strings.ToLower(filepath.Ext(relPath))]; ok {
+		opts.EOL = eol
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParseExtEOL(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides string
+		expected  map[string]string
+		wantErr   bool
+	}{
+		{name: "デフォルトのみ", overrides: "", expected: defaultExtEOL},
+		{
+			name:      "上書きと追加",
+			overrides: ".sh=crlf, PY=lf",
+			expected: map[string]string{
+				".bat": eolCRLF, ".cmd": eolCRLF, ".ps1": eolCRLF, ".sln": eolCRLF,
+				".sh": eolCRLF, ".bash": eolLF, ".zsh": eolLF, ".py": eolLF,
+			},
+		},
+		{
+			name:      "anyで要件を削除",
+			overrides: ".ps1=any",
+			expected: map[string]string{
+				".bat": eolCRLF, ".cmd": eolCRLF, ".sln": eolCRLF,
+				".sh": eolLF, ".bash": eolLF, ".zsh": eolLF,
+			},
+		},
+		{name: "区切りなし", overrides: ".sh", wantErr: true},
+		{name: "未知の改行", overrides: ".sh=cr", wantErr: true},
+		{name: "拡張子なし", overrides: "=lf", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseExtEOL(tt.overrides)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("エラーが期待されましたが発生しませんでした")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseExtEOL()でエラーが発生: %v", err)
+			}
+			if len(got) != len(tt.expected) {
+				t.Fatalf("マッピングが期待値と異なります: got %v, expected %v", got, tt.expected)
+			}
+			for ext, eol := range tt.expected {
+				if got[ext] != eol {
+					t.Errorf("%sの改行が期待値と異なります: got %q, expected %q", ext, got[ext], eol)
+				}
+			}
+		})
+	}
+}
+
+func TestExtEOLProcessRepository(t *testing.T) {
+	files := map[string]string{
+		"build.sh":  "echo hi\r\n",
+		"run.bat":   "echo hi\n",
+		"ok.ps1":    "Write-Host hi\r\n",
+		"notes.txt": "crlf is fine here\r\n",
+	}
+
+	tests := []struct {
+		name     string
+		fix      bool
+		expected []string
+	}{
+		{name: "チェックモード", fix: false, expected: []string{"build.sh", "run.bat"}},
+		{name: "修正モード", fix: true, expected: []string{"build.sh", "run.bat"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+					t.Fatalf("テストファイルの作成に失敗: %v", err)
+				}
+			}
+
+			result, err := processRepository(dir, Options{Fix: tt.fix, ExtEOL: defaultExtEOL})
+			if err != nil {
+				t.Fatalf("processRepository()でエラーが発生: %v", err)
+			}
+
+			got := result.Problematic
+			if tt.fix {
+				got = result.Fixed
+			}
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("対象ファイルが期待値と異なります: got %v, expected %v", got, tt.expected)
+			}
+
+			if !tt.fix {
+				return
+			}
+			for name, want := range map[string]string{"build.sh": "echo hi\n", "run.bat": "echo hi\r\n"} {
+				data, err := os.ReadFile(filepath.Join(dir, name))
+				if err != nil {
+					t.Fatalf("ファイルの読み込みに失敗: %v", err)
+				}
+				if string(data) != want {
+					t.Errorf("%sの内容が期待値と異なります: got %q, expected %q", name, data, want)
+				}
+			}
+		})
+	}
+}
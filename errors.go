@@ -0,0 +1,33 @@
+package main
+
+// ReadError reports a file that could not be read. The message leaves out
+// Path, which reports already list next to it; errors.As recovers both.
+type ReadError struct {
+	Path string
+	Err  error
+}
+
+func (e *ReadError) Error() string { return "failed to read file: " + e.Err.Error() }
+
+func (e *ReadError) Unwrap() error { return e.Err }
+
+// WriteError reports a fixed file that could not be written back
+type WriteError struct {
+	Path string
+	Err  error
+}
+
+func (e *WriteError) Error() string { return "failed to write file: " + e.Err.Error() }
+
+func (e *WriteError) Unwrap() error { return e.Err }
+
+// WalkError reports a tree that could not be walked, ending the run.
+// Path is the root of the walk.
+type WalkError struct {
+	Path string
+	Err  error
+}
+
+func (e *WalkError) Error() string { return "failed to walk repository: " + e.Err.Error() }
+
+func (e *WalkError) Unwrap() error { return e.Err }
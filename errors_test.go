@@ -0,0 +1,30 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTypedErrors(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.txt")
+
+	_, err := checkAndFixFile(missing, Options{})
+	var readErr *ReadError
+	if !errors.As(err, &readErr) || readErr.Path != missing {
+		t.Errorf("ReadErrorが返されませんでした: %v", err)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("元のエラーがラップされていません: %v", err)
+	}
+
+	_, err = processRepository(missing, Options{})
+	var walkErr *WalkError
+	if !errors.As(err, &walkErr) || walkErr.Path != missing {
+		t.Errorf("WalkErrorが返されませんでした: %v", err)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("元のエラーがラップされていません: %v", err)
+	}
+}
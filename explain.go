@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// walkPruneStep names the walk's pruning of skipped and ignored
+// directories in -trace-path output
+const walkPruneStep = "skip-dir"
+
+// examineFile processes path alone without changing anything, the way a
+// walk of the working directory would, and returns its outcome. flag
+// names the option asking, for the errors about paths that aren't a
+// single file.
+func examineFile(path, flag string, opts Options) (FileResult, error) {
+	opts = previewOptions(opts)
+	display := filepath.ToSlash(filepath.Clean(path))
+
+	info, err := statPath(path, opts)
+	if err != nil {
+		return FileResult{Path: display, Status: statusError, Reason: err.Error()}, nil
+	}
+	if info.IsDir() {
+		return FileResult{}, fmt.Errorf("%s: is a directory; %s takes a file", display, flag)
+	}
+	if opts.Archive && archiveKind(path) != "" {
+		return FileResult{}, fmt.Errorf("%s: is an archive; %s takes a file", display, flag)
+	}
+
+	relPath := cwdRelPath(path)
+	if reason := walkPruneReason(relPath, opts); reason != "" {
+		return FileResult{Path: display, Status: statusSkipped, Reason: reason, Step: walkPruneStep}, nil
+	}
+
+	result := newRepoResult(opts)
+	processFile(path, relPath, relPath, info, opts, result)
+	if len(result.Files) == 0 {
+		return FileResult{}, errors.New(display + ": was not examined")
+	}
+	file := result.Files[0]
+	file.Path = display
+	return file, nil
+}
+
+// cwdRelPath returns path relative to the working directory, slash
+// separated, or the cleaned path itself when it lies outside
+func cwdRelPath(path string) string {
+	display := filepath.ToSlash(filepath.Clean(path))
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return display
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return display
+	}
+	rel, err := filepath.Rel(cwd, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return display
+	}
+	return filepath.ToSlash(rel)
+}
+
+// walkPruneReason returns why a walk never reaches relPath because one of
+// the directories it lies in is skipped or ignored, or an empty string
+func walkPruneReason(relPath string, opts Options) string {
+	dir := ""
+	for _, name := range strings.Split(relPath, "/")[:strings.Count(relPath, "/")] {
+		dir = filepath.Join(dir, name)
+		if name == "" || name == "." || name == ".." {
+			continue
+		}
+		if opts.skipsDir(name) {
+			return "in skipped directory " + filepath.ToSlash(dir)
+		}
+		if opts.Ignore != nil {
+			if ignored, err := opts.Ignore.ignored(dir, true); err == nil && ignored {
+				return "in ignored directory " + filepath.ToSlash(dir)
+			}
+		}
+	}
+	return ""
+}
+
+// explainSkip runs the per-file decisions for one path without changing
+// anything and describes the outcome to w. It returns 0 when the file
+// would be checked, 1 when it would be skipped and 2 when it can't be
+// examined.
+func explainSkip(path string, opts Options, w io.Writer) int {
+	file, err := examineFile(path, "-explain-skip", opts)
+	if err != nil {
+		fmt.Fprintf(w, "%v\n", err)
+		return 2
+	}
+
+	switch file.Status {
+	case statusError:
+		fmt.Fprintf(w, "%s: cannot be examined: %s\n", file.Path, file.Reason)
+		return 2
+	case statusSkipped, statusBinary:
+		fmt.Fprintf(w, "%s: skipped (%s)\n", file.Path, file.Reason)
+		return 1
+	case statusMissing:
+		fmt.Fprintf(w, "%s: checked (%s)\n", file.Path, file.Reason)
+	default:
+		fmt.Fprintf(w, "%s: checked (ends with newline)\n", file.Path)
+	}
+	return 0
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExplainSkip(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"ok.txt":      "ok\n",
+		"missing.txt": "missing",
+		"image.png":   "\x89PNG",
+		"data.txt":    "\x00\x01\x02",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		opts     Options
+		code     int
+		expected string
+	}{
+		{name: "チェック対象", path: "ok.txt", code: 0, expected: "checked (ends with newline)"},
+		{name: "改行なし", path: "missing.txt", code: 0, expected: "checked (missing final newline)"},
+		{name: "修正モードでも変更しない", path: "missing.txt", opts: Options{Fix: true}, code: 0, expected: "checked (missing final newline)"},
+		{name: "バイナリ拡張子", path: "image.png", code: 1, expected: "skipped (binary extension .png)"},
+		{name: "バイナリの内容", path: "data.txt", code: 1, expected: "skipped (content looks binary)"},
+		{name: "存在しないファイル", path: "nope.txt", code: 2, expected: "cannot be examined"},
+		{name: "ディレクトリ", path: ".", code: 2, expected: "is a directory"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			code := explainSkip(filepath.Join(dir, tt.path), tt.opts, &buf)
+			if code != tt.code {
+				t.Errorf("終了コードが期待値と異なります: got %d, expected %d", code, tt.code)
+			}
+			if !strings.Contains(buf.String(), tt.expected) {
+				t.Errorf("出力が期待値と異なります: got %q, expected to contain %q", buf.String(), tt.expected)
+			}
+		})
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "missing.txt"))
+	if err != nil {
+		t.Fatalf("ファイルの読み込みに失敗: %v", err)
+	}
+	if string(data) != "missing" {
+		t.Errorf("-explain-skipでファイルが変更されました: %q", data)
+	}
+}
+
+// writeEmptyZip writes a zip archive without members to path
+func writeEmptyZip(t *testing.T, path string) {
+	t.Helper()
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("アーカイブの作成に失敗: %v", err)
+	}
+	if err := zip.NewWriter(f).Close(); err != nil {
+		t.Fatalf("アーカイブの書き込みに失敗: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("アーカイブの書き込みに失敗: %v", err)
+	}
+}
+
+func TestExplainSkipWalkDecisions(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	for _, name := range []string{"node_modules/a.txt", "src/a.txt"} {
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatalf("ディレクトリの作成に失敗: %v", err)
+		}
+		if err := os.WriteFile(name, []byte("missing"), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+	writeEmptyZip(t, "empty.zip")
+
+	tests := []struct {
+		name     string
+		path     string
+		opts     Options
+		code     int
+		expected string
+	}{
+		{name: "スキップするディレクトリ", path: "node_modules/a.txt", opts: Options{SkipDirs: skipDirSet(true, "")}, code: 1, expected: "skipped (in skipped directory node_modules)"},
+		{name: "-skip-dir", path: "src/a.txt", opts: Options{SkipDirs: skipDirSet(false, "src")}, code: 1, expected: "skipped (in skipped directory src)"},
+		{name: "スキップしないディレクトリ", path: "src/a.txt", opts: Options{SkipDirs: skipDirSet(true, "")}, code: 0, expected: "checked (missing final newline)"},
+		{name: "空のアーカイブ", path: "empty.zip", opts: Options{Archive: true}, code: 2, expected: "is an archive"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if code := explainSkip(tt.path, tt.opts, &buf); code != tt.code {
+				t.Errorf("終了コードが期待値と異なります: got %d, expected %d", code, tt.code)
+			}
+			if !strings.Contains(buf.String(), tt.expected) {
+				t.Errorf("出力が期待値と異なります: got %q, expected to contain %q", buf.String(), tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// textExts are extensions whose files are assumed to be text, so a check
+// run only needs to look at their last byte
+var textExts = map[string]bool{
+	".go": true, ".mod": true, ".sum": true,
+	".c": true, ".h": true, ".cc": true, ".cpp": true, ".hpp": true, ".rs": true,
+	".java": true, ".kt": true, ".scala": true, ".swift": true, ".cs": true,
+	".js": true, ".jsx": true, ".ts": true, ".tsx": true, ".mjs": true, ".cjs": true,
+	".py": true, ".rb": true, ".php": true, ".pl": true, ".lua": true,
+	".sh": true, ".bash": true, ".zsh": true, ".ps1": true, ".bat": true,
+	".html": true, ".htm": true, ".css": true, ".scss": true, ".vue": true,
+	".json": true, ".yml": true, ".yaml": true, ".toml": true, ".ini": true, ".xml": true,
+	".md": true, ".rst": true, ".txt": true, ".csv": true, ".sql": true,
+}
+
+// canUseFastPath reports whether path can be checked by its last byte
+// alone. Fixing, content-based options and unknown extensions all need
+// the full content.
+func canUseFastPath(path string, opts Options) bool {
+	if opts.Fix || opts.FullScan || opts.GeneratedPattern != nil || opts.SkipContent != nil || opts.EOL != "" || opts.IsBinary != nil || opts.FinalNewlines > 1 || opts.NoBOM || opts.ErrorOnBinaryContent || opts.Encoding != nil {
+		return false
+	}
+	return textExts[strings.ToLower(filepath.Ext(path))]
+}
+
+// endsWithNewlineFast reports whether the file is empty or its content
+// ends with a single newline, reading only the first and last two bytes.
+// A newline after whitespace or another line terminator needs a full read
+// to tell blank lines and trailing whitespace apart, and so does UTF-16
+// text, where a 0x0A byte may be half of another character.
+func endsWithNewlineFast(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+	size := info.Size()
+	if size == 0 {
+		return true, nil
+	}
+
+	if size >= 2 {
+		first := make([]byte, 2)
+		if _, err := f.ReadAt(first, 0); err != nil {
+			return false, err
+		}
+		if bytes.Equal(first, utf16LEBOM) || bytes.Equal(first, utf16BEBOM) {
+			return false, nil
+		}
+	}
+
+	last := make([]byte, min(size, 2))
+	if _, err := f.ReadAt(last, size-int64(len(last))); err != nil {
+		return false, err
+	}
+
+	if last[len(last)-1] != '\n' {
+		return false, nil
+	}
+	return len(last) == 1 || !strings.ContainsRune(" \t\r\n", rune(last[0])), nil
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/unicode"
+)
+
+func TestCanUseFastPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		opts     Options
+		expected bool
+	}{
+		{name: "既知のテキスト拡張子", path: "main.go", opts: Options{}, expected: true},
+		{name: "大文字の拡張子", path: "README.MD", opts: Options{}, expected: true},
+		{name: "不明な拡張子", path: "data.unknown", opts: Options{}, expected: false},
+		{name: "修正モード", path: "main.go", opts: Options{Fix: true}, expected: false},
+		{name: "-full-scan", path: "main.go", opts: Options{FullScan: true}, expected: false},
+		{name: "改行の種類を指定", path: "main.go", opts: Options{EOL: eolLF}, expected: false},
+		{name: "エンコーディングを指定", path: "main.go", opts: Options{Encoding: unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM)}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := canUseFastPath(tt.path, tt.opts); result != tt.expected {
+				t.Errorf("canUseFastPath(%s) = %v, expected %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEndsWithNewlineFast(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected bool
+	}{
+		{name: "改行で終わる", content: "package main\n", expected: true},
+		{name: "改行で終わらない", content: "package main", expected: false},
+		{name: "空のファイル", content: "", expected: true},
+		{name: "1バイトの改行", content: "\n", expected: true},
+		{name: "空行が続く", content: "package main\n\n", expected: false},
+		{name: "末尾の空白", content: "package main \n", expected: false},
+		{name: "UTF-16BEでU+4E0Aで終わる", content: "\xfe\xff\x4e\x0a", expected: false},
+		{name: "UTF-16LE", content: "\xff\xfea\x00\n\x00", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "file.go")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatalf("テストファイルの作成に失敗: %v", err)
+			}
+
+			result, err := endsWithNewlineFast(path)
+			if err != nil {
+				t.Fatalf("endsWithNewlineFast()でエラーが発生: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("endsWithNewlineFast() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+// createBenchmarkTree creates a realistic mix of mostly compliant source files
+func createBenchmarkTree(b *testing.B) string {
+	b.Helper()
+
+	dir := b.TempDir()
+	exts := []string{".go", ".md", ".js", ".json", ".yaml", ".dat"}
+	line := strings.Repeat("x", 79) + "\n"
+
+	for i := 0; i < 200; i++ {
+		ext := exts[i%len(exts)]
+		content := strings.Repeat(line, 50+(i%20)*50)
+		if i%25 == 0 {
+			content = strings.TrimSuffix(content, "\n")
+		}
+
+		path := filepath.Join(dir, fmt.Sprintf("pkg%d", i%10), fmt.Sprintf("file%d%s", i, ext))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			b.Fatalf("ディレクトリの作成に失敗: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			b.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	return dir
+}
+
+func BenchmarkWalkRepositoryFastPath(b *testing.B) {
+	dir := createBenchmarkTree(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := walkRepository(dir, "", Options{}, newRepoResult(Options{})); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkWalkRepositoryFullScan(b *testing.B) {
+	dir := createBenchmarkTree(b)
+	opts := Options{FullScan: true}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := walkRepository(dir, "", opts, newRepoResult(opts)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestProcessRepositoryUTF16FastPath(t *testing.T) {
+	dir := t.TempDir()
+	// U+4E0A の下位バイトが 0x0A で、改行のように見える
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("\xfe\xff\x4e\x0a"), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+
+	result, err := processRepository(dir, Options{})
+	if err != nil {
+		t.Fatalf("processRepository()でエラーが発生: %v", err)
+	}
+	if len(result.Problematic) != 1 {
+		t.Errorf("UTF-16のファイルが改行で終わると判定されました: %v", result.Problematic)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// globPattern is a compiled gitignore-style pattern. Patterns without a
+// slash match the base name at any depth; others match the whole path
+// relative to the directory they were defined in. "**" matches across
+// directory boundaries.
+type globPattern struct {
+	re       *regexp.Regexp
+	basename bool
+}
+
+// compileGlob compiles a gitignore-style pattern, matching letters in
+// either case when ignoreCase is set
+func compileGlob(pattern string, ignoreCase bool) (*globPattern, error) {
+	pattern = strings.TrimSuffix(pattern, "/")
+	basename := !strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	var sb strings.Builder
+	if ignoreCase {
+		sb.WriteString("(?i)")
+	}
+	sb.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			sb.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid pattern %q: unterminated character class", pattern)
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			sb.WriteString(regexp.QuoteMeta(string(pattern[i])))
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+
+	return &globPattern{re: re, basename: basename}, nil
+}
+
+// match reports whether the slash-separated relative path matches
+func (g *globPattern) match(relPath string) bool {
+	if g.basename {
+		relPath = relPath[strings.LastIndexByte(relPath, '/')+1:]
+	}
+	return g.re.MatchString(relPath)
+}
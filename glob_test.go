@@ -0,0 +1,46 @@
+package main
+
+import "testing"
+
+func TestCompileGlob(t *testing.T) {
+	tests := []struct {
+		name       string
+		pattern    string
+		path       string
+		ignoreCase bool
+		expected   bool
+	}{
+		{name: "拡張子のパターン", pattern: "*.sh", path: "scripts/build.sh", expected: true},
+		{name: "拡張子の不一致", pattern: "*.sh", path: "scripts/build.bash", expected: false},
+		{name: "スラッシュを含むパターン", pattern: "docs/*.md", path: "docs/index.md", expected: true},
+		{name: "スラッシュを含むパターンは深い階層に一致しない", pattern: "docs/*.md", path: "sub/docs/index.md", expected: false},
+		{name: "先頭のスラッシュ", pattern: "/build.sh", path: "build.sh", expected: true},
+		{name: "先頭の**/", pattern: "**/vendor/*.go", path: "a/b/vendor/x.go", expected: true},
+		{name: "途中の/**/", pattern: "src/**/gen.go", path: "src/gen.go", expected: true},
+		{name: "末尾の/**", pattern: "dist/**", path: "dist/js/app.js", expected: true},
+		{name: "文字クラス", pattern: "file[0-9].txt", path: "file3.txt", expected: true},
+		{name: "否定の文字クラス", pattern: "file[!0-9].txt", path: "file3.txt", expected: false},
+		{name: "?は1文字", pattern: "a?.txt", path: "ab.txt", expected: true},
+		{name: "大文字小文字を区別する", pattern: "*.PNG", path: "photo.png", expected: false},
+		{name: "-ignore-caseで大文字小文字を区別しない", pattern: "*.PNG", path: "photo.png", ignoreCase: true, expected: true},
+		{name: "-ignore-caseでディレクトリ名も区別しない", pattern: "Docs/*.md", path: "docs/INDEX.md", ignoreCase: true, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			glob, err := compileGlob(tt.pattern, tt.ignoreCase)
+			if err != nil {
+				t.Fatalf("compileGlob(%q)でエラーが発生: %v", tt.pattern, err)
+			}
+			if result := glob.match(tt.path); result != tt.expected {
+				t.Errorf("match(%q, %q) = %v, expected %v", tt.pattern, tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCompileGlobInvalid(t *testing.T) {
+	if _, err := compileGlob("file[0-9.txt", false); err == nil {
+		t.Errorf("閉じていない文字クラスでエラーが発生しませんでした")
+	}
+}
@@ -3,3 +3,5 @@ module github.com/Tattsum/check-new-line
 go 1.24.4
 
 require github.com/magefile/mage v1.15.0
+
+require golang.org/x/text v0.30.0
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Ignore file names, from lowest to highest precedence within a directory
+var (
+	gitIgnoreFiles = []string{".gitignore"}
+	allIgnoreFiles = []string{".gitignore", ".ignore", ".rgignore"}
+)
+
+// ignoreRule is one pattern line of an ignore file
+type ignoreRule struct {
+	pattern *globPattern
+	// negate re-includes paths matched by an earlier pattern
+	negate bool
+	// dirOnly patterns, written with a trailing slash, only match
+	// directories
+	dirOnly bool
+}
+
+// ignoreRules resolves gitignore-style ignore files for paths, reading
+// each directory's files once
+type ignoreRules struct {
+	names      []string
+	ignoreCase bool
+	dirs       map[string][]ignoreRule
+}
+
+// newIgnoreRules returns a resolver reading the named ignore files. With
+// ignoreCase the patterns match paths in either case.
+func newIgnoreRules(names []string, ignoreCase bool) *ignoreRules {
+	return &ignoreRules{names: names, ignoreCase: ignoreCase, dirs: map[string][]ignoreRule{}}
+}
+
+// parseIgnoreLine parses one line of an ignore file. Comments and blank
+// lines yield a nil rule.
+func parseIgnoreLine(line string, ignoreCase bool) (*ignoreRule, error) {
+	line = strings.TrimRight(line, " \t")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil, nil
+	}
+
+	rule := &ignoreRule{}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+	}
+
+	pattern, err := compileGlob(line, ignoreCase)
+	if err != nil {
+		return nil, err
+	}
+	rule.pattern = pattern
+	return rule, nil
+}
+
+// rulesFor returns the rules of dir's ignore files in precedence order
+func (r *ignoreRules) rulesFor(dir string) ([]ignoreRule, error) {
+	if rules, ok := r.dirs[dir]; ok {
+		return rules, nil
+	}
+
+	var rules []ignoreRule
+	for _, name := range r.names {
+		path := filepath.Join(dir, name)
+		f, err := os.Open(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			rule, err := parseIgnoreLine(scanner.Text(), r.ignoreCase)
+			if err != nil {
+				f.Close()
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			if rule != nil {
+				rules = append(rules, *rule)
+			}
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+	}
+
+	r.dirs[dir] = rules
+	return rules, nil
+}
+
+// ignored reports whether path, or a directory between it and the
+// repository top, is excluded by the ignore files. As with git, a file
+// inside an ignored directory can't be re-included.
+func (r *ignoreRules) ignored(path string, isDir bool) (bool, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	dirs := repoDirs(abs)
+	top := dirs[len(dirs)-1]
+
+	// Check ancestors first, outermost to innermost, then path itself
+	for i := len(dirs) - 2; i >= 0; i-- {
+		if ok, err := r.matches(dirs[i], true, top); ok || err != nil {
+			return ok, err
+		}
+	}
+	return r.matches(abs, isDir, top)
+}
+
+// matches applies the rules of every directory from top down to abs's
+// parent, so deeper files and later lines take precedence
+func (r *ignoreRules) matches(abs string, isDir bool, top string) (bool, error) {
+	var dirs []string
+	for dir := filepath.Dir(abs); ; dir = filepath.Dir(dir) {
+		dirs = append(dirs, dir)
+		if dir == top || filepath.Dir(dir) == dir {
+			break
+		}
+	}
+
+	ignored := false
+	for i := len(dirs) - 1; i >= 0; i-- {
+		rules, err := r.rulesFor(dirs[i])
+		if err != nil {
+			return false, err
+		}
+
+		rel, err := filepath.Rel(dirs[i], abs)
+		if err != nil {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+
+		for _, rule := range rules {
+			if rule.dirOnly && !isDir {
+				continue
+			}
+			if rule.pattern.match(rel) {
+				ignored = !rule.negate
+			}
+		}
+	}
+	return ignored, nil
+}
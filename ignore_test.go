@@ -0,0 +1,102 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParseIgnoreLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		isNil   bool
+		negate  bool
+		dirOnly bool
+	}{
+		{name: "空行", line: "  ", isNil: true},
+		{name: "コメント", line: "# comment", isNil: true},
+		{name: "通常", line: "*.log"},
+		{name: "否定", line: "!keep.log", negate: true},
+		{name: "ディレクトリのみ", line: "build/ ", dirOnly: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := parseIgnoreLine(tt.line, false)
+			if err != nil {
+				t.Fatalf("parseIgnoreLine(%q)でエラーが発生: %v", tt.line, err)
+			}
+			if (rule == nil) != tt.isNil {
+				t.Fatalf("parseIgnoreLine(%q) = %+v", tt.line, rule)
+			}
+			if rule != nil && (rule.negate != tt.negate || rule.dirOnly != tt.dirOnly) {
+				t.Errorf("parseIgnoreLine(%q) = negate:%v dirOnly:%v, expected negate:%v dirOnly:%v",
+					tt.line, rule.negate, rule.dirOnly, tt.negate, tt.dirOnly)
+			}
+		})
+	}
+}
+
+func TestProcessRepositoryIgnoreFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(tempDir, ".git"), 0o755); err != nil {
+		t.Fatalf("ディレクトリの作成に失敗: %v", err)
+	}
+
+	files := map[string]string{
+		".gitignore":          "*.log\nbuild/\n",
+		".ignore":             "!keep.log\n",
+		".rgignore":           "secret.txt\n",
+		"app.log":             "x",
+		"keep.log":            "x",
+		"secret.txt":          "x",
+		"main.txt":            "x",
+		"build/out.txt":       "x",
+		"sub/.gitignore":      "!important.log\n",
+		"sub/important.log":   "x",
+		"sub/other.log":       "x",
+		"sub/build/deep.txt":  "x",
+		"docs/build.txt":      "x",
+		"docs/build/note.txt": "x",
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("ディレクトリの作成に失敗: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		names    []string
+		expected []string
+	}{
+		{
+			name:     ".gitignoreのみ",
+			names:    gitIgnoreFiles,
+			expected: []string{"docs/build.txt", "main.txt", "secret.txt", "sub/important.log"},
+		},
+		{
+			name:     "すべての無視ファイル",
+			names:    allIgnoreFiles,
+			expected: []string{"docs/build.txt", "keep.log", "main.txt", "sub/important.log"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := processRepository(tempDir, Options{Ignore: newIgnoreRules(tt.names, false)})
+			if err != nil {
+				t.Fatalf("processRepository()でエラーが発生: %v", err)
+			}
+			if !slices.Equal(result.Problematic, tt.expected) {
+				t.Errorf("problematic = %v, expected %v", result.Problematic, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// rcCommentPrefix starts the keys of a .newlinerc that are comments. JSON
+// has no comments, so -init documents each flag under such a key.
+const rcCommentPrefix = "//"
+
+// initRC returns the .newlinerc written by -init: an object with a
+// "// name" entry describing each flag it may set and its default. It sets nothing,
+// so it behaves like having no .newlinerc until a flag is added.
+func initRC() []byte {
+	var buf bytes.Buffer
+	entry := func(key, value string) {
+		k, _ := json.Marshal(key)
+		v, _ := json.Marshal(value)
+		if buf.Len() > 0 {
+			buf.WriteString(",\n")
+		} else {
+			buf.WriteString("{\n")
+		}
+		fmt.Fprintf(&buf, "  %s: %s", k, v)
+	}
+
+	entry(rcCommentPrefix, "check-new-line settings. Each \"// name\" entry describes a flag; set it with a \"name\": value entry, e.g. \"fix-whitespace\": true. Flags given on the command line win.")
+	newFlagSet(&cliConfig{}).VisitAll(func(f *flag.Flag) {
+		if !rcFlags[f.Name] {
+			return
+		}
+		_, usage := flag.UnquoteUsage(f)
+		switch f.DefValue {
+		case "", "false", "0", "0s":
+		default:
+			usage += fmt.Sprintf(" (default: %s)", f.DefValue)
+		}
+		entry(rcCommentPrefix+" "+f.Name, usage)
+	})
+	buf.WriteString("\n}\n")
+	return buf.Bytes()
+}
+
+// writeInitRC writes the -init .newlinerc into dir and returns its path.
+// An existing file is only replaced with force.
+func writeInitRC(dir string, force bool) (string, error) {
+	path := filepath.Join(dir, rcFileName)
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !force {
+		flags |= os.O_EXCL
+	}
+
+	f, err := os.OpenFile(path, flags, defaultFileMode)
+	if errors.Is(err, os.ErrExist) {
+		return path, fmt.Errorf("%s already exists; use -force to overwrite it", path)
+	}
+	if err != nil {
+		return path, fmt.Errorf("failed to create %s: %w", path, err)
+	}
+
+	_, werr := f.Write(initRC())
+	if cerr := f.Close(); werr == nil {
+		werr = cerr
+	}
+	if werr != nil {
+		return path, fmt.Errorf("failed to write %s: %w", path, werr)
+	}
+	return path, nil
+}
+
+// runInit writes a documented .newlinerc into the working directory
+func runInit(force bool, w io.Writer) int {
+	path, err := writeInitRC(".", force)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(w, "Wrote %s\n", path)
+	return 0
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteInitRC(t *testing.T) {
+	dir := t.TempDir()
+
+	path, err := writeInitRC(dir, false)
+	if err != nil {
+		t.Fatalf("設定ファイルの作成に失敗: %v", err)
+	}
+	if path != filepath.Join(dir, rcFileName) {
+		t.Errorf("パスが期待値と異なります: got %q", path)
+	}
+
+	settings, err := loadRCFile(path)
+	if err != nil {
+		t.Fatalf("作成した設定ファイルの読み込みに失敗: %v", err)
+	}
+	if len(settings) != 0 {
+		t.Errorf("作成した設定ファイルがフラグを設定しています: %v", settings)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ファイルの読み込みに失敗: %v", err)
+	}
+	for _, expected := range []string{`"// single-final-newline"`, `"// format": "Report format: `, `(default: text)"`} {
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("設定ファイルに %s が含まれていません", expected)
+		}
+	}
+	for _, unexpected := range []string{`"// init"`, `"// fix"`, `"// skip-command"`} {
+		if strings.Contains(string(data), unexpected) {
+			t.Errorf("設定ファイルに %s が含まれています", unexpected)
+		}
+	}
+
+	if _, err := writeInitRC(dir, false); err == nil || !strings.Contains(err.Error(), "-force") {
+		t.Errorf("既存のファイルが上書きされました: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("{}"), 0o644); err != nil {
+		t.Fatalf("ファイルの書き込みに失敗: %v", err)
+	}
+	if _, err := writeInitRC(dir, true); err != nil {
+		t.Fatalf("-forceでの上書きに失敗: %v", err)
+	}
+	data, err = os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ファイルの読み込みに失敗: %v", err)
+	}
+	if string(data) != string(initRC()) {
+		t.Error("-forceで上書きされていません")
+	}
+}
+
+func TestLoadRCFileComments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), rcFileName)
+	content := `{"//": "comment", "// fix": "Fix files", "single-final-newline": true}`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+
+	settings, err := loadRCFile(path)
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗: %v", err)
+	}
+	if len(settings) != 1 || settings["single-final-newline"] != "true" {
+		t.Errorf("設定が期待値と異なります: %v", settings)
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// hardLinkID reports no identity where inode information is unavailable,
+// so every path is processed on its own
+func hardLinkID(info os.FileInfo) (fileID, bool) {
+	return fileID{}, false
+}
+
+// copyOwner does nothing where file ownership is unavailable
+func copyOwner(path string, info os.FileInfo) error {
+	return nil
+}
+
+// deviceID reports no device where it is unavailable, so filesystem
+// boundaries are not detected
+func deviceID(info os.FileInfo) (uint64, bool) {
+	return 0, false
+}
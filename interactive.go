@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxPromptLineLength limits how much of the last line a prompt shows
+const maxPromptLineLength = 80
+
+// prompter asks on out before each fix and reads the answer from in
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+	// all is set once the user answers "all", after which every
+	// remaining fix is applied without asking
+	all bool
+}
+
+// newPrompter returns a prompter reading answers from r and writing
+// prompts to w
+func newPrompter(r io.Reader, w io.Writer) *prompter {
+	return &prompter{in: bufio.NewReader(r), out: w}
+}
+
+// confirm shows path and its last line and asks whether to fix it. Any
+// answer other than yes or all, including end of input, declines.
+func (p *prompter) confirm(path string, data []byte) bool {
+	if p.all {
+		return true
+	}
+
+	fmt.Fprintf(p.out, "%s: end of file needs fixing\n", path)
+	fmt.Fprintf(p.out, "  last line: %s\n", lastLine(data))
+	fmt.Fprint(p.out, "Fix? [y]es/[n]o/[a]ll: ")
+
+	answer, err := p.in.ReadString('\n')
+	if err != nil && answer == "" {
+		fmt.Fprintln(p.out)
+		return false
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	case "a", "all":
+		p.all = true
+		return true
+	}
+	return false
+}
+
+// lastLine returns the final line of data, quoted and shortened for display
+func lastLine(data []byte) string {
+	data = bytes.TrimRight(data, "\r\n")
+	if i := bytes.LastIndexByte(data, '\n'); i >= 0 {
+		data = data[i+1:]
+	}
+
+	line := string(data)
+	if runes := []rune(line); len(runes) > maxPromptLineLength {
+		line = string(runes[:maxPromptLineLength]) + "..."
+	}
+	return fmt.Sprintf("%q", line)
+}
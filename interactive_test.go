@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPrompterConfirm(t *testing.T) {
+	var out bytes.Buffer
+	p := newPrompter(strings.NewReader("n\nYes\na\n"), &out)
+
+	// "all"の後は入力を読まずにすべて修正する
+	expected := []bool{false, true, true, true, true}
+	for i, want := range expected {
+		if got := p.confirm("a.txt", []byte("first\nlast")); got != want {
+			t.Errorf("%d回目のconfirm() = %v, expected %v", i+1, got, want)
+		}
+	}
+
+	if prompts := strings.Count(out.String(), "Fix? "); prompts != 3 {
+		t.Errorf("確認の表示回数が期待値と異なります: got %d, expected 3", prompts)
+	}
+	if !strings.Contains(out.String(), `last line: "last"`) {
+		t.Errorf("最終行が表示されていません: %q", out.String())
+	}
+}
+
+func TestPrompterConfirmEOF(t *testing.T) {
+	p := newPrompter(strings.NewReader(""), &bytes.Buffer{})
+	if p.confirm("a.txt", []byte("text")) {
+		t.Errorf("入力の終端で修正が許可されました")
+	}
+}
+
+func TestLastLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected string
+	}{
+		{name: "1行", data: "only", expected: `"only"`},
+		{name: "複数行", data: "a\nb\nlast", expected: `"last"`},
+		{name: "空", data: "", expected: `""`},
+		{name: "長い行", data: strings.Repeat("x", 100), expected: `"` + strings.Repeat("x", 80) + `..."`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lastLine([]byte(tt.data)); got != tt.expected {
+				t.Errorf("lastLine(%q) = %s, expected %s", tt.data, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestProcessRepositoryConfirm(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{"keep.txt", "fix.txt"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte("no newline"), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	opts := Options{
+		Fix:        true,
+		ReportFile: filepath.Join(t.TempDir(), "report.txt"),
+		Confirm: func(path string, data []byte) bool {
+			return filepath.Base(path) == "fix.txt"
+		},
+	}
+	result, err := processRepository(tempDir, opts)
+	if err != nil {
+		t.Fatalf("processRepository()でエラーが発生: %v", err)
+	}
+
+	if !slices.Equal(result.Fixed, []string{"fix.txt"}) {
+		t.Errorf("fixedが期待値と異なります: %v", result.Fixed)
+	}
+	if !slices.Equal(result.Problematic, []string{"keep.txt"}) {
+		t.Errorf("problematicが期待値と異なります: %v", result.Problematic)
+	}
+
+	data, err := os.ReadFile(filepath.Join(tempDir, "keep.txt"))
+	if err != nil {
+		t.Fatalf("ファイルの読み込みに失敗: %v", err)
+	}
+	if string(data) != "no newline" {
+		t.Errorf("拒否したファイルが変更されています: %q", data)
+	}
+}
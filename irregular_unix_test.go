@@ -0,0 +1,41 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestProcessRepositorySkipsNamedPipes(t *testing.T) {
+	dir := t.TempDir()
+	if err := syscall.Mkfifo(filepath.Join(dir, "pipe"), 0o644); err != nil {
+		t.Skipf("FIFOを作成できません: %v", err)
+	}
+	if err := os.Symlink("pipe", filepath.Join(dir, "link")); err != nil {
+		t.Fatalf("シンボリックリンクの作成に失敗: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+
+	// FIFOを読み込むと書き込み側がないためテストが止まる
+	result, err := processRepository(dir, Options{Fix: true})
+	if err != nil {
+		t.Fatalf("processRepository()でエラーが発生: %v", err)
+	}
+
+	if result.Total != 1 || result.Skipped != 2 {
+		t.Errorf("件数が期待値と異なります: total %d, skipped %d", result.Total, result.Skipped)
+	}
+	for _, file := range result.Files {
+		if file.Path == "a.txt" {
+			continue
+		}
+		if file.Status != statusSkipped || file.Reason != reasonNamedPipe {
+			t.Errorf("%s: FIFOがスキップされていません: %+v", file.Path, file)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// junitSuiteName names the test suite in JUnit reports
+const junitSuiteName = "check-new-line"
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+}
+
+// writeJUnitReport writes the result as JUnit XML. Every checked file is
+// a test case; a missing final newline is a failure. Skipped files are
+// left out, except binary-content files which are marked skipped.
+func writeJUnitReport(w io.Writer, result *RepoResult) error {
+	suite := junitTestSuite{Name: junitSuiteName}
+
+	for _, file := range result.Files {
+		tc := junitTestCase{Name: file.Path, ClassName: junitSuiteName}
+
+		switch file.Status {
+		case statusMissing:
+			tc.Failure = &junitMessage{Message: file.Reason, Type: file.Status}
+			suite.Failures++
+		case statusError:
+			tc.Error = &junitMessage{Message: file.Reason}
+			suite.Errors++
+		case statusFixed, statusPatched:
+			tc.SystemOut = file.Status + ": " + file.Reason
+		case statusBinary:
+			tc.Skipped = &junitMessage{Message: file.Reason}
+			suite.Skipped++
+		case statusSkipped:
+			continue
+		}
+
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+	suite.Tests = len(suite.TestCases)
+
+	suites := junitTestSuites{
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Errors:   suite.Errors,
+		Skipped:  suite.Skipped,
+		Suites:   []junitTestSuite{suite},
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+)
+
+func TestWriteJUnitReport(t *testing.T) {
+	result := &RepoResult{
+		Mode: modeCheck,
+		Files: []FileResult{
+			{Path: "a.txt", Status: statusOK},
+			{Path: "b.txt", Status: statusMissing, Reason: "missing final newline"},
+			{Path: "c.bin", Status: statusBinary, Reason: "content looks binary"},
+			{Path: ".hidden", Status: statusSkipped, Reason: "hidden file or directory"},
+			{Path: "d.txt", Status: statusError, Reason: "permission denied"},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := writeJUnitReport(&buf, result); err != nil {
+		t.Fatalf("writeJUnitReport()でエラーが発生: %v", err)
+	}
+
+	var decoded junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("XMLのデコードに失敗: %v\n%s", err, buf.String())
+	}
+
+	if decoded.Tests != 4 || decoded.Failures != 1 || decoded.Errors != 1 || decoded.Skipped != 1 {
+		t.Errorf("件数が期待値と異なります: tests=%d, failures=%d, errors=%d, skipped=%d",
+			decoded.Tests, decoded.Failures, decoded.Errors, decoded.Skipped)
+	}
+
+	if len(decoded.Suites) != 1 || decoded.Suites[0].Name != junitSuiteName {
+		t.Fatalf("テストスイートが期待値と異なります: %+v", decoded.Suites)
+	}
+
+	failed := decoded.Suites[0].TestCases[1]
+	if failed.Name != "b.txt" || failed.Failure == nil || failed.Failure.Message != "missing final newline" {
+		t.Errorf("失敗したテストケースが期待値と異なります: %+v", failed)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// lockFileName is the advisory lock created in the root during fix runs
+const lockFileName = ".newline-checker.lock"
+
+// lockPollInterval is how often a held lock is retried while waiting
+const lockPollInterval = 100 * time.Millisecond
+
+// errLockHeld is returned when another run holds the lock
+var errLockHeld = errors.New("lock is held by another run")
+
+// acquireLock creates the lock file in root, waiting up to wait for a
+// concurrent run to release it. A zero wait fails fast. The returned
+// function releases the lock.
+func acquireLock(root string, wait time.Duration) (func() error, error) {
+	path := filepath.Join(root, lockFileName)
+	deadline := time.Now().Add(wait)
+
+	for {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err == nil {
+			// Record the owner to help diagnose stale locks
+			_, werr := f.WriteString(strconv.Itoa(os.Getpid()) + "\n")
+			if cerr := f.Close(); werr == nil {
+				werr = cerr
+			}
+			if werr != nil {
+				os.Remove(path)
+				return nil, fmt.Errorf("failed to write lock file: %w", werr)
+			}
+
+			return func() error {
+				if err := os.Remove(path); err != nil {
+					return fmt.Errorf("failed to remove lock file: %w", err)
+				}
+				return nil
+			}, nil
+		}
+
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to create lock file: %w", err)
+		}
+
+		if !time.Now().Before(deadline) {
+			return nil, fmt.Errorf("%w: %s (remove it if no other run is active)", errLockHeld, path)
+		}
+
+		time.Sleep(lockPollInterval)
+	}
+}
+
+// acquireLocks takes the lock of each of dirs, skipping repeated and
+// missing ones, whose paths are reported by the run itself. Either every
+// lock is taken or none is. The returned function releases them all.
+func acquireLocks(dirs []string, wait time.Duration) (func() error, error) {
+	var releases []func() error
+	releaseAll := func() error {
+		var errs []error
+		for _, release := range releases {
+			errs = append(errs, release())
+		}
+		return errors.Join(errs...)
+	}
+
+	seen := map[string]bool{}
+	for _, dir := range dirs {
+		dir = filepath.Clean(dir)
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			continue
+		}
+
+		release, err := acquireLock(dir, wait)
+		if err != nil {
+			releaseAll()
+			return nil, err
+		}
+		releases = append(releases, release)
+	}
+	return releaseAll, nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAcquireLock(t *testing.T) {
+	tempDir := t.TempDir()
+
+	release, err := acquireLock(tempDir, 0)
+	if err != nil {
+		t.Fatalf("ロックの取得に失敗: %v", err)
+	}
+
+	// 2つ目の実行は即座に失敗する
+	if _, err := acquireLock(tempDir, 0); !errors.Is(err, errLockHeld) {
+		t.Errorf("ロック取得中の2回目の取得でerrLockHeldが返されませんでした: %v", err)
+	}
+
+	if err := release(); err != nil {
+		t.Fatalf("ロックの解放に失敗: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(tempDir, lockFileName)); !os.IsNotExist(err) {
+		t.Errorf("解放後もロックファイルが残っています")
+	}
+
+	release, err = acquireLock(tempDir, 0)
+	if err != nil {
+		t.Fatalf("解放後のロックの再取得に失敗: %v", err)
+	}
+	if err := release(); err != nil {
+		t.Fatalf("ロックの解放に失敗: %v", err)
+	}
+}
+
+func TestAcquireLockWait(t *testing.T) {
+	tempDir := t.TempDir()
+
+	release, err := acquireLock(tempDir, 0)
+	if err != nil {
+		t.Fatalf("ロックの取得に失敗: %v", err)
+	}
+
+	go func() {
+		time.Sleep(2 * lockPollInterval)
+		release()
+	}()
+
+	// 待機中に解放されれば取得できる
+	second, err := acquireLock(tempDir, 5*time.Second)
+	if err != nil {
+		t.Fatalf("待機後のロックの取得に失敗: %v", err)
+	}
+	if err := second(); err != nil {
+		t.Fatalf("ロックの解放に失敗: %v", err)
+	}
+}
+
+func TestExecuteHonorsLock(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Chdir(tempDir)
+	for _, name := range []string{"a/x.txt", "b/y.txt"} {
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatalf("ディレクトリの作成に失敗: %v", err)
+		}
+		if err := os.WriteFile(name, []byte("no newline"), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+	if err := os.WriteFile("list.txt", []byte("a/x.txt\nb/y.txt\n"), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+
+	// 他の実行がbのロックを保持している
+	release, err := acquireLock("b", 0)
+	if err != nil {
+		t.Fatalf("ロックの取得に失敗: %v", err)
+	}
+	defer release()
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "複数のパス", args: []string{"-fix", "a", "b"}},
+		{name: "@ファイル", args: []string{"-fix", "@list.txt"}},
+		{name: "複数のファイル", args: []string{"-fix", "a/x.txt", "b/y.txt"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := parseArgs(tt.args)
+			if err != nil {
+				t.Fatalf("parseArgs()でエラーが発生: %v", err)
+			}
+			if code := execute(cfg); code != 1 {
+				t.Errorf("終了コード = %d, expected 1", code)
+			}
+
+			// どちらのファイルも修正されず、aのロックも残らない
+			for _, name := range []string{"a/x.txt", "b/y.txt"} {
+				data, err := os.ReadFile(name)
+				if err != nil {
+					t.Fatalf("ファイルの読み込みに失敗: %v", err)
+				}
+				if string(data) != "no newline" {
+					t.Errorf("ロック中に %s が修正されました: %q", name, data)
+				}
+			}
+			if _, err := os.Stat(filepath.Join("a", lockFileName)); !os.IsNotExist(err) {
+				t.Errorf("aのロックファイルが残っています: %v", err)
+			}
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/magefile/mage/mg"
 	"github.com/magefile/mage/sh"
@@ -183,6 +184,11 @@ func Release() error {
 		return fmt.Errorf("failed to create dist directory: %w", err)
 	}
 
+	ldflags, err := versionLdflags()
+	if err != nil {
+		return err
+	}
+
 	for _, platform := range platforms {
 		env := map[string]string{
 			"GOOS":   platform.goos,
@@ -198,7 +204,7 @@ func Release() error {
 
 		fmt.Printf("Building %s/%s -> %s\n", platform.goos, platform.goarch, outputPath)
 
-		if err := sh.RunWith(env, "go", "build", "-ldflags", "-s -w", "-o", outputPath, "."); err != nil {
+		if err := sh.RunWith(env, "go", "build", "-ldflags", ldflags, "-o", outputPath, "."); err != nil {
 			return fmt.Errorf("failed to build %s/%s: %w", platform.goos, platform.goarch, err)
 		}
 	}
@@ -207,6 +213,23 @@ func Release() error {
 	return nil
 }
 
+// versionLdflags returns linker flags stamping version, commit and build date
+func versionLdflags() (string, error) {
+	version, err := sh.Output("git", "describe", "--tags", "--always", "--dirty")
+	if err != nil {
+		return "", fmt.Errorf("failed to get version from git: %w", err)
+	}
+
+	commit, err := sh.Output("git", "rev-parse", "--short", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("failed to get commit from git: %w", err)
+	}
+
+	date := time.Now().UTC().Format(time.RFC3339)
+
+	return fmt.Sprintf("-s -w -X main.version=%s -X main.commit=%s -X main.date=%s", version, commit, date), nil
+}
+
 // ModTidy runs go mod tidy
 func ModTidy() error {
 	fmt.Println("📦 Running go mod tidy...")
@@ -2,30 +2,76 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
 )
 
+// controlSet marks the control bytes (0-31) that count as text
+type controlSet [32]bool
+
+// defaultTextControls are the control bytes allowed in text files. Form
+// feed and vertical tab appear in page-broken text such as man pages.
+var defaultTextControls = controlSet{'\t': true, '\n': true, '\v': true, '\f': true, '\r': true}
+
+// parseControlSet parses a comma-separated list of byte values such as
+// "9,10,12,13,27". Values above 31 are printable anyway and are ignored.
+func parseControlSet(s string) (*controlSet, error) {
+	var set controlSet
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		value, err := strconv.Atoi(field)
+		if err != nil || value < 0 || value > 255 {
+			return nil, fmt.Errorf("invalid control byte %q: must be a number between 0 and 255", field)
+		}
+		if value < len(set) {
+			set[value] = true
+		}
+	}
+	return &set, nil
+}
+
 // isBinary checks if a file is likely to be binary
 func isBinary(data []byte) bool {
+	return isBinaryWith(data, &defaultTextControls)
+}
+
+// isBinaryWith is isBinary with a custom set of control bytes treated as text
+func isBinaryWith(data []byte, allowed *controlSet) bool {
 	if len(data) == 0 {
 		return false
 	}
 
 	// Check for null bytes which typically indicate binary files
-	for _, b := range data {
-		if b == 0 {
-			return true
+	if !allowed[0] {
+		for _, b := range data {
+			if b == 0 {
+				return true
+			}
 		}
 	}
 
 	// Check if file has too many non-printable characters
 	nonPrintable := 0
 	for _, b := range data {
-		if b < 32 && b != '\n' && b != '\r' && b != '\t' {
+		if b < 32 && !allowed[b] {
 			nonPrintable++
 		}
 	}
@@ -34,17 +80,98 @@ func isBinary(data []byte) bool {
 	return float64(nonPrintable)/float64(len(data)) > 0.3
 }
 
+// defaultSkipDirs are dependency and build output directories that are
+// pointless to walk
+var defaultSkipDirs = []string{"node_modules", "vendor", "dist", ".venv"}
+
+// skipDirSet builds the set of directory names to prune from the walk
+func skipDirSet(defaults bool, extra string) map[string]bool {
+	set := map[string]bool{}
+	if defaults {
+		for _, name := range defaultSkipDirs {
+			set[name] = true
+		}
+	}
+	for _, name := range strings.Split(extra, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			set[name] = true
+		}
+	}
+	return set
+}
+
+// skipsDir reports whether directories called name are never walked
+// into
+func (o Options) skipsDir(name string) bool {
+	if o.SkipDirs[name] {
+		return true
+	}
+	if o.IgnoreCase {
+		for dir := range o.SkipDirs {
+			if strings.EqualFold(dir, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // shouldSkipFile determines if a file should be skipped based on its path
 func shouldSkipFile(path string) bool {
-	// Skip hidden files and directories
+	return skipReason(path) != ""
+}
+
+// hiddenReason is the skip reason for hidden files and directories
+const hiddenReason = "hidden file or directory"
+
+// skipReason explains why a file is skipped based on its path, or returns
+// an empty string if it isn't
+func skipReason(path string) string {
+	if isHiddenPath(path) {
+		return hiddenReason
+	}
+	if ext := binaryExt(path); ext != "" {
+		return "binary extension " + ext
+	}
+	return ""
+}
+
+// pathSkipReason is skipReason under opts, where binary extensions are
+// not skipped with ForceText or NoExtSkip
+func (o Options) pathSkipReason(path string) string {
+	if isHiddenPath(path) {
+		return hiddenReason
+	}
+	return o.binaryExtReason(path)
+}
+
+// binaryExtReason returns the skip reason for a file with a binary
+// extension, or an empty string when the extension isn't one or isn't
+// skipped under o
+func (o Options) binaryExtReason(path string) string {
+	if o.ForceText || o.NoExtSkip {
+		return ""
+	}
+	if ext := binaryExt(path); ext != "" {
+		return "binary extension " + ext
+	}
+	return ""
+}
+
+// isHiddenPath reports whether any element of path starts with a dot
+func isHiddenPath(path string) bool {
 	parts := strings.Split(filepath.ToSlash(path), "/")
 	for _, part := range parts {
-		if strings.HasPrefix(part, ".") && part != "." {
+		if strings.HasPrefix(part, ".") && part != "." && part != ".." {
 			return true
 		}
 	}
+	return false
+}
 
-	// Skip common binary extensions
+// binaryExt returns the lowercased extension of path if it is a common
+// binary extension, or an empty string otherwise
+func binaryExt(path string) string {
 	binaryExts := []string{
 		".exe", ".dll", ".so", ".dylib", ".a", ".o",
 		".jpg", ".jpeg", ".png", ".gif", ".bmp", ".ico", ".svg",
@@ -58,6 +185,28 @@ func shouldSkipFile(path string) bool {
 	ext := strings.ToLower(filepath.Ext(path))
 	for _, binExt := range binaryExts {
 		if ext == binExt {
+			return ext
+		}
+	}
+
+	return ""
+}
+
+// defaultGeneratedPattern matches the Go convention for generated-code headers
+const defaultGeneratedPattern = `^// Code generated .* DO NOT EDIT\.$`
+
+// generatedScanLines is how many leading lines are searched for the generated marker
+const generatedScanLines = 5
+
+// isGenerated reports whether one of the first lines of data matches pattern
+func isGenerated(data []byte, pattern *regexp.Regexp) bool {
+	lines := bytes.SplitN(data, []byte("\n"), generatedScanLines+1)
+	if len(lines) > generatedScanLines {
+		lines = lines[:generatedScanLines]
+	}
+
+	for _, line := range lines {
+		if pattern.Match(bytes.TrimSuffix(line, []byte("\r"))) {
 			return true
 		}
 	}
@@ -65,148 +214,1609 @@ func shouldSkipFile(path string) bool {
 	return false
 }
 
+// skipContentChunk is how much of the start of a file -skip-content-regex
+// is matched against
+const skipContentChunk = 4096
+
+// skipContentReason is the skip reason for files matching SkipContent
+const skipContentReason = "content matches -skip-content-regex"
+
+// matchesStart reports whether pattern matches the first chunk of data
+func matchesStart(data []byte, pattern *regexp.Regexp) bool {
+	return pattern.Match(data[:min(len(data), skipContentChunk)])
+}
+
+// Final newline styles for Options.EOL
+const (
+	eolLF   = "lf"
+	eolCRLF = "crlf"
+)
+
+// fileCheck is the outcome of checking a single file
+type fileCheck struct {
+	// ok reports whether the file ends with newline. Empty and binary
+	// files are always ok.
+	ok bool
+	// binary is set when the content looks binary and wasn't checked
+	binary bool
+	// size is the length of the content before any fix
+	size int64
+	// generated is set when the file was left alone because of its
+	// generated-code marker
+	generated bool
+	// skippedContent is set when the start of the file matches
+	// Options.SkipContent
+	skippedContent bool
+	// noLineTerminators is set when a file missing its final newline
+	// contains no newline at all, e.g. a minified file
+	noLineTerminators bool
+	// lastLine is the 1-based number of the line missing its terminator
+	// when the final newline is missing, and 0 otherwise
+	lastLine int
+	// wrongEOL is set when the file ends with newline but not the style
+	// required by Options.EOL
+	wrongEOL bool
+	// declined is set when a fix was needed but Options.Confirm refused it
+	declined bool
+	// blankLines is set when empty or whitespace-only lines follow the
+	// last line of content
+	blankLines bool
+	// trailingWhitespace is set when the last line of content ends with
+	// spaces or tabs
+	trailingWhitespace bool
+	// cleaned is set when FixBlankLines or FixWhitespace change the content
+	cleaned bool
+	// utf8BOM is set when the content starts with a UTF-8 byte order mark
+	utf8BOM bool
+	// extraNewlines is set when the content ends with more newlines than
+	// FinalNewlines or SingleFinalNewline allow
+	extraNewlines bool
+	// fewNewlines is set when the content ends with newline but with
+	// fewer than FinalNewlines
+	fewNewlines bool
+	// blankLinesAdded counts the empty lines a fix adds to reach
+	// FinalNewlines
+	blankLinesAdded int
+	// truncatedUTF8 is set when PreserveTrailingContent left the content
+	// alone because it ends in an incomplete UTF-8 sequence
+	truncatedUTF8 bool
+	// finalEOL is the style of the final newline before any fix, eolLF
+	// or eolCRLF, and empty when the content doesn't end with newline
+	finalEOL string
+}
+
+// changes reports whether a checked file is rewritten under opts
+func (c fileCheck) changes(opts Options) bool {
+	return !c.declined && ((!c.ok && opts.Fix) || c.cleaned)
+}
+
+// checkBytes checks whether content ends with newline. It returns the
+// result along with the fixed content, which is data itself when no fix
+// is needed.
+func checkBytes(data []byte, opts Options) (fileCheck, []byte) {
+	// Skip empty files
+	if len(data) == 0 {
+		return fileCheck{ok: true}, data
+	}
+
+	// A declared encoding replaces autodetection
+	if opts.Encoding != nil {
+		return checkEncoded(data, opts)
+	}
+
+	// UTF-16 text is full of NUL bytes, so recognize it by its byte order
+	// mark and run binary detection on the decoded text instead. Binary
+	// content that merely starts with FF FE or FE FF falls through to the
+	// usual checks. Only the final newline is checked; the trailing blank
+	// line and whitespace checks assume ASCII.
+	if nl, ok := utf16Newlines(data); ok && (opts.ForceText || !opts.isBinary(decodeUTF16(data))) {
+		check, fixed := checkFinalNewline(data, nl, opts)
+		check.finalEOL = finalEOLStyle(data, nl)
+		return check, fixed
+	}
+
+	return checkText(data, opts)
+}
+
+// utf8BOM is the byte order mark reported and stripped by -no-bom
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// checkText checks non-empty content in an ASCII-compatible encoding
+func checkText(data []byte, opts Options) (fileCheck, []byte) {
+	// Skip binary files
+	if !opts.ForceText && opts.isBinary(data) {
+		return fileCheck{ok: true, binary: true}, data
+	}
+
+	// Skip generated files
+	if opts.GeneratedPattern != nil && isGenerated(data, opts.GeneratedPattern) {
+		return fileCheck{ok: true, generated: true}, data
+	}
+	if opts.SkipContent != nil && matchesStart(data, opts.SkipContent) {
+		return fileCheck{ok: true, skippedContent: true}, data
+	}
+
+	// A newline appended to a truncated file would make it look complete
+	if opts.PreserveTrailingContent && endsWithIncompleteRune(data) {
+		return fileCheck{ok: true, truncatedUTF8: true}, data
+	}
+
+	tail := inspectTail(data)
+	cleaned := cleanTail(data, opts)
+	bom := bytes.HasPrefix(data, utf8BOM)
+	if bom && opts.NoBOM && opts.Fix {
+		cleaned = cleaned[len(utf8BOM):]
+	}
+
+	check, fixed := checkFinalNewline(cleaned, asciiNewlines, opts)
+	check.utf8BOM = bom
+	check.blankLines = tail.blankLines >= max(opts.finalNewlines(), 1)
+	check.trailingWhitespace = tail.spaces > tail.content
+	check.cleaned = len(cleaned) != len(data)
+	check.finalEOL = finalEOLStyle(data, asciiNewlines)
+	return check, fixed
+}
+
+// endsWithIncompleteRune reports whether data ends with the first bytes
+// of a multibyte UTF-8 sequence but not the rest
+func endsWithIncompleteRune(data []byte) bool {
+	for i := len(data) - 1; i >= max(len(data)-utf8.UTFMax, 0); i-- {
+		if utf8.RuneStart(data[i]) {
+			return !utf8.FullRune(data[i:])
+		}
+	}
+	return false
+}
+
+// finalEOLStyle returns the style of the newline ending data, or an empty
+// string when data doesn't end with one
+func finalEOLStyle(data []byte, nl newlines) string {
+	switch {
+	case bytes.HasSuffix(data, nl.crlf):
+		return eolCRLF
+	case bytes.HasSuffix(data, nl.lf):
+		return eolLF
+	}
+	return ""
+}
+
+// checkFinalNewline checks the final newline of non-empty text content
+func checkFinalNewline(data []byte, nl newlines, opts Options) (fileCheck, []byte) {
+	lf, crlf := nl.lf, nl.crlf
+
+	if want := opts.finalNewlines(); want > 0 {
+		if content, last, n := trimNewlines(data, nl); n > 0 && n != want {
+			switch opts.EOL {
+			case eolLF:
+				last = lf
+			case eolCRLF:
+				last = crlf
+			}
+			check := fileCheck{extraNewlines: n > want, fewNewlines: n < want, blankLinesAdded: max(want-n, 0)}
+			return check, replaceSuffix(content, nil, bytes.Repeat(last, want))
+		}
+	}
+
+	// Check if file ends with the required newline. When LF is required,
+	// a bare CR ending the file is taken as the terminator to replace, as
+	// appending LF would leave a CRLF.
+	cr := crlf[:len(crlf)-len(lf)]
+	switch {
+	case opts.EOL == eolLF && bytes.HasSuffix(data, crlf):
+		return fileCheck{wrongEOL: true}, replaceSuffix(data, crlf, lf)
+	case opts.EOL == eolLF && bytes.HasSuffix(data, cr):
+		return fileCheck{wrongEOL: true}, replaceSuffix(data, cr, lf)
+	case opts.EOL == eolCRLF && bytes.HasSuffix(data, crlf):
+		return fileCheck{ok: true}, data
+	case opts.EOL == eolCRLF && bytes.HasSuffix(data, lf):
+		return fileCheck{wrongEOL: true}, replaceSuffix(data, lf, crlf)
+	case bytes.HasSuffix(data, lf):
+		return fileCheck{ok: true}, data
+	}
+
+	// Add newlines at the end
+	newline := lf
+	if opts.EOL == eolCRLF {
+		newline = crlf
+	}
+	count := max(opts.finalNewlines(), 1)
+	lines := bytes.Count(data, lf)
+	check := fileCheck{noLineTerminators: lines == 0, lastLine: lines + 1, blankLinesAdded: count - 1}
+	return check, replaceSuffix(data, nil, bytes.Repeat(newline, count))
+}
+
+// trimNewlines strips the line terminators ending data. It returns the
+// rest, the final terminator, whose style is kept, and how many
+// terminators were removed.
+func trimNewlines(data []byte, nl newlines) ([]byte, []byte, int) {
+	var final []byte
+	n := 0
+	for {
+		var term []byte
+		switch {
+		case bytes.HasSuffix(data, nl.crlf):
+			term = nl.crlf
+		case bytes.HasSuffix(data, nl.lf):
+			term = nl.lf
+		default:
+			return data, final, n
+		}
+
+		if n == 0 {
+			final = term
+		}
+		data = data[:len(data)-len(term)]
+		n++
+	}
+}
+
+// replaceSuffix returns a copy of data with suffix old replaced by repl.
+// data must end with old.
+func replaceSuffix(data, old, repl []byte) []byte {
+	kept := data[:len(data)-len(old)]
+	fixed := make([]byte, len(kept), len(kept)+len(repl))
+	copy(fixed, kept)
+	return append(fixed, repl...)
+}
+
 // checkAndFixFile checks if a file ends with newline and fixes it if needed
-func checkAndFixFile(path string, fix bool) (bool, error) {
+func checkAndFixFile(path string, opts Options) (fileCheck, error) {
+	// Most files already end with newline; confirm that cheaply when
+	// possible and fall back to a full read otherwise
+	if canUseFastPath(path, opts) {
+		if ok, err := endsWithNewlineFast(path); err == nil && ok {
+			return fileCheck{ok: true, finalEOL: eolLF}, nil
+		}
+	}
+
 	// Read file
-	data, err := os.ReadFile(path)
+	data, err := readFile(path, opts.Retries)
 	if err != nil {
-		return false, fmt.Errorf("failed to read file: %w", err)
+		return fileCheck{}, &ReadError{Path: path, Err: err}
 	}
 
-	// Skip empty files
+	// Empty files are placeholders, such as __init__.py or .keep, and are
+	// never written whatever fixes are enabled
 	if len(data) == 0 {
-		return true, nil
+		return fileCheck{ok: true}, nil
 	}
 
-	// Skip binary files
-	if isBinary(data) {
-		return true, nil
+	check, fixed := checkBytes(data, opts)
+
+	if check.changes(opts) {
+		if !opts.confirmFix(path, data) {
+			check.declined = true
+			return check, nil
+		}
+		fixed = fixedContent(data, fixed, check, opts)
+
+		if opts.Patch != nil {
+			opts.Patch.add(path, data, fixed)
+			return check, nil
+		}
+
+		// Write back to file, keeping its current permissions
+		err = writeFile(path, fixed, fileModeFor(path, opts), opts.Retries)
+		if err != nil {
+			return fileCheck{}, &WriteError{Path: path, Err: err}
+		}
+	}
+
+	return check, nil
+}
+
+// fixedContent returns what a file needing changes under opts is
+// rewritten with. checkBytes always adds a missing final newline, so when
+// only the tail cleanups are enabled they are applied on their own.
+func fixedContent(data, fixed []byte, check fileCheck, opts Options) []byte {
+	if !check.ok && !opts.Fix {
+		return cleanTail(data, opts)
+	}
+	return fixed
+}
+
+// defaultFileMode is used for written files when no mode is known or configured
+const defaultFileMode os.FileMode = 0o644
+
+// parseFileMode parses an octal permission string such as "0644"
+func parseFileMode(s string) (os.FileMode, error) {
+	mode, err := strconv.ParseUint(s, 8, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid file mode %q: must be octal", s)
+	}
+	if mode > 0o777 {
+		return 0, fmt.Errorf("invalid file mode %q: must be between 0000 and 0777", s)
+	}
+	return os.FileMode(mode), nil
+}
+
+// fileModeFor returns the permissions to write path with: its existing
+// mode if it can be determined, otherwise the configured mode
+func fileModeFor(path string, opts Options) os.FileMode {
+	if info, err := os.Stat(path); err == nil {
+		return info.Mode().Perm()
+	}
+	return opts.newFileMode()
+}
+
+// isBinary applies Options.IsBinary, or the built-in detection with the
+// configured text control bytes
+func (o Options) isBinary(data []byte) bool {
+	if o.IsBinary != nil {
+		return o.IsBinary(data)
+	}
+	if o.TextControls != nil {
+		return isBinaryWith(data, o.TextControls)
+	}
+	return isBinary(data)
+}
+
+// finalNewlines returns how many newlines must end the content, or 0
+// when any number of at least one is accepted
+func (o Options) finalNewlines() int {
+	if o.FinalNewlines > 0 {
+		return o.FinalNewlines
+	}
+	if o.SingleFinalNewline {
+		return 1
+	}
+	return 0
+}
+
+// writesFiles reports whether any fix that rewrites files is enabled
+func (o Options) writesFiles() bool {
+	return o.Fix || o.FixBlankLines || o.FixWhitespace
+}
+
+// confirmFix asks Options.Confirm whether to fix path, allowing every fix
+// when no confirmation is configured
+func (o Options) confirmFix(path string, data []byte) bool {
+	return o.Confirm == nil || o.Confirm(path, data)
+}
+
+// newFileMode returns the mode for files that don't exist yet
+func (o Options) newFileMode() os.FileMode {
+	if o.FileMode == 0 {
+		return defaultFileMode
+	}
+	return o.FileMode
+}
+
+// displayPath returns path relative to root in a stable, slash-separated form
+// regardless of how root was specified (".", trailing slash, etc.)
+func displayPath(root, path string) string {
+	relPath, err := filepath.Rel(root, path)
+	if err != nil {
+		relPath = path
+	}
+
+	// Clean strips any leading "./" left over from the fallback path
+	return filepath.ToSlash(filepath.Clean(relPath))
+}
+
+// Options controls how files are checked and how results are reported
+type Options struct {
+	Fix        bool
+	Format     string
+	ReportFile string
+	// ReportFormat, when set, is the format of ReportFile while Format
+	// applies to stdout
+	ReportFormat string
+	// OutputEncoding selects the line endings and byte order mark of
+	// ReportFile: outputLF (the default when empty), outputCRLF,
+	// outputLFBOM or outputCRLFBOM
+	OutputEncoding string
+	Verbose        bool
+	// FileMode is used for files whose original mode can't be determined
+	FileMode os.FileMode
+	// DiffExit makes a fix run exit non-zero when any file was changed
+	DiffExit bool
+	// WalkOrder reports files in the order they were processed instead of
+	// sorting them by path
+	WalkOrder bool
+	// Quarantine, when set, receives fixed copies of problematic files
+	// under their relative paths instead of the originals being required
+	// to change
+	Quarantine string
+	// FullScan always reads whole files instead of checking the last byte
+	// of files with well-known text extensions
+	FullScan bool
+	// TextControls overrides the control bytes isBinary treats as text
+	TextControls *controlSet
+	// IsBinary, when set, replaces the built-in binary detection and
+	// TextControls. It is called with the whole content of every
+	// non-empty file that is checked, decoded to UTF-8 when Encoding is
+	// set, and returns true to skip the file as binary. UTF-16 content
+	// with a byte order mark is recognized before it is consulted. It
+	// must not modify or retain data. Setting it disables the last-byte
+	// fast path so that no file escapes it.
+	IsBinary func(data []byte) bool
+	// ForceText checks the file even if its extension or content looks binary
+	ForceText bool
+	// NoExtSkip checks files with a binary extension, leaving it to the
+	// content to tell whether they are binary
+	NoExtSkip bool
+	// ErrorOnBinaryContent reports files whose content looks binary as
+	// errors instead of skipping them. Files with a binary extension are
+	// still skipped without being read.
+	ErrorOnBinaryContent bool
+	// Required lists files, relative to each checked directory, whose
+	// absence is reported in MissingRequired
+	Required []string
+	// PreserveTrailingContent reports text files ending in an incomplete
+	// UTF-8 sequence as errors instead of appending a newline, as the
+	// content was probably truncated
+	PreserveTrailingContent bool
+	// Strict also fails check runs on trailing whitespace and blank lines
+	// that were not removed
+	Strict bool
+	// EOL requires the final newline to be LF or CRLF. Empty accepts either.
+	EOL string
+	// Confirm, when set, is asked before each fix and may decline it
+	Confirm func(path string, data []byte) bool
+	// Patch, when set, collects the fixes as a unified diff instead of
+	// writing them to the files
+	Patch *patchSet
+	// MinFileSize skips non-empty files smaller than this many bytes
+	MinFileSize int64
+	// SingleFinalNewline treats several newlines at the end as a problem
+	// and fixes them to exactly one
+	SingleFinalNewline bool
+	// FinalNewlines, when positive, requires exactly this many newlines
+	// at the end, adding or removing newlines to fix it. It takes
+	// precedence over SingleFinalNewline.
+	FinalNewlines int
+	// NoBOM reports files starting with a UTF-8 byte order mark, and
+	// strips the mark with Fix
+	NoBOM bool
+	// FixBlankLines removes blank lines after the last line of content
+	FixBlankLines bool
+	// FixWhitespace removes spaces and tabs ending the last line of content
+	FixWhitespace bool
+	// SkipCommand, when set, is asked whether to skip each file
+	SkipCommand *skipCommand
+	// ModifiedSince, when set, skips files last modified before it
+	ModifiedSince time.Time
+	// SkipDirs holds directory base names that are never walked into
+	SkipDirs map[string]bool
+	// IgnoreCase matches SkipDirs names in either case. Ignore and
+	// GitAttributes are told separately when they are created.
+	IgnoreCase bool
+	// ConfineToRoot skips symlinks found while walking or listed by git
+	// that resolve outside the checked tree
+	ConfineToRoot bool
+	// SkipSymlinks skips symbolic links instead of checking their
+	// targets. Without it, links are checked but never fixed.
+	SkipSymlinks bool
+	// Ignore, when set, skips files excluded by .gitignore-style files
+	Ignore *ignoreRules
+	// ExtEOL, when set, maps extensions to the required final newline for
+	// files whose EOL isn't otherwise set
+	ExtEOL map[string]string
+	// BlankLinePolicy, when set, maps extensions to whether blank lines
+	// before the final newline fail the check (deny) or are not reported
+	// at all (allow). Other files have them reported without failing.
+	BlankLinePolicy map[string]string
+	// GitAttributes, when set, applies .gitattributes text and eol settings
+	GitAttributes *gitAttributes
+	// Cache, when set, skips reading files unchanged since the last run
+	Cache *fileCache
+	// DetailedExit reports each problem category as a bit of the exit code
+	DetailedExit bool
+	// WarnOnly keeps the exit code 0 when a check run finds problems
+	WarnOnly bool
+	// Archive checks the members of .zip, .tar and .tar.gz arguments
+	// instead of skipping them as binary
+	Archive bool
+	// Retries is how many times a read or write failing with a transient
+	// error is repeated
+	Retries int
+	// SummaryTemplate, when set, replaces the text summary
+	SummaryTemplate *template.Template
+	// Encoding, when set, is the declared encoding of the checked content
+	// and disables UTF-16 autodetection
+	Encoding encoding.Encoding
+	// MaxErrors, when positive, stops the run once more files than this
+	// failed to be read or written
+	MaxErrors int
+	// Context, when set, stops the run before the next file once it is
+	// done. -timeout sets errTimeout as its cause.
+	Context context.Context
+	// PathStyle is how reported paths are displayed: relative to the
+	// checked root (the default), absolute, or relative to the working
+	// directory
+	PathStyle string
+	// PathPrefix is prepended to every displayed path, e.g. a repository
+	// name when reports of several repositories are aggregated
+	PathPrefix string
+	// PathMap, when set, renames displayed paths after PathStyle is
+	// applied
+	PathMap *pathMap
+	// CountLinesAdded reports the files changed and lines added by the fix
+	CountLinesAdded bool
+	// ReportClean lists compliant files in check mode reports
+	ReportClean bool
+	// Timing records how long each file takes, keeping the TimingTop
+	// slowest (all of them when TimingTop is 0)
+	Timing    bool
+	TimingTop int
+	// MaxReport limits the problematic files listed by the text report
+	// to the first N (0 lists all). Counts and the exit code still cover
+	// every file.
+	MaxReport int
+	// EOLMajority reports the final newline style most checked files use
+	// and the files ending in the other one
+	EOLMajority bool
+	// SummaryStream is where the text summary goes, stdout or stderr.
+	// Stdout leaves it with the report, as before.
+	SummaryStream string
+	// Summary selects how a run over several paths is summarized:
+	// summaryCombined (the default when empty), summaryPerRoot or
+	// summaryBoth
+	Summary string
+	// GeneratedPattern, when set, skips files whose leading lines match it
+	GeneratedPattern *regexp.Regexp
+	// SkipContent, when set, skips files whose first chunk matches it
+	SkipContent *regexp.Regexp
+}
+
+// FileError records a file that could not be processed
+type FileError struct {
+	Path string `json:"path"`
+	Err  string `json:"error"`
+}
+
+// Run modes recorded in RepoResult
+const (
+	modeCheck = "check"
+	modeFix   = "fix"
+)
+
+// Per-file statuses recorded in FileResult
+const (
+	statusOK      = "ok"
+	statusMissing = "missing_newline"
+	statusFixed   = "fixed"
+	statusPatched = "patched"
+	statusSkipped = "skipped"
+	statusBinary  = "binary"
+	statusError   = "error"
+)
+
+// FileResult is the outcome for one processed file
+type FileResult struct {
+	Path   string `json:"path"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+	// Line is the 1-based number of the last line when it lacks its
+	// line terminator
+	Line int `json:"line,omitempty"`
+	// Bytes is the size of a checked file before any fix
+	Bytes int64 `json:"bytes,omitempty"`
+	// Step names the decision that settled the file, if any
+	Step string `json:"-"`
+	// EOL is the style of the final newline of a checked text file before
+	// any fix, empty when it had none
+	EOL string `json:"eol,omitempty"`
+}
+
+// RepoResult holds the outcome of processing a repository. The process
+// functions return it without printing; writeResult formats it.
+type RepoResult struct {
+	Mode    string   `json:"mode"`
+	Total   int      `json:"total_files"`
+	Skipped int      `json:"skipped_files"`
+	Cached  int      `json:"cached_files"`
+	Fixed   []string `json:"fixed"`
+	// Patched lists files whose fixes went to -output-patch instead of
+	// being written. They are fixed once the patch is applied.
+	Patched []string `json:"patched"`
+	// Quarantined lists files whose fixed version was written to the
+	// quarantine directory
+	Quarantined []string `json:"quarantined"`
+	Generated   []string `json:"generated"`
+	// Binary lists files that were not checked because their content
+	// looks binary. They are still counted as checked.
+	Binary      []string `json:"binary"`
+	Problematic []string `json:"problematic"`
+	// Categories maps each problem category enabled for the run to the
+	// files left with that problem
+	Categories map[string][]string `json:"categories,omitempty"`
+	// NoLineTerminators lists the fixed or problematic files that contain
+	// no newline at all
+	NoLineTerminators []string `json:"no_line_terminators"`
+	// LastLines maps each fixed or problematic file missing its final
+	// newline to the 1-based number of its unterminated last line
+	LastLines map[string]int `json:"last_lines,omitempty"`
+	// TrailingBlankLines and TrailingWhitespace list files with blank
+	// lines or whitespace after their content, whether or not they were
+	// fixed. They don't affect the exit code.
+	TrailingBlankLines []string `json:"trailing_blank_lines"`
+	TrailingWhitespace []string `json:"trailing_whitespace"`
+	// UTF8BOM lists the text files starting with a UTF-8 byte order mark,
+	// whether or not it was stripped. It is only filled with -no-bom.
+	UTF8BOM []string `json:"utf8_bom,omitempty"`
+	// MissingRequired lists the files named by -require that don't exist
+	MissingRequired []string `json:"missing_required,omitempty"`
+	// Confined lists the symlinks skipped by -confine-to-root because
+	// they resolve outside the checked tree
+	Confined []string `json:"confined_symlinks,omitempty"`
+	// FilesChanged and LinesAdded describe the diff a fix commit has, or
+	// in check mode would have. They are only filled with
+	// -count-lines-added.
+	FilesChanged int `json:"files_changed,omitempty"`
+	LinesAdded   int `json:"lines_added,omitempty"`
+	// Clean lists the files that already end with a newline. It is only
+	// filled in check mode with -report-clean.
+	Clean  []string    `json:"clean,omitempty"`
+	Errors []FileError `json:"errors"`
+	// Compliance is the percentage of checked files whose final newline
+	// was compliant before any fix. Files that could not be processed
+	// are left out.
+	Compliance float64 `json:"compliance_percent"`
+	// Slowest lists the files that took longest, slowest first. It is
+	// only filled with -timing.
+	Slowest []FileTiming `json:"slowest_files,omitempty"`
+	// DurationMS and FilesPerSec are the wall-clock time of the run and
+	// the checked files per second. They are only filled with -timing.
+	DurationMS  int64   `json:"duration_ms,omitempty"`
+	FilesPerSec float64 `json:"files_per_sec,omitempty"`
+	// Roots has the share of each path named on the command line in the
+	// totals above. It is only filled with -summary per-root or both.
+	Roots []RootSummary `json:"roots,omitempty"`
+	// EOLMajority is the majority final newline style and the files
+	// deviating from it. It is only filled with -eol-majority.
+	EOLMajority *EOLMajority `json:"eol_majority,omitempty"`
+	// Files has one entry per processed file, checked or skipped
+	Files []FileResult `json:"-"`
+
+	// links maps hard-linked files to the first path they were seen at
+	links map[fileID]string
+	// visited holds the absolute paths of the files processed so far when
+	// the roots overlap
+	visited map[string]bool
+	// paths maps displayed paths to absolute ones for -paths
+	paths map[string]string
+	// started is when the run began
+	started time.Time
+	// nonCompliant counts the checked files whose final newline broke
+	// the policy before any fix, so that compliance doesn't depend on
+	// the mode
+	nonCompliant int
+}
+
+// fileID identifies an underlying file independently of its path
+type fileID struct {
+	dev, ino uint64
+}
+
+// newRepoResult returns an empty result for a run with the given options
+func newRepoResult(opts Options) *RepoResult {
+	result := &RepoResult{
+		Mode:               modeCheck,
+		Fixed:              []string{},
+		Patched:            []string{},
+		Quarantined:        []string{},
+		Generated:          []string{},
+		Binary:             []string{},
+		Problematic:        []string{},
+		NoLineTerminators:  []string{},
+		TrailingBlankLines: []string{},
+		TrailingWhitespace: []string{},
+		Errors:             []FileError{},
+		started:            time.Now(),
 	}
+	if opts.Fix {
+		result.Mode = modeFix
+	}
+	return result
+}
 
-	// Check if file ends with newline
-	endsWithNewline := bytes.HasSuffix(data, []byte("\n"))
+// sortPaths orders every file list lexically by path so that output is
+// stable regardless of how files were visited
+func (r *RepoResult) sortPaths() {
+	for _, list := range [][]string{r.Fixed, r.Patched, r.Quarantined, r.Generated, r.Binary, r.Problematic, r.NoLineTerminators, r.TrailingBlankLines, r.TrailingWhitespace, r.UTF8BOM, r.Clean, r.Confined, r.MissingRequired} {
+		slices.Sort(list)
+	}
+	for _, root := range r.Roots {
+		slices.Sort(root.Problematic)
+	}
+	slices.SortStableFunc(r.Errors, func(a, b FileError) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	slices.SortStableFunc(r.Files, func(a, b FileResult) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+}
 
-	if !endsWithNewline && fix {
-		// Add newline at the end
-		data = append(data, '\n')
+// compliancePercent returns the share of checked files whose final
+// newline was compliant before any fix. Trailing blank lines only count
+// when -blank-line-policy denies them and trailing whitespace never does,
+// so the share is the same in check and fix mode. Files that failed
+// with an error were never checked, so they count neither way. A run that
+// checked nothing is fully compliant.
+func (r *RepoResult) compliancePercent() float64 {
+	checked := r.Total - len(r.Errors)
+	if checked <= 0 {
+		return 100
+	}
+	compliant := checked - r.nonCompliant
+	return math.Round(float64(compliant)/float64(checked)*1000) / 10
+}
 
-		// Write back to file
-		err = os.WriteFile(path, data, 0o644)
+// finish completes a result once every file has been processed
+func (r *RepoResult) finish(opts Options) {
+	r.relabel(opts)
+	if opts.PathMap != nil {
+		r.rewritePaths(opts.PathMap.display)
+	}
+	if opts.PathPrefix != "" {
+		r.rewritePaths(prefixPath(opts.PathPrefix))
+	}
+	if !opts.WalkOrder {
+		r.sortPaths()
+	}
+	if opts.Timing {
+		r.keepSlowest(opts)
+		r.recordThroughput()
+	}
+	for _, file := range r.Files {
+		if file.Line > 0 {
+			if r.LastLines == nil {
+				r.LastLines = make(map[string]int)
+			}
+			r.LastLines[file.Path] = file.Line
+		}
+	}
+	if opts.EOLMajority {
+		r.EOLMajority = majorityEOL(r.Files)
+	}
+	r.categorize(opts)
+	r.Compliance = r.compliancePercent()
+}
+
+// Skip reasons for files that are not regular
+const (
+	reasonNamedPipe = "named pipe"
+	reasonDevice    = "device file"
+	reasonSocket    = "socket"
+	reasonIrregular = "not a regular file"
+)
+
+// irregularReason returns why the file at path, or the target of a
+// symlink there, is not a regular file that can be read, or "" when it
+// is one. Dangling links are left for the read to report.
+func irregularReason(path string, info os.FileInfo) string {
+	mode := info.Mode()
+	if mode&os.ModeSymlink != 0 {
+		target, err := os.Stat(path)
 		if err != nil {
-			return false, fmt.Errorf("failed to write file: %w", err)
+			return ""
 		}
+		mode = target.Mode()
+	}
+
+	switch {
+	case mode.IsRegular():
+		return ""
+	case mode&os.ModeNamedPipe != 0:
+		return reasonNamedPipe
+	case mode&os.ModeDevice != 0:
+		return reasonDevice
+	case mode&os.ModeSocket != 0:
+		return reasonSocket
+	}
+	return reasonIrregular
+}
 
-		return false, nil
+// processFile checks and potentially fixes a single file, recording the
+// outcome under relPath. The hidden and binary extension checks look at
+// skipPath instead, which is the part of the path below its root. It
+// makes the decisions of fileSteps in order until one of them settles
+// the file.
+func processFile(path, relPath, skipPath string, info os.FileInfo, opts Options, result *RepoResult) {
+	if result.visitedBefore(path) {
+		return
 	}
+	defer result.timeFile(relPath, opts)()
+	result.rememberPath(relPath, path, opts)
 
-	return endsWithNewline, nil
+	v := &fileVisit{path: path, relPath: relPath, skipPath: skipPath, info: info, opts: opts, result: result}
+	for _, step := range fileSteps {
+		if step.content && !v.read {
+			if !v.readContent() {
+				return
+			}
+		}
+		if !step.decide(v) {
+			if n := len(result.Files); n > 0 {
+				result.Files[n-1].Step = step.name
+			}
+			return
+		}
+	}
+	v.record()
+}
+
+// fileVisit is what processFile knows about the file it is processing,
+// shared by its steps
+type fileVisit struct {
+	path, relPath, skipPath string
+	info                    os.FileInfo
+	// opts are the options for this file, which steps may adjust
+	opts   Options
+	result *RepoResult
+	// read is set once the content has been checked into check
+	read  bool
+	check fileCheck
+}
+
+// fileStep is one decision processFile makes about a file
+type fileStep struct {
+	name string
+	// enabled reports whether the step can settle a file under opts
+	enabled func(opts Options) bool
+	// content is set for the steps that decide from the checked content
+	content bool
+	// decide returns false when it has recorded the file and nothing is
+	// left to do
+	decide func(v *fileVisit) bool
+}
+
+func stepAlways(Options) bool { return true }
+
+// fileSteps are the decisions of processFile in the order it makes them.
+// -trace-path reports the same steps.
+var fileSteps = []fileStep{
+	{"symlink", func(o Options) bool { return o.SkipSymlinks }, false, (*fileVisit).decideSymlink},
+	{"irregular", stepAlways, false, (*fileVisit).decideIrregular},
+	{"ignore-file", func(o Options) bool { return o.Ignore != nil }, false, (*fileVisit).decideIgnored},
+	{"min-file-size", func(o Options) bool { return o.MinFileSize > 0 }, false, (*fileVisit).decideMinSize},
+	{"modified-since", func(o Options) bool { return !o.ModifiedSince.IsZero() }, false, (*fileVisit).decideModified},
+	{"gitattributes", func(o Options) bool { return o.GitAttributes != nil }, false, (*fileVisit).decideGitAttributes},
+	{"hidden", stepAlways, false, (*fileVisit).decideHidden},
+	{"binary-extension", func(o Options) bool { return !o.ForceText && !o.NoExtSkip }, false, (*fileVisit).decideBinaryExt},
+	{"skip-command", func(o Options) bool { return o.SkipCommand != nil }, false, (*fileVisit).decideSkipCommand},
+	{"hard-link", stepAlways, false, (*fileVisit).decideHardLink},
+	{"binary-content", func(o Options) bool { return !o.ForceText }, true, (*fileVisit).decideBinaryContent},
+	{"generated", func(o Options) bool { return o.GeneratedPattern != nil }, true, (*fileVisit).decideGenerated},
+	{"skip-content", func(o Options) bool { return o.SkipContent != nil }, true, (*fileVisit).decideSkipContent},
+}
+
+// skip records the file as skipped for reason and stops processing it
+func (v *fileVisit) skip(reason string) bool {
+	v.result.addSkipped(v.relPath, reason)
+	return false
+}
+
+// decideSymlink skips symlinks with SkipSymlinks. Otherwise they are
+// checked but not fixed, as writing through a link would silently
+// rewrite its target, which may be shared or live outside the tree.
+func (v *fileVisit) decideSymlink() bool {
+	if v.info.Mode()&os.ModeSymlink == 0 {
+		return true
+	}
+	if v.opts.SkipSymlinks {
+		return v.skip("symlink")
+	}
+	v.opts.Fix, v.opts.FixBlankLines, v.opts.FixWhitespace = false, false, false
+	return true
+}
+
+// decideIrregular skips FIFOs, devices and sockets, as reading them may
+// block forever or never end
+func (v *fileVisit) decideIrregular() bool {
+	if reason := irregularReason(v.path, v.info); reason != "" {
+		return v.skip(reason)
+	}
+	return true
 }
 
-// processRepository walks through the repository and processes files
-func processRepository(repoPath string, fix bool) error {
-	var totalFiles, fixedFiles, skippedFiles int
-	var problematicFiles []string
+func (v *fileVisit) decideIgnored() bool {
+	if v.opts.Ignore == nil {
+		return true
+	}
+	ignored, err := v.opts.Ignore.ignored(v.path, false)
+	if err != nil {
+		v.result.addError(v.relPath, err)
+		return false
+	}
+	if ignored {
+		return v.skip("ignore file")
+	}
+	return true
+}
 
+// decideMinSize skips tiny files without reading them. Empty files are
+// still checked and always pass.
+func (v *fileVisit) decideMinSize() bool {
+	if size := v.info.Size(); size > 0 && size < v.opts.MinFileSize {
+		return v.skip("smaller than -min-file-size")
+	}
+	return true
+}
+
+// decideModified skips files not touched recently without reading them
+func (v *fileVisit) decideModified() bool {
+	if !v.opts.ModifiedSince.IsZero() && v.info.ModTime().Before(v.opts.ModifiedSince) {
+		return v.skip("not modified recently")
+	}
+	return true
+}
+
+// decideGitAttributes applies .gitattributes and then the per-extension
+// newline styles to the options for the file
+func (v *fileVisit) decideGitAttributes() bool {
+	if !applyGitAttributes(v.path, v.relPath, &v.opts, v.result) {
+		return false
+	}
+	applyExtEOL(v.relPath, &v.opts)
+	return true
+}
+
+func (v *fileVisit) decideHidden() bool {
+	if isHiddenPath(v.skipPath) {
+		return v.skip(hiddenReason)
+	}
+	return true
+}
+
+func (v *fileVisit) decideBinaryExt() bool {
+	if reason := v.opts.binaryExtReason(v.skipPath); reason != "" {
+		return v.skip(reason)
+	}
+	return true
+}
+
+func (v *fileVisit) decideSkipCommand() bool {
+	return applySkipCommand(v.path, v.relPath, v.opts, v.result)
+}
+
+// decideHardLink processes a file with several hard links only once,
+// under the first path it was seen at
+func (v *fileVisit) decideHardLink() bool {
+	id, ok := hardLinkID(v.info)
+	if !ok {
+		return true
+	}
+	if canonical, seen := v.result.links[id]; seen {
+		return v.skip("hard link to " + canonical)
+	}
+	if v.result.links == nil {
+		v.result.links = make(map[fileID]string)
+	}
+	v.result.links[id] = v.relPath
+	return true
+}
+
+// decideBinaryContent records a file whose content looks binary, which
+// counts as checked but has nothing more to check
+func (v *fileVisit) decideBinaryContent() bool {
+	if v.check.binary {
+		v.record()
+		return false
+	}
+	return true
+}
+
+func (v *fileVisit) decideGenerated() bool {
+	if v.check.generated {
+		v.skip("generated-code marker")
+		v.result.Generated = append(v.result.Generated, v.relPath)
+		return false
+	}
+	return true
+}
+
+func (v *fileVisit) decideSkipContent() bool {
+	if v.check.skippedContent {
+		return v.skip(skipContentReason)
+	}
+	return true
+}
+
+// readContent checks and potentially fixes the file, reusing the last
+// result for an unchanged file when it needs no fix. It returns false
+// when the file has been recorded.
+func (v *fileVisit) readContent() bool {
+	v.read = true
+	opts, result := v.opts, v.result
+
+	if opts.Cache != nil {
+		if entry, ok := opts.Cache.lookup(v.path, v.info, opts); ok && !entry.needsRead(opts) {
+			result.Cached++
+			check := entry.check()
+			recordCheck(v.relPath, check, opts, result)
+			return false
+		}
+	}
+
+	check, err := checkAndFixFile(v.path, opts)
+	if vanished(v.path, err) {
+		// Deleted by another process since the walk saw it
+		return v.skip(vanishedReason)
+	}
+	if err != nil {
+		result.addError(v.relPath, err)
+		return false
+	}
+	v.check = check
+	return true
+}
+
+// record adds the checked file to the result, caching the outcome and
+// writing a quarantine copy as configured
+func (v *fileVisit) record() {
+	opts, result, check := v.opts, v.result, v.check
+
+	check.size = v.info.Size()
+	if v.info.Mode()&os.ModeSymlink != 0 {
+		if target, err := os.Stat(v.path); err == nil {
+			check.size = target.Size()
+		}
+	}
+
+	// A fixed file has changed on disk, so its old size and mtime are
+	// stale. A truncated file is an error, which is never cached.
+	if opts.Cache != nil && !check.changes(opts) && !check.truncatedUTF8 {
+		opts.Cache.store(v.path, v.info, check, opts)
+	}
+
+	if !check.ok && opts.Quarantine != "" {
+		if err := quarantineFile(v.path, v.relPath, opts); err != nil {
+			result.Errors = append(result.Errors, FileError{Path: v.relPath, Err: err.Error()})
+		} else {
+			result.Quarantined = append(result.Quarantined, v.relPath)
+		}
+	}
+
+	recordCheck(v.relPath, check, opts, result)
+}
+
+// applyGitAttributes adjusts opts for path according to .gitattributes:
+// -text skips the file, text and eol force the text check, and eol
+// selects the required newline. It returns false when the file has been
+// recorded as skipped or failed and must not be checked.
+func applyGitAttributes(path, relPath string, opts *Options, result *RepoResult) bool {
+	if opts.GitAttributes == nil {
+		return true
+	}
+
+	attrs, err := opts.GitAttributes.lookup(path)
+	if err != nil {
+		result.addError(relPath, err)
+		return false
+	}
+
+	if attrs.text == attrUnset {
+		result.addSkipped(relPath, "gitattributes -text")
+		return false
+	}
+	if attrs.text == attrSet || attrs.eol == eolLF || attrs.eol == eolCRLF {
+		opts.ForceText = true
+	}
+	if attrs.eol == eolLF || attrs.eol == eolCRLF {
+		opts.EOL = attrs.eol
+	}
+	return true
+}
+
+// applySkipCommand consults Options.SkipCommand about path. It returns
+// false when the file has been recorded as skipped or failed.
+func applySkipCommand(path, relPath string, opts Options, result *RepoResult) bool {
+	if opts.SkipCommand == nil {
+		return true
+	}
+
+	skip, err := opts.SkipCommand.skip(path)
+	if err != nil {
+		result.addError(relPath, err)
+		return false
+	}
+	if skip {
+		result.addSkipped(relPath, "skip command")
+		return false
+	}
+	return true
+}
+
+// errBinaryContent is the error recorded with -error-on-binary-content
+// for a file whose content looks binary
+var errBinaryContent = errors.New("content looks binary")
+
+// errTruncatedUTF8 is the error recorded with -preserve-trailing-content
+// for a file ending in an incomplete UTF-8 sequence
+var errTruncatedUTF8 = errors.New("content ends with an incomplete UTF-8 sequence and may be truncated; not appending a newline")
+
+// recordCheck adds the outcome of checking a file to result
+func recordCheck(relPath string, check fileCheck, opts Options, result *RepoResult) {
+	if check.binary && opts.ErrorOnBinaryContent {
+		result.Binary = append(result.Binary, relPath)
+		result.addError(relPath, errBinaryContent)
+		return
+	}
+	if check.truncatedUTF8 {
+		result.addError(relPath, errTruncatedUTF8)
+		return
+	}
+
+	result.Total++
+
+	file := FileResult{Path: relPath, Status: statusOK, Line: check.lastLine, Bytes: check.size, EOL: check.finalEOL}
+	if check.binary {
+		result.Binary = append(result.Binary, relPath)
+		file.Status, file.Reason = statusBinary, "content looks binary"
+	}
+
+	if !check.ok {
+		result.nonCompliant++
+		file.Status, file.Reason = statusMissing, "missing final newline"
+		switch {
+		case check.wrongEOL:
+			file.Reason = "wrong final newline style"
+		case check.extraNewlines:
+			file.Reason = "multiple final newlines"
+		case check.fewNewlines:
+			file.Reason = "too few final newlines"
+		}
+		if opts.Fix && !check.declined {
+			result.markFixed(&file, opts)
+		} else {
+			result.Problematic = append(result.Problematic, relPath)
+		}
+		if check.noLineTerminators {
+			result.NoLineTerminators = append(result.NoLineTerminators, relPath)
+		}
+	}
+
+	policy := opts.blankLinePolicy(relPath)
+	if check.blankLines && policy != blankLineAllow {
+		result.TrailingBlankLines = append(result.TrailingBlankLines, relPath)
+	}
+	if check.ok && check.blankLines && policy == blankLineDeny {
+		result.nonCompliant++
+	}
+	if check.trailingWhitespace {
+		result.TrailingWhitespace = append(result.TrailingWhitespace, relPath)
+	}
+	if check.utf8BOM && opts.NoBOM {
+		result.UTF8BOM = append(result.UTF8BOM, relPath)
+	}
+	switch {
+	case check.ok && check.blankLines && policy == blankLineDeny && !opts.FixBlankLines:
+		file.Status, file.Reason = statusMissing, blankLineDeniedReason
+		result.Problematic = append(result.Problematic, relPath)
+	case check.ok && check.changes(opts):
+		file.Reason = tailReason(check, opts)
+		result.markFixed(&file, opts)
+	}
+
+	if opts.CountLinesAdded {
+		result.countChange(check, opts)
+	}
+	if opts.ReportClean && !opts.Fix && file.Status == statusOK {
+		result.Clean = append(result.Clean, relPath)
+	}
+
+	result.Files = append(result.Files, file)
+}
+
+// markFixed records file as fixed, or as patched when the fix went to
+// -output-patch and the file itself is unchanged
+func (r *RepoResult) markFixed(file *FileResult, opts Options) {
+	if opts.Patch != nil {
+		file.Status = statusPatched
+		r.Patched = append(r.Patched, file.Path)
+		return
+	}
+	file.Status = statusFixed
+	r.Fixed = append(r.Fixed, file.Path)
+}
+
+// tailReason describes the cleanups applied to a file
+func tailReason(check fileCheck, opts Options) string {
+	blank := check.blankLines && opts.FixBlankLines
+	space := check.trailingWhitespace && opts.FixWhitespace
+	reason := ""
+	switch {
+	case blank && space:
+		reason = "trailing blank lines and whitespace"
+	case blank:
+		reason = "trailing blank lines"
+	case space:
+		reason = "trailing whitespace"
+	}
+
+	if check.utf8BOM && opts.NoBOM && opts.Fix {
+		if reason == "" {
+			return "leading UTF-8 BOM"
+		}
+		reason += " and leading UTF-8 BOM"
+	}
+	return reason
+}
+
+// countChange adds one file's share of the fix diff. Adding or changing
+// the final newline, or stripping trailing whitespace, rewrites the last
+// line and so shows as one added line; removing extra newlines or blank
+// lines only deletes lines.
+func (r *RepoResult) countChange(check fileCheck, opts Options) {
+	if check.declined || (check.ok && !check.changes(opts)) {
+		return
+	}
+
+	r.FilesChanged++
+	rewritesLine := (!check.ok && !check.fewNewlines && (!check.extraNewlines || check.wrongEOL)) ||
+		(check.trailingWhitespace && opts.FixWhitespace)
+	if rewritesLine {
+		r.LinesAdded++
+	}
+	if !check.ok {
+		r.LinesAdded += check.blankLinesAdded
+	}
+	// Stripping the mark rewrites the first line, unless it is also the
+	// rewritten last one
+	if check.utf8BOM && opts.NoBOM && opts.Fix && !(rewritesLine && check.noLineTerminators) {
+		r.LinesAdded++
+	}
+}
+
+// errTooManyErrors stops a run once more than -max-errors files failed
+var errTooManyErrors = errors.New("too many errors")
+
+// overBudget returns errTooManyErrors, with the count, once the recorded
+// errors exceed opts.MaxErrors. A zero MaxErrors never stops the run.
+func (r *RepoResult) overBudget(opts Options) error {
+	if opts.MaxErrors > 0 && len(r.Errors) > opts.MaxErrors {
+		return fmt.Errorf("%w: %d files failed, more than -max-errors %d; stopped early", errTooManyErrors, len(r.Errors), opts.MaxErrors)
+	}
+	return nil
+}
+
+// errTimeout stops a run once -timeout has passed
+var errTimeout = errors.New("timed out")
+
+// stopReason returns why the run must stop before the next file: the
+// error budget ran out, or Options.Context is done, e.g. after -timeout
+func (r *RepoResult) stopReason(opts Options) error {
+	if err := r.overBudget(opts); err != nil {
+		return err
+	}
+	if opts.Context != nil && opts.Context.Err() != nil {
+		return context.Cause(opts.Context)
+	}
+	return nil
+}
+
+// isEarlyStop reports whether err is a stopReason, as opposed to a
+// failure of the run itself
+func isEarlyStop(err error) bool {
+	return errors.Is(err, errTooManyErrors) || errors.Is(err, errTimeout) || errors.Is(err, context.Canceled)
+}
+
+// addError records a file that could not be processed
+func (r *RepoResult) addError(relPath string, err error) {
+	r.Total++
+	r.Errors = append(r.Errors, FileError{Path: relPath, Err: err.Error()})
+	r.Files = append(r.Files, FileResult{Path: relPath, Status: statusError, Reason: err.Error()})
+}
+
+// vanishedReason is the skip reason for files deleted during the run
+const vanishedReason = "deleted during the run"
+
+// vanished reports whether err comes from path having been deleted, as
+// opposed to, say, a dangling symlink at path
+func vanished(path string, err error) bool {
+	if !errors.Is(err, os.ErrNotExist) {
+		return false
+	}
+	_, lerr := os.Lstat(path)
+	return errors.Is(lerr, os.ErrNotExist)
+}
+
+// addSkipped records a file that was not checked
+func (r *RepoResult) addSkipped(relPath, reason string) {
+	r.Skipped++
+	r.Files = append(r.Files, FileResult{Path: relPath, Status: statusSkipped, Reason: reason})
+}
+
+// walkRepository processes every file under repoPath into result. Displayed
+// paths are relative to repoPath, joined onto prefix when it is non-empty.
+func walkRepository(repoPath, prefix string, opts Options, result *RepoResult) error {
 	err := filepath.Walk(repoPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil && path != repoPath && vanished(path, err) {
+			// Listed in its directory but deleted before it was visited
+			relPath := displayPath(repoPath, path)
+			if prefix != "" {
+				relPath = prefix + "/" + relPath
+			}
+			result.addSkipped(relPath, vanishedReason)
+			return nil
+		}
 		if err != nil {
 			return err
 		}
 
 		// Skip directories
 		if info.IsDir() {
+			if isQuarantineDir(path, opts) || (path != repoPath && opts.skipsDir(info.Name())) {
+				return filepath.SkipDir
+			}
+			if opts.Ignore != nil && path != repoPath {
+				if ignored, err := opts.Ignore.ignored(path, true); err == nil && ignored {
+					return filepath.SkipDir
+				}
+			}
 			return nil
 		}
 
-		// Get relative path for display
-		relPath, err := filepath.Rel(repoPath, path)
-		if err != nil {
-			relPath = path
+		// Get relative path for display. The skip checks look at the
+		// part below repoPath only, so a root such as .work/proj is not
+		// hidden as a whole.
+		rootRel := displayPath(repoPath, path)
+		relPath := rootRel
+		if prefix != "" {
+			relPath = prefix + "/" + rootRel
 		}
 
-		// Skip files that should be ignored
-		if shouldSkipFile(relPath) {
-			skippedFiles++
-			return nil
+		if confineSymlink(repoPath, path, relPath, info, opts, result) {
+			processFile(path, relPath, rootRel, info, opts, result)
 		}
+		return result.stopReason(opts)
+	})
+	if isEarlyStop(err) {
+		return err
+	}
+	if err != nil {
+		return &WalkError{Path: repoPath, Err: err}
+	}
 
-		totalFiles++
+	return nil
+}
 
-		// Check and potentially fix the file
-		endsWithNewline, err := checkAndFixFile(path, fix)
-		if err != nil {
-			fmt.Printf("Error processing %s: %v\n", relPath, err)
-			return nil
+// processRepository walks through the repository and processes its
+// files, returning the collected result for the caller to report. A
+// file given as repoPath is checked on its own and displayed as given.
+func processRepository(repoPath string, opts Options) (*RepoResult, error) {
+	info, err := statPath(repoPath, opts)
+	if err != nil {
+		return nil, &WalkError{Path: repoPath, Err: err}
+	}
+
+	result := newRepoResult(opts)
+
+	if err := processPath(repoPath, "", info, opts, result); err != nil {
+		return partialResult(result, err, opts)
+	}
+
+	result.finish(opts)
+
+	return result, nil
+}
+
+// processPaths processes a list of files and directories into one
+// combined result. Directories are walked recursively; paths are
+// displayed as given.
+func processPaths(paths []string, opts Options) (*RepoResult, error) {
+	roots := make([]root, len(paths))
+	for i, path := range paths {
+		roots[i] = root{path: path, opts: opts}
+	}
+	return processRoots(roots, opts)
+}
+
+// root is a path named on the command line together with the options it
+// is checked with
+type root struct {
+	path string
+	opts Options
+}
+
+// processRoots processes several roots into one combined result like
+// processPaths, checking each with its own options. The run-wide state
+// and the summary come from opts. Overlapping roots are reported with a
+// warning, and the files they share are processed only once.
+func processRoots(roots []root, opts Options) (*RepoResult, error) {
+	result := newRepoResult(opts)
+	dedupOverlappingRoots(roots, result)
+
+	for _, r := range roots {
+		if err := result.stopReason(opts); err != nil {
+			return partialResult(result, err, opts)
 		}
 
-		if !endsWithNewline {
-			if fix {
-				fixedFiles++
-				fmt.Printf("Fixed: %s\n", relPath)
-			} else {
-				problematicFiles = append(problematicFiles, relPath)
-			}
+		before := result.rootCounts()
+		err := processRoot(r, opts, result)
+		if opts.Summary != "" && opts.Summary != summaryCombined {
+			result.Roots = append(result.Roots, result.rootSummary(r.path, before))
 		}
+		if err != nil {
+			return partialResult(result, err, opts)
+		}
+	}
+	if err := result.stopReason(opts); err != nil {
+		return partialResult(result, err, opts)
+	}
 
-		return nil
-	})
+	result.finish(opts)
+
+	return result, nil
+}
+
+// processRoot adds one root to result. A root that can't be examined is
+// recorded as an error; only errors that end the run are returned.
+func processRoot(r root, opts Options, result *RepoResult) error {
+	rootOpts := shareRunState(r.opts, opts)
+	display := filepath.ToSlash(filepath.Clean(r.path))
+	info, err := statPath(r.path, rootOpts)
 	if err != nil {
-		return fmt.Errorf("failed to walk repository: %w", err)
+		result.Errors = append(result.Errors, FileError{Path: display, Err: err.Error()})
+		return nil
 	}
+	return processPath(r.path, display, info, rootOpts, result)
+}
 
-	// Print summary
-	fmt.Printf("\n=== Summary ===\n")
-	fmt.Printf("Total files checked: %d\n", totalFiles)
-	fmt.Printf("Files skipped: %d\n", skippedFiles)
+// shareRunState returns rootOpts using the state that belongs to the
+// whole run in opts: its context, cache, patch, fix prompt and error
+// budget
+func shareRunState(rootOpts, opts Options) Options {
+	rootOpts.Context = opts.Context
+	rootOpts.Cache = opts.Cache
+	rootOpts.Patch = opts.Patch
+	rootOpts.Confirm = opts.Confirm
+	rootOpts.MaxErrors = opts.MaxErrors
+	return rootOpts
+}
+
+// statPath returns the file info of a path named on the command line.
+// Links named explicitly are followed unless -skip-symlinks is set.
+func statPath(path string, opts Options) (os.FileInfo, error) {
+	info, err := os.Lstat(path)
+	if err == nil && info.Mode()&os.ModeSymlink != 0 && !opts.SkipSymlinks {
+		info, err = os.Stat(path)
+	}
+	return info, err
+}
 
-	if fix {
-		fmt.Printf("Files fixed: %d\n", fixedFiles)
-		if fixedFiles == 0 {
-			fmt.Println("All files already end with newline!")
+// processPath adds one path named on the command line to result.
+// Directories are walked, with the files below them displayed relative
+// to the directory and joined onto prefix when it is non-empty; archives
+// are opened with -archive; anything else is checked as a single file
+// displayed as prefix, or as the cleaned path when prefix is empty.
+func processPath(path, prefix string, info os.FileInfo, opts Options, result *RepoResult) error {
+	if info.IsDir() {
+		if prefix == "." {
+			prefix = ""
 		}
-	} else {
-		fmt.Printf("Files missing newline: %d\n", len(problematicFiles))
-		if len(problematicFiles) > 0 {
-			fmt.Println("\nFiles that don't end with newline:")
-			for _, file := range problematicFiles {
-				fmt.Printf("  - %s\n", file)
-			}
-			fmt.Println("\nRun with -fix flag to automatically add newlines")
-		} else {
-			fmt.Println("All files end with newline!")
+		if err := walkRepository(path, prefix, opts, result); err != nil {
+			return err
 		}
+		checkRequired(path, prefix, opts, result)
+		return nil
 	}
 
+	display := prefix
+	if display == "" {
+		display = filepath.ToSlash(filepath.Clean(path))
+	}
+
+	if kind := archiveKind(path); opts.Archive && kind != "" {
+		if result.visitedBefore(path) {
+			return nil
+		}
+		result.rememberPath(display, path, opts)
+		processArchive(path, display, kind, opts, result)
+		return nil
+	}
+	// Only the name of a file given directly is its own, so a hidden
+	// directory on the way to it doesn't skip it
+	processFile(path, display, filepath.Base(path), info, opts, result)
 	return nil
 }
 
-func main() {
-	var fix bool
-	flag.BoolVar(&fix, "fix", false, "Fix files that don't end with newline")
-	flag.Parse()
+// partialResult returns what was processed before the run stopped early
+// along with the error, so that the summary can still be reported. Other
+// errors discard the result.
+func partialResult(result *RepoResult, err error, opts Options) (*RepoResult, error) {
+	if !isEarlyStop(err) {
+		return nil, err
+	}
+	result.finish(opts)
+	return result, err
+}
 
-	args := flag.Args()
-	if len(args) != 1 {
-		fmt.Fprintf(os.Stderr, "Usage: %s [-fix] <repository_path>\n", os.Args[0])
-		os.Exit(1)
+// Exit status bits for DetailedExit, added to exitDetailedBase so that
+// they never collide with the plain 1 and the usage error 2
+const (
+	exitDetailedBase = 16
+	exitMissingBit   = 1
+	exitTrailingBit  = 2
+	exitErrorBit     = 4
+	exitBOMBit       = 8
+	exitRequiredBit  = 16
+)
+
+// exitMissingRequired is the exit status when files named by -require
+// are missing, whatever else the run found
+const exitMissingRequired = 3
+
+// exitCode returns the process exit status for a completed run. Check
+// runs fail when files are missing a newline unless WarnOnly is set, and
+// with Strict also on unremoved trailing whitespace and blank lines.
+func exitCode(result *RepoResult, opts Options) int {
+	if opts.DetailedExit {
+		return detailedExitCode(result, opts)
 	}
 
-	repoPath := args[0]
+	if len(result.MissingRequired) > 0 {
+		return exitMissingRequired
+	}
 
-	// Check if path exists
-	info, err := os.Stat(repoPath)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+	if opts.DiffExit && result.changed() {
+		return 1
 	}
+	if !opts.WarnOnly && (len(result.Problematic) > 0 || unfixedBOM(result, opts) || (opts.Strict && unfixedTails(result, opts))) {
+		return 1
+	}
+	if opts.ErrorOnBinaryContent && len(result.Binary) > 0 {
+		return 1
+	}
+	return 0
+}
 
-	if !info.IsDir() {
-		fmt.Fprintf(os.Stderr, "Error: %s is not a directory\n", repoPath)
-		os.Exit(1)
+// changed reports whether the run fixed any file, in place or in the patch
+func (r *RepoResult) changed() bool {
+	return len(r.Fixed) > 0 || len(r.Patched) > 0
+}
+
+// unfixedBOM reports whether -no-bom found byte order marks it didn't strip
+func unfixedBOM(result *RepoResult, opts Options) bool {
+	return len(result.UTF8BOM) > 0 && !opts.Fix
+}
+
+// detailedExitCode combines a bit per problem category found by the run:
+// files missing a newline (or changed, with DiffExit), unfixed trailing
+// whitespace or blank lines, files that could not be processed and
+// unstripped byte order marks, and missing -require files
+func detailedExitCode(result *RepoResult, opts Options) int {
+	bits := 0
+	if !opts.WarnOnly {
+		if len(result.Problematic) > 0 || (opts.DiffExit && result.changed()) {
+			bits |= exitMissingBit
+		}
+		if unfixedTails(result, opts) {
+			bits |= exitTrailingBit
+		}
+		if unfixedBOM(result, opts) {
+			bits |= exitBOMBit
+		}
+	}
+	if len(result.Errors) > 0 {
+		bits |= exitErrorBit
+	}
+	if len(result.MissingRequired) > 0 {
+		bits |= exitRequiredBit
+	}
+
+	if bits == 0 {
+		return 0
 	}
+	return exitDetailedBase + bits
+}
 
-	// Process repository
-	if err := processRepository(repoPath, fix); err != nil {
+func main() {
+	cfg, err := parseArgs(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if errors.Is(err, errUsage) {
+		// Already reported by the flag package
+		os.Exit(2)
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
+
+	os.Exit(run(cfg))
 }
@@ -1,10 +1,17 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestIsBinary(t *testing.T) {
@@ -38,6 +45,11 @@ func TestIsBinary(t *testing.T) {
 			data:     []byte("Hello\tWorld\r\nTest"),
 			expected: false,
 		},
+		{
+			name:     "改ページを多く含むテキスト",
+			data:     []byte("\f1\n\f\f2\n\f\f\v3\n"),
+			expected: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -96,6 +108,11 @@ func TestShouldSkipFile(t *testing.T) {
 			path:     "IMAGE.PNG",
 			expected: true,
 		},
+		{
+			name:     "親ディレクトリからの相対パス",
+			path:     "../src/main.go",
+			expected: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -176,7 +193,7 @@ func TestCheckAndFixFile(t *testing.T) {
 			}
 
 			// 関数をテスト
-			result, err := checkAndFixFile(testFile, tt.fix)
+			check, err := checkAndFixFile(testFile, Options{Fix: tt.fix})
 
 			// エラーのチェック
 			if tt.expectedError && err == nil {
@@ -187,8 +204,8 @@ func TestCheckAndFixFile(t *testing.T) {
 			}
 
 			// 結果のチェック
-			if result != tt.expectedResult {
-				t.Errorf("checkAndFixFile() = %v, expected %v", result, tt.expectedResult)
+			if check.ok != tt.expectedResult {
+				t.Errorf("checkAndFixFile() = %v, expected %v", check.ok, tt.expectedResult)
 			}
 
 			// ファイル内容のチェック
@@ -238,13 +255,13 @@ func TestProcessRepository(t *testing.T) {
 	}
 
 	// 修正なしでテスト
-	err = processRepository(tempDir, false)
+	_, err = processRepository(tempDir, Options{})
 	if err != nil {
 		t.Errorf("processRepository()でエラーが発生: %v", err)
 	}
 
 	// 修正ありでテスト
-	err = processRepository(tempDir, true)
+	_, err = processRepository(tempDir, Options{Fix: true})
 	if err != nil {
 		t.Errorf("processRepository()でエラーが発生: %v", err)
 	}
@@ -261,7 +278,7 @@ func TestProcessRepository(t *testing.T) {
 }
 
 func TestProcessRepositoryNonExistentPath(t *testing.T) {
-	err := processRepository("/non/existent/path", false)
+	_, err := processRepository("/non/existent/path", Options{})
 	if err == nil {
 		t.Errorf("存在しないパスに対してエラーが発生しませんでした")
 	}
@@ -312,7 +329,7 @@ func createTempFileWithContent(t *testing.T, content string) string {
 // エラーケーステスト
 func TestCheckAndFixFileErrors(t *testing.T) {
 	t.Run("存在しないファイル", func(t *testing.T) {
-		_, err := checkAndFixFile("/non/existent/file.txt", false)
+		_, err := checkAndFixFile("/non/existent/file.txt", Options{})
 		if err == nil {
 			t.Errorf("存在しないファイルに対してエラーが発生しませんでした")
 		}
@@ -352,13 +369,13 @@ func TestIntegration(t *testing.T) {
 	}
 
 	// チェックモードで実行
-	err = processRepository(tempDir, false)
+	_, err = processRepository(tempDir, Options{})
 	if err != nil {
 		t.Errorf("チェックモードでエラー: %v", err)
 	}
 
 	// 修正モードで実行
-	err = processRepository(tempDir, true)
+	_, err = processRepository(tempDir, Options{Fix: true})
 	if err != nil {
 		t.Errorf("修正モードでエラー: %v", err)
 	}
@@ -377,3 +394,1422 @@ func TestIntegration(t *testing.T) {
 		}
 	}
 }
+
+func TestDisplayPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		root     string
+		path     string
+		expected string
+	}{
+		{
+			name:     "カレントディレクトリのルート",
+			root:     ".",
+			path:     "src/main.go",
+			expected: "src/main.go",
+		},
+		{
+			name:     "末尾スラッシュ付きのルート",
+			root:     "repo/",
+			path:     filepath.Join("repo", "src", "main.go"),
+			expected: "src/main.go",
+		},
+		{
+			name:     "先頭に./が付いたパス",
+			root:     "./repo",
+			path:     "./repo/main.go",
+			expected: "main.go",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := displayPath(tt.root, tt.path)
+			if result != tt.expected {
+				t.Errorf("displayPath(%s, %s) = %s, expected %s", tt.root, tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestProcessRepositoryRootForms(t *testing.T) {
+	tempDir := t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(tempDir, "sub"), 0o755); err != nil {
+		t.Fatalf("ディレクトリの作成に失敗: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "sub", "file.txt"), []byte("no newline"), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+
+	t.Chdir(tempDir)
+
+	for _, root := range []string{".", "./", "sub/..", tempDir + string(filepath.Separator)} {
+		t.Run(root, func(t *testing.T) {
+			result := runJSONReport(t, root, Options{})
+			if len(result.Problematic) != 1 || result.Problematic[0] != "sub/file.txt" {
+				t.Errorf("表示パスが期待値と異なります: %v", result.Problematic)
+			}
+		})
+	}
+}
+
+func TestProcessRepositoryFileRoot(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Chdir(tempDir)
+
+	if err := os.MkdirAll("sub", 0o755); err != nil {
+		t.Fatalf("ディレクトリの作成に失敗: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join("sub", "file.txt"), []byte("no newline"), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+
+	result, err := processRepository("./sub/file.txt", Options{})
+	if err != nil {
+		t.Fatalf("processRepository()でエラーが発生: %v", err)
+	}
+	if result.Total != 1 || len(result.Problematic) != 1 || result.Problematic[0] != "sub/file.txt" {
+		t.Errorf("ファイルのルートが指定どおりに表示されていません: total=%d, %v", result.Total, result.Problematic)
+	}
+}
+
+func TestIsGenerated(t *testing.T) {
+	pattern := regexp.MustCompile(defaultGeneratedPattern)
+
+	tests := []struct {
+		name     string
+		data     string
+		expected bool
+	}{
+		{
+			name:     "生成コードのヘッダー",
+			data:     "// Code generated by stringer; DO NOT EDIT.\n\npackage main",
+			expected: true,
+		},
+		{
+			name:     "CRLFの生成コードのヘッダー",
+			data:     "// Code generated by protoc-gen-go. DO NOT EDIT.\r\npackage pb",
+			expected: true,
+		},
+		{
+			name:     "通常のファイル",
+			data:     "package main\n\nfunc main() {}",
+			expected: false,
+		},
+		{
+			name:     "先頭行より後ろのマーカー",
+			data:     "1\n2\n3\n4\n5\n// Code generated by tool. DO NOT EDIT.\n",
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := isGenerated([]byte(tt.data), pattern)
+			if result != tt.expected {
+				t.Errorf("isGenerated() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestProcessRepositorySkipGenerated(t *testing.T) {
+	tempDir := t.TempDir()
+
+	content := "// Code generated by tool. DO NOT EDIT.\n\npackage main"
+	generatedFile := filepath.Join(tempDir, "zz_generated.go")
+	if err := os.WriteFile(generatedFile, []byte(content), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+
+	opts := Options{Fix: true, GeneratedPattern: regexp.MustCompile(defaultGeneratedPattern)}
+	result := runJSONReport(t, tempDir, opts)
+
+	if len(result.Generated) != 1 || result.Generated[0] != "zz_generated.go" {
+		t.Errorf("generatedが期待値と異なります: %v", result.Generated)
+	}
+	if len(result.Fixed) != 0 {
+		t.Errorf("生成ファイルが修正対象になっています: %v", result.Fixed)
+	}
+
+	actual, err := os.ReadFile(generatedFile)
+	if err != nil {
+		t.Fatalf("テストファイルの読み込みに失敗: %v", err)
+	}
+	if string(actual) != content {
+		t.Errorf("生成ファイルが変更されています: %q", string(actual))
+	}
+}
+
+func TestProcessRepositorySkipContent(t *testing.T) {
+	tempDir := t.TempDir()
+
+	files := map[string]string{
+		"gen.txt":    "// AUTOGENERATED\ndata",
+		"manual.txt": "data\n// AUTOGENERATED",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	opts := Options{SkipContent: regexp.MustCompile(`^// AUTOGENERATED`)}
+	result, err := processRepository(tempDir, opts)
+	if err != nil {
+		t.Fatalf("processRepository()でエラーが発生: %v", err)
+	}
+
+	if result.Skipped != 1 || result.Total != 1 {
+		t.Errorf("スキップ数 = %d, チェック数 = %d, expected 1, 1", result.Skipped, result.Total)
+	}
+	if len(result.Problematic) != 1 || result.Problematic[0] != "manual.txt" {
+		t.Errorf("先頭以外の一致でスキップされました: %v", result.Problematic)
+	}
+}
+
+func TestProcessRepositoryNoExtSkip(t *testing.T) {
+	tempDir := t.TempDir()
+
+	files := map[string]string{
+		"notes.png":   "text",
+		"image.png":   "\x89PNG\x00\x00",
+		".hidden.txt": "text",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		opts        Options
+		problematic []string
+		binary      []string
+		skipped     int
+	}{
+		{name: "デフォルトは拡張子でスキップ", opts: Options{}, problematic: []string{}, binary: []string{}, skipped: 3},
+		{name: "内容で判定", opts: Options{NoExtSkip: true}, problematic: []string{"notes.png"}, binary: []string{"image.png"}, skipped: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := processRepository(tempDir, tt.opts)
+			if err != nil {
+				t.Fatalf("processRepository()でエラーが発生: %v", err)
+			}
+			if strings.Join(result.Problematic, ",") != strings.Join(tt.problematic, ",") {
+				t.Errorf("problematic = %v, expected %v", result.Problematic, tt.problematic)
+			}
+			if strings.Join(result.Binary, ",") != strings.Join(tt.binary, ",") {
+				t.Errorf("binary = %v, expected %v", result.Binary, tt.binary)
+			}
+			if result.Skipped != tt.skipped {
+				t.Errorf("スキップ数 = %d, expected %d", result.Skipped, tt.skipped)
+			}
+		})
+	}
+}
+
+func TestProcessRepositoryErrorOnBinaryContent(t *testing.T) {
+	tempDir := t.TempDir()
+
+	files := map[string][]byte{
+		"text.txt":  []byte("text\n"),
+		"data.txt":  []byte("bin\x00ary"),
+		"image.png": []byte("\x89PNG\x00"),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), content, 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		opts   Options
+		errors int
+	}{
+		{name: "デフォルトはスキップ", opts: Options{}, errors: 0},
+		{name: "エラーとして報告", opts: Options{ErrorOnBinaryContent: true}, errors: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := processRepository(tempDir, tt.opts)
+			if err != nil {
+				t.Fatalf("processRepository()でエラーが発生: %v", err)
+			}
+			if len(result.Errors) != tt.errors {
+				t.Fatalf("エラー数 = %d, expected %d: %v", len(result.Errors), tt.errors, result.Errors)
+			}
+			if tt.errors > 0 && result.Errors[0].Path != "data.txt" {
+				t.Errorf("バイナリ拡張子のないファイルがエラーになっていません: %v", result.Errors)
+			}
+			if result.Total != 2 {
+				t.Errorf("チェックしたファイル数 = %d, expected 2", result.Total)
+			}
+		})
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   *RepoResult
+		opts     Options
+		expected int
+	}{
+		{
+			name:     "修正なし",
+			result:   &RepoResult{Mode: modeFix},
+			opts:     Options{Fix: true, DiffExit: true},
+			expected: 0,
+		},
+		{
+			name:     "修正ありで-diff-exit",
+			result:   &RepoResult{Mode: modeFix, Fixed: []string{"a.txt"}},
+			opts:     Options{Fix: true, DiffExit: true},
+			expected: 1,
+		},
+		{
+			name:     "修正ありで-diff-exitなし",
+			result:   &RepoResult{Mode: modeFix, Fixed: []string{"a.txt"}},
+			opts:     Options{Fix: true},
+			expected: 0,
+		},
+		{
+			name:     "チェックモードで問題なし",
+			result:   &RepoResult{Mode: modeCheck},
+			opts:     Options{},
+			expected: 0,
+		},
+		{
+			name:     "チェックモードで問題あり",
+			result:   &RepoResult{Mode: modeCheck, Problematic: []string{"a.txt"}},
+			opts:     Options{},
+			expected: 1,
+		},
+		{
+			name:     "チェックモードで問題ありかつ-warn-only",
+			result:   &RepoResult{Mode: modeCheck, Problematic: []string{"a.txt"}},
+			opts:     Options{WarnOnly: true},
+			expected: 0,
+		},
+		{
+			name:     "バイナリ内容ありで-error-on-binary-content",
+			result:   &RepoResult{Mode: modeCheck, Binary: []string{"a.txt"}},
+			opts:     Options{ErrorOnBinaryContent: true},
+			expected: 1,
+		},
+		{
+			name:     "バイナリ内容ありで-error-on-binary-contentなし",
+			result:   &RepoResult{Mode: modeCheck, Binary: []string{"a.txt"}},
+			opts:     Options{},
+			expected: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := exitCode(tt.result, tt.opts); code != tt.expected {
+				t.Errorf("exitCode() = %d, expected %d", code, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseFileMode(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		expected      os.FileMode
+		expectedError bool
+	}{
+		{name: "0644", input: "0644", expected: 0o644},
+		{name: "先頭の0なし", input: "600", expected: 0o600},
+		{name: "8進数以外", input: "0o9", expectedError: true},
+		{name: "範囲外", input: "1777", expectedError: true},
+		{name: "空文字", input: "", expectedError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mode, err := parseFileMode(tt.input)
+			if tt.expectedError {
+				if err == nil {
+					t.Errorf("parseFileMode(%q)でエラーが発生しませんでした", tt.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("予期しないエラーが発生: %v", err)
+			}
+			if mode != tt.expected {
+				t.Errorf("parseFileMode(%q) = %o, expected %o", tt.input, mode, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCheckAndFixFilePreservesMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windowsではパーミッションビットが保持されません")
+	}
+
+	testFile := filepath.Join(t.TempDir(), "script.sh")
+	if err := os.WriteFile(testFile, []byte("echo hi"), 0o700); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+	if err := os.Chmod(testFile, 0o700); err != nil {
+		t.Fatalf("パーミッションの設定に失敗: %v", err)
+	}
+
+	if _, err := checkAndFixFile(testFile, Options{Fix: true, FileMode: 0o600}); err != nil {
+		t.Fatalf("checkAndFixFile()でエラーが発生: %v", err)
+	}
+
+	info, err := os.Stat(testFile)
+	if err != nil {
+		t.Fatalf("ファイル情報の取得に失敗: %v", err)
+	}
+	if info.Mode().Perm() != 0o700 {
+		t.Errorf("パーミッションが変更されています: %o", info.Mode().Perm())
+	}
+}
+
+func TestCheckBytesNoLineTerminators(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected bool
+	}{
+		{name: "1行のみのファイル", data: "no newline here", expected: true},
+		{name: "複数行で最終行に改行なし", data: "a\nb", expected: false},
+		{name: "改行で終わるファイル", data: "a\n", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check, _ := checkBytes([]byte(tt.data), Options{})
+			if check.noLineTerminators != tt.expected {
+				t.Errorf("noLineTerminators = %v, expected %v", check.noLineTerminators, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCheckBytesLastLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		opts     Options
+		expected int
+	}{
+		{name: "1行のみのファイル", data: "no newline here", expected: 1},
+		{name: "複数行で最終行に改行なし", data: "a\nb", expected: 2},
+		{name: "CRLFの複数行", data: "a\r\nb\r\nc", opts: Options{EOL: eolCRLF}, expected: 3},
+		{name: "改行で終わるファイル", data: "a\nb\n", expected: 0},
+		{name: "改行の種類違い", data: "a\r\n", opts: Options{EOL: eolLF}, expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check, _ := checkBytes([]byte(tt.data), tt.opts)
+			if check.lastLine != tt.expected {
+				t.Errorf("lastLine = %d, expected %d", check.lastLine, tt.expected)
+			}
+		})
+	}
+}
+
+func TestProcessRepositoryLastLines(t *testing.T) {
+	tempDir := t.TempDir()
+
+	files := map[string]string{
+		"multi.txt": "a\nb\nc",
+		"ok.txt":    "a\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	result, err := processRepository(tempDir, Options{})
+	if err != nil {
+		t.Fatalf("processRepository()でエラーが発生: %v", err)
+	}
+	if len(result.LastLines) != 1 || result.LastLines["multi.txt"] != 3 {
+		t.Errorf("最終行の行番号が期待値と異なります: %v", result.LastLines)
+	}
+}
+
+func TestProcessPaths(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Chdir(tempDir)
+
+	files := map[string]string{
+		"frontend/app.js":    "console.log(1)",
+		"frontend/ok.js":     "ok\n",
+		"backend/main.go":    "package main",
+		"backend/skipped.go": "package main",
+		"single.txt":         "single",
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("ディレクトリの作成に失敗: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	result, err := processPaths([]string{"frontend/", "./single.txt", "backend/main.go", "missing.txt"}, Options{})
+	if err != nil {
+		t.Fatalf("processPaths()でエラーが発生: %v", err)
+	}
+
+	expected := []string{"backend/main.go", "frontend/app.js", "single.txt"}
+	if strings.Join(result.Problematic, ",") != strings.Join(expected, ",") {
+		t.Errorf("problematicが期待値と異なります: %v, expected %v", result.Problematic, expected)
+	}
+	if result.Total != 4 {
+		t.Errorf("チェックしたファイル数 = %d, expected 4", result.Total)
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Path != "missing.txt" {
+		t.Errorf("存在しないパスがエラーとして記録されていません: %v", result.Errors)
+	}
+}
+
+func TestProcessRoots(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Chdir(tempDir)
+
+	for _, name := range []string{"frontend/a.txt", "backend/a.txt"} {
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatalf("ディレクトリの作成に失敗: %v", err)
+		}
+		if err := os.WriteFile(name, []byte("text\n\n"), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	roots := []root{
+		{path: "frontend", opts: Options{SingleFinalNewline: true}},
+		{path: "backend", opts: Options{}},
+	}
+	result, err := processRoots(roots, Options{})
+	if err != nil {
+		t.Fatalf("processRoots()でエラーが発生: %v", err)
+	}
+
+	if result.Total != 2 {
+		t.Errorf("チェックしたファイル数 = %d, expected 2", result.Total)
+	}
+	if len(result.Problematic) != 1 || result.Problematic[0] != "frontend/a.txt" {
+		t.Errorf("ルートごとの設定が適用されていません: %v", result.Problematic)
+	}
+}
+
+func TestSkipsDir(t *testing.T) {
+	skipDirs := skipDirSet(false, "Build")
+
+	if (Options{SkipDirs: skipDirs}).skipsDir("build") {
+		t.Error("-ignore-caseなしで大文字小文字の異なるディレクトリがスキップされました")
+	}
+	if !(Options{SkipDirs: skipDirs, IgnoreCase: true}).skipsDir("build") {
+		t.Error("-ignore-caseで大文字小文字の異なるディレクトリがスキップされませんでした")
+	}
+}
+
+func TestSkipReason(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{name: "通常のファイル", path: "main.go", expected: ""},
+		{name: "隠しファイル", path: ".env", expected: "hidden file or directory"},
+		{name: "バイナリ拡張子", path: "tool.EXE", expected: "binary extension .exe"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if reason := skipReason(tt.path); reason != tt.expected {
+				t.Errorf("skipReason(%s) = %q, expected %q", tt.path, reason, tt.expected)
+			}
+		})
+	}
+}
+
+func TestProcessPathsOrdering(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Chdir(tempDir)
+
+	for _, name := range []string{"b.txt", "a.txt", "c/z.txt", "c/a.txt"} {
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatalf("ディレクトリの作成に失敗: %v", err)
+		}
+		if err := os.WriteFile(name, []byte("\xef\xbb\xbfno newline"), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	paths := []string{"c", "b.txt", "a.txt"}
+
+	result, err := processPaths(paths, Options{NoBOM: true})
+	if err != nil {
+		t.Fatalf("processPaths()でエラーが発生: %v", err)
+	}
+	expected := "a.txt,b.txt,c/a.txt,c/z.txt"
+	if actual := strings.Join(result.Problematic, ","); actual != expected {
+		t.Errorf("ソート順が期待値と異なります: %s, expected %s", actual, expected)
+	}
+	if actual := strings.Join(result.UTF8BOM, ","); actual != expected {
+		t.Errorf("utf8_bomのソート順が期待値と異なります: %s, expected %s", actual, expected)
+	}
+
+	// WalkOrderでは処理した順序のまま
+	result, err = processPaths(paths, Options{WalkOrder: true})
+	if err != nil {
+		t.Fatalf("processPaths()でエラーが発生: %v", err)
+	}
+	expected = "c/a.txt,c/z.txt,b.txt,a.txt"
+	if actual := strings.Join(result.Problematic, ","); actual != expected {
+		t.Errorf("処理順が期待値と異なります: %s, expected %s", actual, expected)
+	}
+}
+
+func TestProcessPathsHiddenRoot(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Chdir(tempDir)
+
+	for _, name := range []string{".work/proj/a.txt", ".work/proj/.b.txt", ".work/proj/.cache/c.txt", "d.txt"} {
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatalf("ディレクトリの作成に失敗: %v", err)
+		}
+		if err := os.WriteFile(name, []byte("no newline"), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	// 隠しディレクトリの下を指定しても、その中のファイルはチェックする
+	result, err := processPaths([]string{".work/proj", "d.txt"}, Options{})
+	if err != nil {
+		t.Fatalf("processPaths()でエラーが発生: %v", err)
+	}
+	expected := ".work/proj/a.txt,d.txt"
+	if actual := strings.Join(result.Problematic, ","); actual != expected {
+		t.Errorf("問題のあるファイルが期待値と異なります: %s, expected %s", actual, expected)
+	}
+	if result.Skipped != 2 {
+		t.Errorf("スキップしたファイル数 = %d, expected 2", result.Skipped)
+	}
+}
+
+func TestCheckBytesEOL(t *testing.T) {
+	tests := []struct {
+		name          string
+		data          string
+		eol           string
+		expectedOK    bool
+		expectedFixed string
+	}{
+		{name: "指定なしでLF", data: "a\n", expectedOK: true, expectedFixed: "a\n"},
+		{name: "指定なしでCRLF", data: "a\r\n", expectedOK: true, expectedFixed: "a\r\n"},
+		{name: "LF指定でCRLF", data: "a\r\n", eol: eolLF, expectedOK: false, expectedFixed: "a\n"},
+		{name: "LF指定で改行なし", data: "a", eol: eolLF, expectedOK: false, expectedFixed: "a\n"},
+		{name: "LF指定でLF", data: "a\n", eol: eolLF, expectedOK: true, expectedFixed: "a\n"},
+		{name: "LF指定でCR", data: "a\r", eol: eolLF, expectedOK: false, expectedFixed: "a\n"},
+		{name: "LF指定で空行のCR", data: "a\n\r", eol: eolLF, expectedOK: false, expectedFixed: "a\n\n"},
+		{name: "CRLF指定でLF", data: "a\n", eol: eolCRLF, expectedOK: false, expectedFixed: "a\r\n"},
+		{name: "CRLF指定で改行なし", data: "a", eol: eolCRLF, expectedOK: false, expectedFixed: "a\r\n"},
+		{name: "CRLF指定でCRLF", data: "a\r\n", eol: eolCRLF, expectedOK: true, expectedFixed: "a\r\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check, fixed := checkBytes([]byte(tt.data), Options{EOL: tt.eol})
+			if check.ok != tt.expectedOK {
+				t.Errorf("ok = %v, expected %v", check.ok, tt.expectedOK)
+			}
+			if string(fixed) != tt.expectedFixed {
+				t.Errorf("修正後の内容 = %q, expected %q", string(fixed), tt.expectedFixed)
+			}
+		})
+	}
+}
+
+func TestRejectCRLFEOF(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"lf.txt":   "a\n",
+		"crlf.txt": "a\r\nb\r\n",
+		"cr.txt":   "a\r",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	cfg, err := parseArgs([]string{"-no-rc", "-reject-crlf-eof", "-fix", dir})
+	if err != nil {
+		t.Fatalf("parseArgs()でエラーが発生: %v", err)
+	}
+	result, err := processRepository(dir, cfg.opts)
+	if err != nil {
+		t.Fatalf("processRepository()でエラーが発生: %v", err)
+	}
+
+	if expected := []string{"cr.txt", "crlf.txt"}; !slices.Equal(result.Fixed, expected) {
+		t.Errorf("fixedが期待値と異なります: got %v, expected %v", result.Fixed, expected)
+	}
+
+	expected := map[string]string{"lf.txt": "a\n", "crlf.txt": "a\r\nb\n", "cr.txt": "a\n"}
+	for name, content := range expected {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("ファイルの読み込みに失敗: %v", err)
+		}
+		if string(data) != content {
+			t.Errorf("%s: 内容が期待値と異なります: got %q, expected %q", name, data, content)
+		}
+	}
+}
+
+func TestPreserveTrailingContent(t *testing.T) {
+	// "あ" は3バイトのUTF-8。末尾の1バイトが欠けた状態を作る
+	truncated := "line1\n" + string([]byte("あ")[:2])
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"truncated.txt": truncated,
+		"complete.txt":  "line1\nあ",
+		"ascii.txt":     "line1",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	result, err := processRepository(dir, Options{Fix: true, PreserveTrailingContent: true})
+	if err != nil {
+		t.Fatalf("processRepository()でエラーが発生: %v", err)
+	}
+
+	if expected := []string{"ascii.txt", "complete.txt"}; !slices.Equal(result.Fixed, expected) {
+		t.Errorf("fixedが期待値と異なります: got %v, expected %v", result.Fixed, expected)
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Path != "truncated.txt" {
+		t.Fatalf("errorsが期待値と異なります: %v", result.Errors)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "truncated.txt"))
+	if err != nil {
+		t.Fatalf("ファイルの読み込みに失敗: %v", err)
+	}
+	if string(data) != truncated {
+		t.Errorf("途中で切れたファイルが変更されました: %q", data)
+	}
+}
+
+func TestEndsWithIncompleteRune(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		expected bool
+	}{
+		{name: "ASCII", data: []byte("abc"), expected: false},
+		{name: "完全な3バイト文字", data: []byte("abcあ"), expected: false},
+		{name: "3バイト文字の先頭2バイト", data: []byte("あ")[:2], expected: true},
+		{name: "4バイト文字の先頭1バイト", data: []byte("a😀")[:2], expected: true},
+		{name: "先頭バイトのない継続バイト", data: []byte{'a', 0x80}, expected: false},
+		{name: "空", data: nil, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := endsWithIncompleteRune(tt.data); got != tt.expected {
+				t.Errorf("endsWithIncompleteRune(%q) = %v, expected %v", tt.data, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseControlSet(t *testing.T) {
+	set, err := parseControlSet("9, 10,13,27,200")
+	if err != nil {
+		t.Fatalf("parseControlSet()でエラーが発生: %v", err)
+	}
+	for _, b := range []byte{9, 10, 13, 27} {
+		if !set[b] {
+			t.Errorf("バイト %d が許可されていません", b)
+		}
+	}
+	if set[12] {
+		t.Errorf("指定していないバイト 12 が許可されています")
+	}
+
+	for _, invalid := range []string{"abc", "-1", "256"} {
+		if _, err := parseControlSet(invalid); err == nil {
+			t.Errorf("parseControlSet(%q)でエラーが発生しませんでした", invalid)
+		}
+	}
+}
+
+func TestIsBinaryWithEscapeSequences(t *testing.T) {
+	// カーソル保存・復元のエスケープシーケンスを多く含むログ
+	data := []byte(strings.Repeat("\x1b7\x1b8\n", 20))
+
+	if !isBinary(data) {
+		t.Fatalf("既定の設定ではESCを多く含むデータがバイナリと判定される前提です")
+	}
+
+	set, err := parseControlSet("9,10,13,27")
+	if err != nil {
+		t.Fatalf("parseControlSet()でエラーが発生: %v", err)
+	}
+	if isBinaryWith(data, set) {
+		t.Errorf("ESCを許可してもバイナリと判定されました")
+	}
+}
+
+func TestCustomIsBinary(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"magic.txt": "MAGIC no newline",
+		"plain.txt": "no newline",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	// 独自のマジックナンバーで始まる内容だけをバイナリとみなす
+	opts := Options{IsBinary: func(data []byte) bool {
+		return strings.HasPrefix(string(data), "MAGIC")
+	}}
+	result, err := processRepository(tempDir, opts)
+	if err != nil {
+		t.Fatalf("processRepository()でエラーが発生: %v", err)
+	}
+	if !slices.Equal(result.Binary, []string{"magic.txt"}) {
+		t.Errorf("binaryが期待値と異なります: %v", result.Binary)
+	}
+	if !slices.Equal(result.Problematic, []string{"plain.txt"}) {
+		t.Errorf("problematicが期待値と異なります: %v", result.Problematic)
+	}
+
+	// 組み込みの判定ではバイナリになる内容もテキストとして扱える
+	check, _ := checkBytes([]byte{0x00, 0x01, 0x02}, Options{IsBinary: func([]byte) bool { return false }})
+	if check.binary || check.ok {
+		t.Errorf("独自の判定が使われていません: %+v", check)
+	}
+}
+
+func TestProcessRepositoryModifiedSince(t *testing.T) {
+	tempDir := t.TempDir()
+
+	oldFile := filepath.Join(tempDir, "old.txt")
+	newFile := filepath.Join(tempDir, "new.txt")
+	for _, path := range []string{oldFile, newFile} {
+		if err := os.WriteFile(path, []byte("no newline"), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(oldFile, old, old); err != nil {
+		t.Fatalf("更新日時の変更に失敗: %v", err)
+	}
+
+	opts := Options{ModifiedSince: time.Now().Add(-10 * time.Minute), ReportFile: filepath.Join(t.TempDir(), "report.txt")}
+	result, err := processRepository(tempDir, opts)
+	if err != nil {
+		t.Fatalf("processRepository()でエラーが発生: %v", err)
+	}
+
+	if result.Total != 1 || result.Skipped != 1 {
+		t.Errorf("件数が期待値と異なります: total=%d, skipped=%d", result.Total, result.Skipped)
+	}
+	if len(result.Problematic) != 1 || result.Problematic[0] != "new.txt" {
+		t.Errorf("problematicが期待値と異なります: %v", result.Problematic)
+	}
+}
+
+func TestProcessRepositoryHardLinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("inode情報を利用できないプラットフォームです")
+	}
+
+	tempDir := t.TempDir()
+	original := filepath.Join(tempDir, "a.txt")
+	if err := os.WriteFile(original, []byte("no newline"), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+	if err := os.Link(original, filepath.Join(tempDir, "b.txt")); err != nil {
+		t.Skipf("ハードリンクを作成できません: %v", err)
+	}
+
+	opts := Options{Fix: true, ReportFile: filepath.Join(t.TempDir(), "report.txt")}
+	result, err := processRepository(tempDir, opts)
+	if err != nil {
+		t.Fatalf("processRepository()でエラーが発生: %v", err)
+	}
+
+	if result.Total != 1 || result.Skipped != 1 {
+		t.Errorf("件数が期待値と異なります: total=%d, skipped=%d", result.Total, result.Skipped)
+	}
+	if len(result.Fixed) != 1 || result.Fixed[0] != "a.txt" {
+		t.Errorf("fixedが期待値と異なります: %v", result.Fixed)
+	}
+
+	data, err := os.ReadFile(original)
+	if err != nil {
+		t.Fatalf("ファイルの読み込みに失敗: %v", err)
+	}
+	if string(data) != "no newline\n" {
+		t.Errorf("ファイルの内容が期待値と異なります: %q", data)
+	}
+
+	// 修正してもハードリンクは切れない
+	data, err = os.ReadFile(filepath.Join(tempDir, "b.txt"))
+	if err != nil {
+		t.Fatalf("ファイルの読み込みに失敗: %v", err)
+	}
+	if string(data) != "no newline\n" {
+		t.Errorf("ハードリンクの内容が期待値と異なります: %q", data)
+	}
+}
+
+func TestProcessRepositoryMinFileSize(t *testing.T) {
+	tempDir := t.TempDir()
+
+	files := map[string]string{
+		"empty.txt": "",
+		"tiny.txt":  "x",
+		"large.txt": "substantial content",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	opts := Options{MinFileSize: 8, ReportFile: filepath.Join(t.TempDir(), "report.txt")}
+	result, err := processRepository(tempDir, opts)
+	if err != nil {
+		t.Fatalf("processRepository()でエラーが発生: %v", err)
+	}
+
+	// 空のファイルはスキップせずにチェックする
+	if result.Total != 2 || result.Skipped != 1 {
+		t.Errorf("件数が期待値と異なります: total=%d, skipped=%d", result.Total, result.Skipped)
+	}
+	if len(result.Problematic) != 1 || result.Problematic[0] != "large.txt" {
+		t.Errorf("problematicが期待値と異なります: %v", result.Problematic)
+	}
+}
+
+func TestCheckBytesSingleFinalNewline(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		eol      string
+		ok       bool
+		expected string
+	}{
+		{name: "改行なし", data: "text", expected: "text\n"},
+		{name: "改行1つ", data: "text\n", ok: true, expected: "text\n"},
+		{name: "改行2つ", data: "text\n\n", expected: "text\n"},
+		{name: "改行多数", data: "text\n\n\n\n", expected: "text\n"},
+		{name: "CRLFの改行多数", data: "text\r\n\r\n\r\n", expected: "text\r\n"},
+		{name: "混在は最後の改行に合わせる", data: "text\r\n\n", expected: "text\n"},
+		{name: "LFを要求", data: "text\r\n\r\n", eol: eolLF, expected: "text\n"},
+		{name: "空白のみの行は対象外", data: "text\n \n", ok: true, expected: "text\n \n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check, fixed := checkBytes([]byte(tt.data), Options{SingleFinalNewline: true, EOL: tt.eol})
+			if check.ok != tt.ok {
+				t.Errorf("ok = %v, expected %v", check.ok, tt.ok)
+			}
+			if string(fixed) != tt.expected {
+				t.Errorf("修正後の内容が期待値と異なります: got %q, expected %q", fixed, tt.expected)
+			}
+		})
+	}
+
+	// フラグなしでは従来どおり追加のみ
+	if check, _ := checkBytes([]byte("text\n\n"), Options{}); !check.ok {
+		t.Errorf("フラグなしで複数の改行が問題として扱われました")
+	}
+}
+
+func TestDetailedExitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   *RepoResult
+		opts     Options
+		expected int
+	}{
+		{name: "問題なし", result: &RepoResult{}, expected: 0},
+		{name: "改行なし", result: &RepoResult{Problematic: []string{"a.txt"}}, expected: 17},
+		{name: "末尾の空白", result: &RepoResult{TrailingWhitespace: []string{"a.txt"}}, expected: 18},
+		{name: "末尾の空行", result: &RepoResult{TrailingBlankLines: []string{"a.txt"}}, expected: 18},
+		{name: "修正済みの空行", result: &RepoResult{TrailingBlankLines: []string{"a.txt"}}, opts: Options{FixBlankLines: true}, expected: 0},
+		{name: "I/Oエラー", result: &RepoResult{Errors: []FileError{{Path: "a.txt", Err: "boom"}}}, expected: 20},
+		{
+			name:     "すべての分類",
+			result:   &RepoResult{Problematic: []string{"a.txt"}, TrailingWhitespace: []string{"b.txt"}, Errors: []FileError{{Path: "c.txt", Err: "boom"}}},
+			expected: 23,
+		},
+		{
+			name:     "-warn-onlyではエラーのみ",
+			result:   &RepoResult{Problematic: []string{"a.txt"}, Errors: []FileError{{Path: "c.txt", Err: "boom"}}},
+			opts:     Options{WarnOnly: true},
+			expected: 20,
+		},
+		{name: "-diff-exitで修正あり", result: &RepoResult{Fixed: []string{"a.txt"}}, opts: Options{Fix: true, DiffExit: true}, expected: 17},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.DetailedExit = true
+			if code := exitCode(tt.result, tt.opts); code != tt.expected {
+				t.Errorf("exitCode() = %d, expected %d", code, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSkipDirSet(t *testing.T) {
+	tests := []struct {
+		name     string
+		defaults bool
+		extra    string
+		expected []string
+	}{
+		{name: "デフォルト", defaults: true, expected: defaultSkipDirs},
+		{name: "追加", defaults: true, extra: "build, tmp", expected: append(slices.Clone(defaultSkipDirs), "build", "tmp")},
+		{name: "デフォルトなし", defaults: false, extra: "build", expected: []string{"build"}},
+		{name: "空", defaults: false, expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set := skipDirSet(tt.defaults, tt.extra)
+			if len(set) != len(tt.expected) {
+				t.Errorf("skipDirSet() = %v, expected %v", set, tt.expected)
+			}
+			for _, name := range tt.expected {
+				if !set[name] {
+					t.Errorf("%sが含まれていません: %v", name, set)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessRepositorySkipDirs(t *testing.T) {
+	tempDir := t.TempDir()
+
+	files := []string{
+		"main.txt",
+		"web/app/node_modules/pkg/index.txt",
+		"web/app/node_modules/pkg/node_modules/dep/index.txt",
+		"a/b/c/d/node_modules/deep.txt",
+		"src/node_modules.txt",
+	}
+	for _, name := range files {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("ディレクトリの作成に失敗: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("no newline"), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	result, err := processRepository(tempDir, Options{SkipDirs: skipDirSet(true, "")})
+	if err != nil {
+		t.Fatalf("processRepository()でエラーが発生: %v", err)
+	}
+
+	// 同名のファイルは対象のまま
+	expected := []string{"main.txt", "src/node_modules.txt"}
+	if !slices.Equal(result.Problematic, expected) {
+		t.Errorf("problematic = %v, expected %v", result.Problematic, expected)
+	}
+	if len(result.Files) != len(expected) {
+		t.Errorf("除外したディレクトリ内のファイルが記録されています: %v", result.Files)
+	}
+}
+
+func TestMaxErrors(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// 壊れたシンボリックリンクは読み込みエラーになる
+	for _, name := range []string{"a.txt", "b.txt", "c.txt", "d.txt"} {
+		if err := os.Symlink(filepath.Join(tempDir, "missing-"+name), filepath.Join(tempDir, name)); err != nil {
+			t.Skipf("シンボリックリンクを作成できません: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "z.txt"), []byte("ok\n"), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		maxErrors int
+		errors    int
+		stopped   bool
+	}{
+		{name: "制限なし", maxErrors: 0, errors: 4, stopped: false},
+		{name: "上限以内", maxErrors: 4, errors: 4, stopped: false},
+		{name: "上限超過", maxErrors: 1, errors: 2, stopped: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := processRepository(tempDir, Options{MaxErrors: tt.maxErrors})
+			if tt.stopped != errors.Is(err, errTooManyErrors) {
+				t.Fatalf("中断の有無が期待値と異なります: %v", err)
+			}
+			if !tt.stopped && err != nil {
+				t.Fatalf("processRepository()でエラーが発生: %v", err)
+			}
+			if result == nil {
+				t.Fatal("途中までの結果が返されませんでした")
+			}
+			if len(result.Errors) != tt.errors {
+				t.Errorf("エラー数が期待値と異なります: got %d, expected %d", len(result.Errors), tt.errors)
+			}
+			if tt.stopped && result.Total != tt.errors {
+				t.Errorf("中断後のファイルがチェックされました: total %d", result.Total)
+			}
+		})
+	}
+}
+
+func TestMaxErrorsProcessPaths(t *testing.T) {
+	dir := t.TempDir()
+	paths := []string{
+		filepath.Join(dir, "missing1.txt"),
+		filepath.Join(dir, "missing2.txt"),
+		filepath.Join(dir, "missing3.txt"),
+	}
+
+	result, err := processPaths(paths, Options{MaxErrors: 1})
+	if !errors.Is(err, errTooManyErrors) {
+		t.Fatalf("上限超過のエラーが返されませんでした: %v", err)
+	}
+	if result == nil || len(result.Errors) != 2 {
+		t.Errorf("途中までの結果が期待値と異なります: %+v", result)
+	}
+}
+
+func TestProcessRepositorySymlinks(t *testing.T) {
+	tempDir := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "target.txt")
+	if err := os.WriteFile(outside, []byte("no newline"), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+	if err := os.Symlink(outside, filepath.Join(tempDir, "link.txt")); err != nil {
+		t.Skipf("シンボリックリンクを作成できません: %v", err)
+	}
+
+	t.Run("デフォルトでは修正しない", func(t *testing.T) {
+		result, err := processRepository(tempDir, Options{Fix: true})
+		if err != nil {
+			t.Fatalf("processRepository()でエラーが発生: %v", err)
+		}
+		if len(result.Fixed) != 0 || len(result.Problematic) != 1 {
+			t.Errorf("fixed=%v, problematic=%v", result.Fixed, result.Problematic)
+		}
+
+		content, err := os.ReadFile(outside)
+		if err != nil {
+			t.Fatalf("リンク先の読み込みに失敗: %v", err)
+		}
+		if string(content) != "no newline" {
+			t.Errorf("リンク先が書き換えられました: %q", string(content))
+		}
+	})
+
+	t.Run("スキップ", func(t *testing.T) {
+		result, err := processRepository(tempDir, Options{SkipSymlinks: true})
+		if err != nil {
+			t.Fatalf("processRepository()でエラーが発生: %v", err)
+		}
+		if result.Total != 0 || result.Skipped != 1 {
+			t.Errorf("total=%d, skipped=%d, expected total=0, skipped=1", result.Total, result.Skipped)
+		}
+	})
+
+	t.Run("明示したリンクのスキップ", func(t *testing.T) {
+		result, err := processPaths([]string{filepath.Join(tempDir, "link.txt")}, Options{SkipSymlinks: true})
+		if err != nil {
+			t.Fatalf("processPaths()でエラーが発生: %v", err)
+		}
+		if result.Total != 0 || result.Skipped != 1 {
+			t.Errorf("total=%d, skipped=%d, expected total=0, skipped=1", result.Total, result.Skipped)
+		}
+	})
+}
+
+func TestProcessRepositoryContext(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte("no newline"), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(fmt.Errorf("%w: test", errTimeout))
+
+	result, err := processRepository(tempDir, Options{Context: ctx})
+	if !errors.Is(err, errTimeout) {
+		t.Fatalf("タイムアウトのエラーが返されませんでした: %v", err)
+	}
+	if result == nil {
+		t.Fatal("途中までの結果が返されませんでした")
+	}
+	// 各ファイルの処理後に確認するため、最初の1件だけがチェックされる
+	if result.Total != 1 {
+		t.Errorf("中断までにチェックされたファイル数 = %d, expected 1", result.Total)
+	}
+}
+
+func TestCheckBytesFinalNewlines(t *testing.T) {
+	tests := []struct {
+		name     string
+		count    int
+		data     string
+		ok       bool
+		expected string
+	}{
+		{name: "N=1 改行なし", count: 1, data: "text", expected: "text\n"},
+		{name: "N=1 改行1つ", count: 1, data: "text\n", ok: true, expected: "text\n"},
+		{name: "N=1 改行2つ", count: 1, data: "text\n\n", expected: "text\n"},
+		{name: "N=2 改行なし", count: 2, data: "text", expected: "text\n\n"},
+		{name: "N=2 改行1つ", count: 2, data: "text\n", expected: "text\n\n"},
+		{name: "N=2 改行2つ", count: 2, data: "text\n\n", ok: true, expected: "text\n\n"},
+		{name: "N=2 改行3つ", count: 2, data: "text\n\n\n", expected: "text\n\n"},
+		{name: "N=2 CRLF", count: 2, data: "text\r\n", expected: "text\r\n\r\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check, fixed := checkBytes([]byte(tt.data), Options{FinalNewlines: tt.count})
+			if check.ok != tt.ok {
+				t.Errorf("ok = %v, expected %v", check.ok, tt.ok)
+			}
+			if string(fixed) != tt.expected {
+				t.Errorf("修正後の内容が期待値と異なります: got %q, expected %q", fixed, tt.expected)
+			}
+			if tt.ok && check.blankLines {
+				t.Errorf("要求どおりの空行が末尾の空行として報告されました")
+			}
+		})
+	}
+}
+
+func TestProcessRepositoryNeverWritesEmptyFiles(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{name: "改行の追加", opts: Options{Fix: true}},
+		{name: "空行の削除", opts: Options{FixBlankLines: true}},
+		{name: "空白の削除", opts: Options{FixWhitespace: true}},
+		{name: "改行1つ", opts: Options{Fix: true, SingleFinalNewline: true}},
+		{name: "改行2つ", opts: Options{Fix: true, FinalNewlines: 2}},
+		{name: "CRLF", opts: Options{Fix: true, EOL: eolCRLF}},
+		{name: "BOMの削除", opts: Options{Fix: true, NoBOM: true}},
+		{name: "テキストとして扱う", opts: Options{Fix: true, ForceText: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			for _, name := range []string{"__init__.py", ".keep", "empty.txt"} {
+				path := filepath.Join(tempDir, name)
+				if err := os.WriteFile(path, nil, 0o644); err != nil {
+					t.Fatalf("テストファイルの作成に失敗: %v", err)
+				}
+				// 書き込まれたら更新時刻が変わるように過去に戻す
+				old := time.Now().Add(-time.Hour)
+				if err := os.Chtimes(path, old, old); err != nil {
+					t.Fatalf("更新時刻の変更に失敗: %v", err)
+				}
+			}
+
+			result, err := processRepository(tempDir, tt.opts)
+			if err != nil {
+				t.Fatalf("processRepository()でエラーが発生: %v", err)
+			}
+			if len(result.Fixed) != 0 {
+				t.Errorf("空のファイルが修正されました: %v", result.Fixed)
+			}
+
+			for _, name := range []string{"__init__.py", "empty.txt"} {
+				info, err := os.Stat(filepath.Join(tempDir, name))
+				if err != nil {
+					t.Fatalf("テストファイルの確認に失敗: %v", err)
+				}
+				if info.Size() != 0 || time.Since(info.ModTime()) < 30*time.Minute {
+					t.Errorf("%sが書き込まれました: size=%d", name, info.Size())
+				}
+			}
+		})
+	}
+}
+
+func TestProcessRepositoryFinalNewlines(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"one.txt":  "text\n",
+		"two.txt":  "text\n\n",
+		"none.txt": "text",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	// 既知のテキスト拡張子でも末尾1バイトの確認では済ませない
+	result, err := processRepository(tempDir, Options{FinalNewlines: 2})
+	if err != nil {
+		t.Fatalf("processRepository()でエラーが発生: %v", err)
+	}
+	if !slices.Equal(result.Problematic, []string{"none.txt", "one.txt"}) {
+		t.Errorf("problematicが期待値と異なります: %v", result.Problematic)
+	}
+
+	result, err = processRepository(tempDir, Options{Fix: true, FixBlankLines: true, FinalNewlines: 2, CountLinesAdded: true})
+	if err != nil {
+		t.Fatalf("processRepository()でエラーが発生: %v", err)
+	}
+	for name := range files {
+		content, err := os.ReadFile(filepath.Join(tempDir, name))
+		if err != nil {
+			t.Fatalf("テストファイルの読み込みに失敗: %v", err)
+		}
+		if string(content) != "text\n\n" {
+			t.Errorf("%s の修正結果が期待値と異なります: %q", name, content)
+		}
+	}
+	// none.txtは最終行の書き換えと空行1行、one.txtは空行1行
+	if result.FilesChanged != 2 || result.LinesAdded != 3 {
+		t.Errorf("files changed=%d, lines added=%d, expected 2, 3", result.FilesChanged, result.LinesAdded)
+	}
+}
+
+func TestNoBOM(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"bom.txt":       "\xEF\xBB\xBFtext\n",
+		"bom-nonl.txt":  "\xEF\xBB\xBFtext",
+		"plain.txt":     "text\n",
+		"bom-data.json": "\xEF\xBB\xBF{}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	// -no-bomなしでは報告しない
+	result, err := processRepository(tempDir, Options{})
+	if err != nil {
+		t.Fatalf("processRepository()でエラーが発生: %v", err)
+	}
+	if len(result.UTF8BOM) != 0 {
+		t.Errorf("-no-bomなしでBOMが報告されました: %v", result.UTF8BOM)
+	}
+
+	result, err = processRepository(tempDir, Options{NoBOM: true})
+	if err != nil {
+		t.Fatalf("processRepository()でエラーが発生: %v", err)
+	}
+	if !slices.Equal(result.UTF8BOM, []string{"bom-data.json", "bom-nonl.txt", "bom.txt"}) {
+		t.Errorf("utf8_bomが期待値と異なります: %v", result.UTF8BOM)
+	}
+	if !slices.Equal(result.Problematic, []string{"bom-nonl.txt"}) {
+		t.Errorf("BOMが改行の問題として報告されました: %v", result.Problematic)
+	}
+	if code := exitCode(result, Options{NoBOM: true}); code != 1 {
+		t.Errorf("BOMが残っている場合の終了コード = %d, expected 1", code)
+	}
+
+	result, err = processRepository(tempDir, Options{NoBOM: true, Fix: true})
+	if err != nil {
+		t.Fatalf("processRepository()でエラーが発生: %v", err)
+	}
+	expected := map[string]string{
+		"bom.txt":       "text\n",
+		"bom-nonl.txt":  "text\n",
+		"plain.txt":     "text\n",
+		"bom-data.json": "{}\n",
+	}
+	for name, want := range expected {
+		content, err := os.ReadFile(filepath.Join(tempDir, name))
+		if err != nil {
+			t.Fatalf("テストファイルの読み込みに失敗: %v", err)
+		}
+		if string(content) != want {
+			t.Errorf("%s の修正結果 = %q, expected %q", name, content, want)
+		}
+	}
+	if !slices.Equal(result.Fixed, []string{"bom-data.json", "bom-nonl.txt", "bom.txt"}) {
+		t.Errorf("fixedが期待値と異なります: %v", result.Fixed)
+	}
+}
+
+func TestNoBOMSkipsBinary(t *testing.T) {
+	data := append([]byte("\xEF\xBB\xBF"), 0x00, 0x01, 0x02)
+	check, fixed := checkBytes(data, Options{NoBOM: true, Fix: true})
+	if !check.binary || check.utf8BOM || string(fixed) != string(data) {
+		t.Errorf("バイナリファイルのBOMが扱われました: %+v", check)
+	}
+}
+
+func TestProcessFileVanished(t *testing.T) {
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "gone.txt")
+	if err := os.WriteFile(path, []byte("no newline"), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+
+	// 走査で見つけた後、読み込む前に別のプロセスが削除した状況
+	info, err := os.Lstat(path)
+	if err != nil {
+		t.Fatalf("ファイル情報の取得に失敗: %v", err)
+	}
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("テストファイルの削除に失敗: %v", err)
+	}
+
+	result := newRepoResult(Options{})
+	processFile(path, "gone.txt", "gone.txt", info, Options{Fix: true}, result)
+
+	if len(result.Errors) != 0 {
+		t.Errorf("削除されたファイルがエラーとして記録されました: %v", result.Errors)
+	}
+	if result.Skipped != 1 || result.Files[0].Reason != vanishedReason {
+		t.Errorf("削除されたファイルがスキップとして記録されていません: %+v", result.Files)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("削除されたファイルが再作成されました")
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// canonicalPath returns path made absolute with symlinks resolved, or an
+// empty string when it can't be resolved
+func canonicalPath(path string) string {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return ""
+	}
+	abs, err := filepath.Abs(resolved)
+	if err != nil {
+		return ""
+	}
+	return abs
+}
+
+// containsPath reports whether path is dir or lies below it. Both must be
+// clean absolute paths.
+func containsPath(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// overlappingRoots returns a warning for each root that lies inside, or
+// names the same path as, an earlier or enclosing root. Roots that can't
+// be resolved are left for processRoot to report.
+func overlappingRoots(roots []root) []string {
+	canonical := make([]string, len(roots))
+	for i, r := range roots {
+		canonical[i] = canonicalPath(r.path)
+	}
+
+	var warnings []string
+	for i, r := range roots {
+		for j := range roots {
+			if j == i || canonical[i] == "" || canonical[j] == "" || !containsPath(canonical[j], canonical[i]) {
+				continue
+			}
+			// Of two roots naming the same path, only the later one is
+			// reported
+			if canonical[i] != canonical[j] || j < i {
+				warnings = append(warnings, fmt.Sprintf("%s overlaps %s; checking its files once", r.path, roots[j].path))
+				break
+			}
+		}
+	}
+	return warnings
+}
+
+// dedupOverlappingRoots warns about overlapping roots and, when there are
+// any, makes result remember the files it visits so that a file reached
+// through several roots is only processed the first time. Files the
+// outer root doesn't visit, e.g. under a skipped directory, are still
+// checked under the inner one.
+func dedupOverlappingRoots(roots []root, result *RepoResult) {
+	warnings := overlappingRoots(roots)
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	if len(warnings) > 0 {
+		result.visited = make(map[string]bool)
+	}
+}
+
+// visitedBefore reports whether the file at path was already processed
+// in this run, remembering it otherwise. It is always false unless
+// overlapping roots were found.
+func (r *RepoResult) visitedBefore(path string) bool {
+	if r.visited == nil {
+		return false
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	if r.visited[abs] {
+		return true
+	}
+	r.visited[abs] = true
+	return false
+}
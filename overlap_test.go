@@ -0,0 +1,97 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestOverlappingRoots(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Chdir(tempDir)
+	if err := os.MkdirAll(filepath.Join("src", "pkg"), 0o755); err != nil {
+		t.Fatalf("ディレクトリの作成に失敗: %v", err)
+	}
+	if err := os.MkdirAll("docs", 0o755); err != nil {
+		t.Fatalf("ディレクトリの作成に失敗: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		paths    []string
+		warnings int
+	}{
+		{name: "重複なし", paths: []string{"src", "docs"}, warnings: 0},
+		{name: "祖先が先", paths: []string{".", "./src"}, warnings: 1},
+		{name: "祖先が後", paths: []string{"src/pkg", "docs", "src"}, warnings: 1},
+		{name: "同じパス", paths: []string{"src", "./src/", "src/pkg"}, warnings: 2},
+		{name: "存在しないパス", paths: []string{".", "missing"}, warnings: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			roots := make([]root, len(tt.paths))
+			for i, path := range tt.paths {
+				roots[i] = root{path: path}
+			}
+
+			if warnings := overlappingRoots(roots); len(warnings) != tt.warnings {
+				t.Errorf("警告の数 = %d, expected %d: %v", len(warnings), tt.warnings, warnings)
+			}
+		})
+	}
+}
+
+func TestProcessPathsOverlappingRoots(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Chdir(tempDir)
+	if err := os.MkdirAll("src", 0o755); err != nil {
+		t.Fatalf("ディレクトリの作成に失敗: %v", err)
+	}
+	for _, name := range []string{"top.txt", filepath.Join("src", "a.txt")} {
+		if err := os.WriteFile(name, []byte("missing"), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	result, err := processPaths([]string{"./src", ".", "src/a.txt"}, Options{Fix: true})
+	if err != nil {
+		t.Fatalf("processPaths()でエラーが発生: %v", err)
+	}
+	if result.Total != 2 || len(result.Fixed) != 2 {
+		t.Errorf("件数が期待値と異なります: total=%d, fixed=%v", result.Total, result.Fixed)
+	}
+
+	// 重複して処理されると改行が2つ追加される
+	content, err := os.ReadFile(filepath.Join("src", "a.txt"))
+	if err != nil {
+		t.Fatalf("ファイルの読み込みに失敗: %v", err)
+	}
+	if string(content) != "missing\n" {
+		t.Errorf("内容 = %q, expected %q", content, "missing\n")
+	}
+}
+
+func TestProcessPathsOverlappingSkippedDir(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Chdir(tempDir)
+	for _, dir := range []string{"vendor", "build"} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("ディレクトリの作成に失敗: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("missing"), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	// 外側のルートが辿らないディレクトリは内側のルートでチェックされる
+	opts := Options{SkipDirs: skipDirSet(true, "build")}
+	result, err := processPaths([]string{".", "vendor", "build"}, opts)
+	if err != nil {
+		t.Fatalf("processPaths()でエラーが発生: %v", err)
+	}
+	if expected := []string{"build/a.txt", "vendor/a.txt"}; !slices.Equal(result.Problematic, expected) {
+		t.Errorf("problematicが期待値と異なります: got %v, expected %v", result.Problematic, expected)
+	}
+}
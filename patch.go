@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// patchContext is the number of unchanged lines around each change, as
+// in diff -u
+const patchContext = 3
+
+// patchSet collects the fixes of an -output-patch run as a unified diff
+// instead of writing them to the files
+type patchSet struct {
+	path  string
+	diffs map[string][]byte
+}
+
+// newPatchSet returns an empty patch to be written to path
+func newPatchSet(path string) *patchSet {
+	return &patchSet{path: path, diffs: map[string][]byte{}}
+}
+
+// add records the change of the file at path from old to fixed. Paths in
+// the patch are relative to the working directory, so that it applies
+// with git apply or patch -p1 from there.
+func (p *patchSet) add(path string, old, fixed []byte) {
+	name := filepath.ToSlash(filepath.Clean(path))
+	if abs, err := filepath.Abs(path); err == nil {
+		if cwd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(cwd, abs); err == nil {
+				name = filepath.ToSlash(rel)
+			}
+		}
+	}
+
+	p.diffs[name] = unifiedDiff(name, old, fixed)
+}
+
+// save writes the collected diffs ordered by path. A run without fixes
+// leaves an empty patch.
+func (p *patchSet) save() error {
+	names := make([]string, 0, len(p.diffs))
+	for name := range p.diffs {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var buf bytes.Buffer
+	for _, name := range names {
+		buf.Write(p.diffs[name])
+	}
+
+	if err := os.WriteFile(p.path, buf.Bytes(), defaultFileMode); err != nil {
+		return fmt.Errorf("failed to write patch: %w", err)
+	}
+	return nil
+}
+
+// unifiedDiff returns a git-style diff of one file changed from old to
+// fixed. The fixes only touch the start and end of a file, so the lines
+// between the common leading and trailing lines form a single hunk.
+func unifiedDiff(name string, old, fixed []byte) []byte {
+	a, b := splitLines(old), splitLines(fixed)
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && bytes.Equal(a[prefix], b[prefix]) {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && bytes.Equal(a[len(a)-1-suffix], b[len(b)-1-suffix]) {
+		suffix++
+	}
+
+	start := max(prefix-patchContext, 0)
+	aEnd := min(len(a)-suffix+patchContext, len(a))
+	bEnd := min(len(b)-suffix+patchContext, len(b))
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "diff --git a/%s b/%s\n--- a/%s\n+++ b/%s\n", name, name, name, name)
+	fmt.Fprintf(&buf, "@@ -%s +%s @@\n", hunkRange(start, aEnd-start), hunkRange(start, bEnd-start))
+
+	for _, line := range a[start:prefix] {
+		writeDiffLine(&buf, ' ', line)
+	}
+	for _, line := range a[prefix : len(a)-suffix] {
+		writeDiffLine(&buf, '-', line)
+	}
+	for _, line := range b[prefix : len(b)-suffix] {
+		writeDiffLine(&buf, '+', line)
+	}
+	for _, line := range a[len(a)-suffix : aEnd] {
+		writeDiffLine(&buf, ' ', line)
+	}
+	return buf.Bytes()
+}
+
+// hunkRange formats the start line and line count of one side of a hunk.
+// An empty side is numbered by the line before it.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// writeDiffLine writes one diff line, marking a line without terminator
+// as diff does
+func writeDiffLine(buf *bytes.Buffer, op byte, line []byte) {
+	buf.WriteByte(op)
+	buf.Write(line)
+	if !bytes.HasSuffix(line, []byte("\n")) {
+		buf.WriteString("\n\\ No newline at end of file\n")
+	}
+}
+
+// splitLines splits data after each newline, keeping the terminators
+func splitLines(data []byte) [][]byte {
+	var lines [][]byte
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			lines = append(lines, data)
+			break
+		}
+		lines = append(lines, data[:i+1])
+		data = data[i+1:]
+	}
+	return lines
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old      string
+		fixed    string
+		expected string
+	}{
+		{
+			name:  "改行の追加",
+			old:   "a\nb\nc\nd\ne",
+			fixed: "a\nb\nc\nd\ne\n",
+			expected: "diff --git a/f.txt b/f.txt\n--- a/f.txt\n+++ b/f.txt\n" +
+				"@@ -2,4 +2,4 @@\n b\n c\n d\n-e\n\\ No newline at end of file\n+e\n",
+		},
+		{
+			name:  "空行の削除",
+			old:   "a\n\n\n",
+			fixed: "a\n",
+			expected: "diff --git a/f.txt b/f.txt\n--- a/f.txt\n+++ b/f.txt\n" +
+				"@@ -1,3 +1,1 @@\n a\n-\n-\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(unifiedDiff("f.txt", []byte(tt.old), []byte(tt.fixed)))
+			if got != tt.expected {
+				t.Errorf("差分が期待値と異なります:\ngot:\n%s\nexpected:\n%s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestOutputPatchAppliesWithGit(t *testing.T) {
+	dir := initGitRepo(t)
+	t.Chdir(dir)
+
+	if err := os.Mkdir("src", 0o755); err != nil {
+		t.Fatalf("ディレクトリの作成に失敗: %v", err)
+	}
+	files := map[string]string{
+		"src/main.txt": "line 1\nline 2",
+		"blank.txt":    "text\n\n\n",
+		"ok.txt":       "ok\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.FromSlash(name), []byte(content), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	patch := newPatchSet(filepath.Join(t.TempDir(), "fixes.diff"))
+	result, err := processRepository(".", Options{Fix: true, FixBlankLines: true, Patch: patch})
+	if err != nil {
+		t.Fatalf("processRepository()でエラーが発生: %v", err)
+	}
+	// ファイルは修正していないので、fixedではなくpatchedに記録する
+	if len(result.Fixed) != 0 || len(result.Patched) != 2 {
+		t.Errorf("fixed=%v, patched=%v, expected 2 patched files", result.Fixed, result.Patched)
+	}
+	for _, file := range result.Files {
+		if (file.Path == "blank.txt" || file.Path == "src/main.txt") && file.Status != statusPatched {
+			t.Errorf("%s のステータス = %s, expected %s", file.Path, file.Status, statusPatched)
+		}
+	}
+	var out bytes.Buffer
+	writeTextReport(&out, result, Options{Fix: true, Patch: patch})
+	for _, want := range []string{"Patched: blank.txt\n", "Files fixed in the patch: 2\n"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("レポートに %q が含まれていません:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "Fixed:") {
+		t.Errorf("パッチのみの修正がFixedとして表示されました:\n%s", out.String())
+	}
+	if err := patch.save(); err != nil {
+		t.Fatalf("パッチの書き込みに失敗: %v", err)
+	}
+
+	// ファイル自体は変更されない
+	for name, content := range files {
+		got, err := os.ReadFile(filepath.FromSlash(name))
+		if err != nil {
+			t.Fatalf("テストファイルの読み込みに失敗: %v", err)
+		}
+		if string(got) != content {
+			t.Errorf("%s が変更されました: %q", name, got)
+		}
+	}
+
+	if _, err := git(dir, nil, "apply", patch.path); err != nil {
+		t.Fatalf("git applyに失敗: %v", err)
+	}
+	expected := map[string]string{
+		"src/main.txt": "line 1\nline 2\n",
+		"blank.txt":    "text\n",
+		"ok.txt":       "ok\n",
+	}
+	for name, want := range expected {
+		got, err := os.ReadFile(filepath.FromSlash(name))
+		if err != nil {
+			t.Fatalf("テストファイルの読み込みに失敗: %v", err)
+		}
+		if string(got) != want {
+			t.Errorf("パッチ適用後の %s = %q, expected %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// pathAlias rewrites displayed paths under from to the same paths under to
+type pathAlias struct {
+	to, from string
+}
+
+// pathMap rewrites displayed paths so that reports stay comparable after
+// directories are renamed. It never changes which files are processed.
+type pathMap struct {
+	aliases []pathAlias
+}
+
+// loadPathMap reads a -path-map file. As in .mailmap, each line gives the
+// name to display followed by the name found in the tree, e.g.
+// "services/api legacy/api". Blank lines and lines starting with "#" are
+// ignored.
+func loadPathMap(name string) (*pathMap, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open path map: %w", err)
+	}
+	defer f.Close()
+
+	m := &pathMap{}
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid path map %s:%d: expected \"<displayed path> <actual path>\"", name, lineNo)
+		}
+		m.aliases = append(m.aliases, pathAlias{
+			to:   strings.TrimSuffix(fields[0], "/"),
+			from: strings.TrimSuffix(fields[1], "/"),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read path map %s: %w", name, err)
+	}
+
+	return m, nil
+}
+
+// display returns relPath with the longest matching alias applied. An
+// alias matches the path itself or any path below it.
+func (m *pathMap) display(relPath string) string {
+	best := -1
+	for i, alias := range m.aliases {
+		if relPath != alias.from && !strings.HasPrefix(relPath, alias.from+"/") {
+			continue
+		}
+		if best < 0 || len(alias.from) > len(m.aliases[best].from) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return relPath
+	}
+
+	alias := m.aliases[best]
+	return alias.to + relPath[len(alias.from):]
+}
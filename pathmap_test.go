@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestPathMapDisplay(t *testing.T) {
+	m := &pathMap{aliases: []pathAlias{
+		{to: "services/api", from: "legacy/api"},
+		{to: "services/api/v2", from: "legacy/api/next"},
+		{to: "README.md", from: "README"},
+	}}
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{path: "legacy/api/main.go", expected: "services/api/main.go"},
+		{path: "legacy/api/next/main.go", expected: "services/api/v2/main.go"},
+		{path: "legacy/apis/main.go", expected: "legacy/apis/main.go"},
+		{path: "README", expected: "README.md"},
+		{path: "other.txt", expected: "other.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := m.display(tt.path); got != tt.expected {
+				t.Errorf("display(%q) = %q, expected %q", tt.path, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoadPathMap(t *testing.T) {
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "valid")
+	content := "# renamed in 2026\n\nservices/api/  legacy/api\n"
+	if err := os.WriteFile(valid, []byte(content), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+	m, err := loadPathMap(valid)
+	if err != nil {
+		t.Fatalf("loadPathMap()でエラーが発生: %v", err)
+	}
+	if !slices.Equal(m.aliases, []pathAlias{{to: "services/api", from: "legacy/api"}}) {
+		t.Errorf("読み込んだ対応が期待値と異なります: %v", m.aliases)
+	}
+
+	invalid := filepath.Join(dir, "invalid")
+	if err := os.WriteFile(invalid, []byte("only-one-path\n"), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+	if _, err := loadPathMap(invalid); err == nil {
+		t.Error("不正な行でエラーが返されませんでした")
+	}
+}
+
+func TestProcessRepositoryPathMap(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tempDir, "legacy"), 0o755); err != nil {
+		t.Fatalf("ディレクトリの作成に失敗: %v", err)
+	}
+	testFile := filepath.Join(tempDir, "legacy", "a.txt")
+	if err := os.WriteFile(testFile, []byte("no newline"), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+
+	opts := Options{Fix: true, PathMap: &pathMap{aliases: []pathAlias{{to: "modern", from: "legacy"}}}}
+	result, err := processRepository(tempDir, opts)
+	if err != nil {
+		t.Fatalf("processRepository()でエラーが発生: %v", err)
+	}
+	if !slices.Equal(result.Fixed, []string{"modern/a.txt"}) {
+		t.Errorf("表示されるパスが期待値と異なります: %v", result.Fixed)
+	}
+
+	// 実際のファイルは元のパスで処理される
+	content, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("テストファイルの読み込みに失敗: %v", err)
+	}
+	if string(content) != "no newline\n" {
+		t.Errorf("ファイルが修正されていません: %q", string(content))
+	}
+}
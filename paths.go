@@ -0,0 +1,112 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Path styles for -paths
+const (
+	pathsRootRel = "root-rel"
+	pathsAbs     = "abs"
+	pathsCwdRel  = "cwd-rel"
+)
+
+// isValidPathStyle reports whether style is a supported -paths value
+func isValidPathStyle(style string) bool {
+	switch style {
+	case pathsRootRel, pathsAbs, pathsCwdRel:
+		return true
+	}
+	return false
+}
+
+// rememberPath records where relPath lives on disk so that finish can
+// display it in another style
+func (r *RepoResult) rememberPath(relPath, path string, opts Options) {
+	if opts.PathStyle == "" || opts.PathStyle == pathsRootRel {
+		return
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return
+	}
+	if r.paths == nil {
+		r.paths = make(map[string]string)
+	}
+	r.paths[relPath] = abs
+}
+
+// relabel rewrites every recorded path in the style chosen by -paths.
+// Paths that were never remembered, such as arguments that could not be
+// read, are left as they are.
+func (r *RepoResult) relabel(opts Options) {
+	if len(r.paths) == 0 {
+		return
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		cwd = ""
+	}
+
+	display := func(relPath string) string {
+		// Archive members are remembered through their archive. Other
+		// files may have the separator in their own name.
+		prefix, member, isMember := strings.Cut(relPath, archiveMemberSep)
+		if isMember && archiveKind(prefix) == "" {
+			prefix, member, isMember = relPath, "", false
+		}
+		abs, ok := r.paths[prefix]
+		if !ok {
+			return relPath
+		}
+
+		shown := filepath.ToSlash(abs)
+		if opts.PathStyle == pathsCwdRel && cwd != "" {
+			if rel, err := filepath.Rel(cwd, abs); err == nil {
+				shown = filepath.ToSlash(rel)
+			}
+		}
+		if isMember {
+			shown += archiveMemberSep + member
+		}
+		return shown
+	}
+
+	r.rewritePaths(display)
+}
+
+// prefixPath returns a display function putting prefix in front of each
+// path as a leading directory
+func prefixPath(prefix string) func(string) string {
+	prefix = strings.TrimSuffix(filepath.ToSlash(prefix), "/") + "/"
+	return func(p string) string {
+		return prefix + strings.TrimPrefix(p, "./")
+	}
+}
+
+// rewritePaths replaces every recorded path with display(path)
+func (r *RepoResult) rewritePaths(display func(string) string) {
+	for _, list := range [][]string{r.Fixed, r.Patched, r.Quarantined, r.Generated, r.Binary, r.Problematic, r.NoLineTerminators, r.TrailingBlankLines, r.TrailingWhitespace, r.UTF8BOM, r.Clean, r.Confined, r.MissingRequired} {
+		for i, p := range list {
+			list[i] = display(p)
+		}
+	}
+	for i := range r.Errors {
+		r.Errors[i].Path = display(r.Errors[i].Path)
+	}
+	for i := range r.Files {
+		r.Files[i].Path = display(r.Files[i].Path)
+	}
+	for i := range r.Slowest {
+		r.Slowest[i].Path = display(r.Slowest[i].Path)
+	}
+	for _, root := range r.Roots {
+		for i, p := range root.Problematic {
+			root.Problematic[i] = display(p)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestPathStyle(t *testing.T) {
+	// 作業ディレクトリとの比較のためシンボリックリンクを解決しておく
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("一時ディレクトリの解決に失敗: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "repo", "src"), 0o755); err != nil {
+		t.Fatalf("ディレクトリの作成に失敗: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "repo", "src", "a.txt"), []byte("missing"), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+
+	// 作業ディレクトリをチェック対象の外に置く
+	if err := os.Mkdir(filepath.Join(root, "work"), 0o755); err != nil {
+		t.Fatalf("ディレクトリの作成に失敗: %v", err)
+	}
+	t.Chdir(filepath.Join(root, "work"))
+
+	abs, err := filepath.Abs(filepath.Join(root, "repo", "src", "a.txt"))
+	if err != nil {
+		t.Fatalf("絶対パスの取得に失敗: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		style    string
+		expected string
+	}{
+		{name: "デフォルト", style: "", expected: "src/a.txt"},
+		{name: "ルート相対", style: pathsRootRel, expected: "src/a.txt"},
+		{name: "絶対パス", style: pathsAbs, expected: filepath.ToSlash(abs)},
+		{name: "作業ディレクトリ相対", style: pathsCwdRel, expected: "../repo/src/a.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := processRepository(filepath.Join(root, "repo"), Options{PathStyle: tt.style})
+			if err != nil {
+				t.Fatalf("processRepository()でエラーが発生: %v", err)
+			}
+
+			if !slices.Equal(result.Problematic, []string{tt.expected}) {
+				t.Errorf("problematicが期待値と異なります: got %v, expected %q", result.Problematic, tt.expected)
+			}
+			if len(result.Files) != 1 || result.Files[0].Path != tt.expected {
+				t.Errorf("ファイル結果のパスが期待値と異なります: %v", result.Files)
+			}
+		})
+	}
+}
+
+func TestPathStyleSeparatorInName(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("一時ディレクトリの解決に失敗: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "a!b.txt"), []byte("missing"), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+	t.Chdir(root)
+
+	// アーカイブでないファイル名の"!"はメンバーの区切りとして扱わない
+	tests := []struct {
+		name     string
+		style    string
+		expected string
+	}{
+		{name: "絶対パス", style: pathsAbs, expected: filepath.ToSlash(filepath.Join(root, "a!b.txt"))},
+		{name: "作業ディレクトリ相対", style: pathsCwdRel, expected: "a!b.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := processRepository(root, Options{PathStyle: tt.style})
+			if err != nil {
+				t.Fatalf("processRepository()でエラーが発生: %v", err)
+			}
+			if !slices.Equal(result.Problematic, []string{tt.expected}) {
+				t.Errorf("problematicが期待値と異なります: got %v, expected %q", result.Problematic, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPathPrefix(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "src"), 0o755); err != nil {
+		t.Fatalf("ディレクトリの作成に失敗: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "src", "a.txt"), []byte("\xef\xbb\xbfmissing"), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		prefix   string
+		expected string
+	}{
+		{name: "なし", prefix: "", expected: "src/a.txt"},
+		{name: "リポジトリ名", prefix: "myrepo", expected: "myrepo/src/a.txt"},
+		{name: "末尾のスラッシュ", prefix: "org/myrepo/", expected: "org/myrepo/src/a.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := processRepository(dir, Options{PathPrefix: tt.prefix, NoBOM: true})
+			if err != nil {
+				t.Fatalf("processRepository()でエラーが発生: %v", err)
+			}
+
+			if !slices.Equal(result.Problematic, []string{tt.expected}) {
+				t.Errorf("problematicが期待値と異なります: got %v, expected %q", result.Problematic, tt.expected)
+			}
+			if len(result.Files) != 1 || result.Files[0].Path != tt.expected {
+				t.Errorf("ファイル結果のパスが期待値と異なります: %v", result.Files)
+			}
+			if !slices.Equal(result.UTF8BOM, []string{tt.expected}) {
+				t.Errorf("utf8_bomが期待値と異なります: got %v, expected %q", result.UTF8BOM, tt.expected)
+			}
+			if result.LastLines[tt.expected] != 1 {
+				t.Errorf("last_linesが期待値と異なります: %v", result.LastLines)
+			}
+		})
+	}
+}
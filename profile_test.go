@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStartProfiling(t *testing.T) {
+	tempDir := t.TempDir()
+	cpuPath := filepath.Join(tempDir, "cpu.prof")
+	memPath := filepath.Join(tempDir, "mem.prof")
+
+	stop, err := startProfiling(cpuPath, memPath)
+	if err != nil {
+		t.Fatalf("プロファイルの開始に失敗: %v", err)
+	}
+	if err := stop(); err != nil {
+		t.Fatalf("プロファイルの書き込みに失敗: %v", err)
+	}
+
+	for _, path := range []string{cpuPath, memPath} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("プロファイルが作成されていません: %v", err)
+		}
+		if info.Size() == 0 {
+			t.Errorf("プロファイルが空です: %s", path)
+		}
+	}
+}
+
+func TestStartProfilingDisabled(t *testing.T) {
+	stop, err := startProfiling("", "")
+	if err != nil {
+		t.Fatalf("プロファイル無効時にエラーが発生: %v", err)
+	}
+	if err := stop(); err != nil {
+		t.Errorf("プロファイル無効時の終了処理でエラーが発生: %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// quarantineFile writes the fixed version of path under the quarantine
+// directory at relPath, leaving the original untouched
+func quarantineFile(path, relPath string, opts Options) error {
+	rel := filepath.Clean(filepath.FromSlash(relPath))
+	if filepath.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("cannot quarantine %s: path is outside the checked tree", relPath)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return &ReadError{Path: path, Err: err}
+	}
+	_, fixed := checkBytes(data, opts)
+
+	dest := filepath.Join(opts.Quarantine, rel)
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return fmt.Errorf("failed to create quarantine directory: %w", err)
+	}
+
+	if err := os.WriteFile(dest, fixed, fileModeFor(path, opts)); err != nil {
+		return fmt.Errorf("failed to write quarantined file: %w", err)
+	}
+
+	return nil
+}
+
+// isQuarantineDir reports whether dir is the quarantine directory, which
+// must not be walked while it is being written to
+func isQuarantineDir(dir string, opts Options) bool {
+	if opts.Quarantine == "" {
+		return false
+	}
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	absQuarantine, err := filepath.Abs(opts.Quarantine)
+	if err != nil {
+		return false
+	}
+
+	return absDir == absQuarantine
+}
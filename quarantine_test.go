@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProcessRepositoryQuarantine(t *testing.T) {
+	tempDir := t.TempDir()
+	quarantineDir := filepath.Join(tempDir, "quarantine")
+
+	files := map[string]string{
+		"src/deep/main.go": "package main",
+		"ok.txt":           "ok\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("ディレクトリの作成に失敗: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	// 隔離ディレクトリはチェック対象のツリー内にあっても走査されない
+	for i := 0; i < 2; i++ {
+		result, err := processRepository(tempDir, Options{Quarantine: quarantineDir})
+		if err != nil {
+			t.Fatalf("processRepository()でエラーが発生: %v", err)
+		}
+		if len(result.Quarantined) != 1 || result.Quarantined[0] != "src/deep/main.go" {
+			t.Errorf("quarantinedが期待値と異なります: %v", result.Quarantined)
+		}
+	}
+
+	original, err := os.ReadFile(filepath.Join(tempDir, "src", "deep", "main.go"))
+	if err != nil {
+		t.Fatalf("元のファイルの読み込みに失敗: %v", err)
+	}
+	if string(original) != "package main" {
+		t.Errorf("元のファイルが変更されています: %q", string(original))
+	}
+
+	quarantined, err := os.ReadFile(filepath.Join(quarantineDir, "src", "deep", "main.go"))
+	if err != nil {
+		t.Fatalf("隔離されたファイルの読み込みに失敗: %v", err)
+	}
+	if string(quarantined) != "package main\n" {
+		t.Errorf("隔離されたファイルの内容が期待値と異なります: %q", string(quarantined))
+	}
+
+	if _, err := os.Stat(filepath.Join(quarantineDir, "ok.txt")); !os.IsNotExist(err) {
+		t.Errorf("問題のないファイルが隔離されています")
+	}
+}
+
+func TestQuarantineFileOutsideTree(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+
+	if err := quarantineFile(path, "../file.txt", Options{Quarantine: t.TempDir()}); err == nil {
+		t.Errorf("ツリー外のパスでエラーが発生しませんでした")
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// rcFileName is the project configuration file searched for upward from
+// the checked directory
+const rcFileName = ".newlinerc"
+
+// rcStartDir returns the directory the .newlinerc search starts from: the
+// path argument, or its directory when it is a file, or the working
+// directory when there are none or several. Several paths are each
+// checked with their own .newlinerc; the one from the working directory
+// only sets the run-wide flags, such as the report format.
+func rcStartDir(args []string) string {
+	if len(args) != 1 {
+		return "."
+	}
+	if info, err := os.Stat(args[0]); err == nil && !info.IsDir() {
+		return filepath.Dir(args[0])
+	}
+	return args[0]
+}
+
+// findRCFile returns the nearest .newlinerc in dir or its parents, or ""
+// when there is none. The search ends at the root of the git work tree,
+// the directory containing .git, and does not cross into another
+// filesystem.
+func findRCFile(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	var device uint64
+	if info, err := os.Stat(dir); err == nil {
+		device, _ = deviceID(info)
+	}
+
+	for {
+		path := filepath.Join(dir, rcFileName)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("failed to check %s: %w", path, err)
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return "", nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		if info, err := os.Stat(parent); err == nil {
+			if dev, ok := deviceID(info); ok && dev != device {
+				return "", nil
+			}
+		}
+		dir = parent
+	}
+}
+
+// rcFlags are the flags a .newlinerc may set: the checking policy, which
+// files are looked at and how the report is laid out. A checked-in file
+// must not be able to run commands, turn a check into a fix or write
+// anywhere, so every other flag is only accepted on the command line.
+var rcFlags = map[string]bool{
+	// Checking policy
+	"encoding": true, "eol-by-extension": true, "eol-map": true, "reject-crlf-eof": true,
+	"single-final-newline": true, "final-newlines": true, "no-bom": true, "strict": true,
+	"require": true, "blank-line-policy": true, "text-control-bytes": true,
+	"preserve-trailing-content": true, "error-on-binary-content": true,
+	// Files looked at
+	"skip-dir": true, "no-default-skip-dirs": true, "no-ext-skip": true,
+	"skip-generated": true, "generated-pattern": true, "skip-content-regex": true,
+	"min-file-size": true, "skip-symlinks": true, "confine-to-root": true,
+	"respect-gitattributes": true, "respect-gitignore": true, "respect-ignore-files": true,
+	"ignore-case": true,
+	// Report layout
+	"format": true, "summary": true, "paths": true, "verbose": true, "max-report": true,
+	"sort": true, "depth-first": true,
+}
+
+// loadRCFile reads a .newlinerc, a JSON object mapping flag names to
+// values, e.g. {"single-final-newline": true, "skip-dir": "build"}. Keys
+// starting with "//" are comments.
+func loadRCFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	settings := make(map[string]string, len(raw))
+	for name, value := range raw {
+		if strings.HasPrefix(name, rcCommentPrefix) {
+			continue
+		}
+		switch v := value.(type) {
+		case bool:
+			settings[name] = strconv.FormatBool(v)
+		case float64:
+			settings[name] = strconv.FormatFloat(v, 'f', -1, 64)
+		case string:
+			settings[name] = v
+		default:
+			return nil, fmt.Errorf("%s: %q must be a boolean, number or string", path, name)
+		}
+	}
+	return settings, nil
+}
+
+// applyRCFile sets the flags configured in the .newlinerc found upward
+// from dir. Flags given on the command line win.
+func applyRCFile(fs *flag.FlagSet, dir string) error {
+	path, err := findRCFile(dir)
+	if err != nil || path == "" {
+		return err
+	}
+
+	settings, err := loadRCFile(path)
+	if err != nil {
+		return err
+	}
+
+	given := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { given[f.Name] = true })
+
+	for name, value := range settings {
+		if fs.Lookup(name) == nil {
+			return fmt.Errorf("%s: unknown flag %q", path, name)
+		}
+		if !rcFlags[name] {
+			return fmt.Errorf("%s: %q can only be given on the command line", path, name)
+		}
+		if given[name] {
+			continue
+		}
+		if err := fs.Set(name, value); err != nil {
+			return fmt.Errorf("%s: invalid value %q for %q: %w", path, value, name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFindRCFile(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "repo", "sub", "dir")
+	if err := os.MkdirAll(sub, 0o755); err != nil {
+		t.Fatalf("ディレクトリの作成に失敗: %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(root, "repo", ".git"), 0o755); err != nil {
+		t.Fatalf("ディレクトリの作成に失敗: %v", err)
+	}
+
+	// gitのルートより上の設定ファイルは使わない
+	if err := os.WriteFile(filepath.Join(root, rcFileName), []byte("{}"), 0o644); err != nil {
+		t.Fatalf("設定ファイルの作成に失敗: %v", err)
+	}
+	path, err := findRCFile(sub)
+	if err != nil {
+		t.Fatalf("findRCFile()でエラーが発生: %v", err)
+	}
+	if path != "" {
+		t.Errorf("gitのルートを越えて設定ファイルが見つかりました: %s", path)
+	}
+
+	rc := filepath.Join(root, "repo", "sub", rcFileName)
+	if err := os.WriteFile(rc, []byte("{}"), 0o644); err != nil {
+		t.Fatalf("設定ファイルの作成に失敗: %v", err)
+	}
+	path, err = findRCFile(sub)
+	if err != nil {
+		t.Fatalf("findRCFile()でエラーが発生: %v", err)
+	}
+	if path != rc {
+		t.Errorf("findRCFile() = %q, expected %q", path, rc)
+	}
+}
+
+func TestParseArgsRCFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, ".git"), 0o755); err != nil {
+		t.Fatalf("ディレクトリの作成に失敗: %v", err)
+	}
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatalf("ディレクトリの作成に失敗: %v", err)
+	}
+	rc := `{"single-final-newline": true, "format": "json", "max-report": 3}`
+	if err := os.WriteFile(filepath.Join(dir, rcFileName), []byte(rc), 0o644); err != nil {
+		t.Fatalf("設定ファイルの作成に失敗: %v", err)
+	}
+
+	// コマンドラインのフラグが優先される
+	cfg, err := parseArgs([]string{"-format", "text", sub})
+	if err != nil {
+		t.Fatalf("parseArgs()でエラーが発生: %v", err)
+	}
+	if !cfg.opts.SingleFinalNewline || cfg.opts.MaxReport != 3 {
+		t.Errorf("設定ファイルの値が適用されていません: %+v", cfg.opts)
+	}
+	if cfg.opts.Format != formatText {
+		t.Errorf("コマンドラインのフラグが上書きされました: %q", cfg.opts.Format)
+	}
+
+	cfg, err = parseArgs([]string{"-no-rc", sub})
+	if err != nil {
+		t.Fatalf("parseArgs()でエラーが発生: %v", err)
+	}
+	if cfg.opts.SingleFinalNewline {
+		t.Error("-no-rcでも設定ファイルが読み込まれました")
+	}
+}
+
+func TestParseArgsRCFileRoots(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, ".git"), 0o755); err != nil {
+		t.Fatalf("ディレクトリの作成に失敗: %v", err)
+	}
+	for _, name := range []string{"frontend", "backend"} {
+		if err := os.Mkdir(filepath.Join(dir, name), 0o755); err != nil {
+			t.Fatalf("ディレクトリの作成に失敗: %v", err)
+		}
+	}
+	rcs := map[string]string{
+		rcFileName:                            `{"format": "json"}`,
+		filepath.Join("frontend", rcFileName): `{"single-final-newline": true}`,
+	}
+	for name, rc := range rcs {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(rc), 0o644); err != nil {
+			t.Fatalf("設定ファイルの作成に失敗: %v", err)
+		}
+	}
+	t.Chdir(dir)
+
+	cfg, err := parseArgs([]string{"frontend", "backend"})
+	if err != nil {
+		t.Fatalf("parseArgs()でエラーが発生: %v", err)
+	}
+	if cfg.opts.Format != formatJSON || cfg.opts.SingleFinalNewline {
+		t.Errorf("カレントディレクトリの設定ファイルが適用されていません: %+v", cfg.opts)
+	}
+	if len(cfg.roots) != 2 {
+		t.Fatalf("ルートごとの設定数 = %d, expected 2", len(cfg.roots))
+	}
+	if !cfg.roots[0].opts.SingleFinalNewline {
+		t.Error("frontendに自身の設定ファイルが適用されていません")
+	}
+	if cfg.roots[1].opts.SingleFinalNewline {
+		t.Error("backendにfrontendの設定ファイルが適用されました")
+	}
+
+	cfg, err = parseArgs([]string{"-no-rc", "frontend", "backend"})
+	if err != nil {
+		t.Fatalf("parseArgs()でエラーが発生: %v", err)
+	}
+	if len(cfg.roots) != 0 {
+		t.Errorf("-no-rcでもルートごとの設定が読み込まれました: %d", len(cfg.roots))
+	}
+}
+
+func TestParseArgsRCFileErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		rc   string
+	}{
+		{name: "不正なJSON", rc: `{"fix": `},
+		{name: "未知のフラグ", rc: `{"no-such-flag": true}`},
+		{name: "不正な値", rc: `{"max-report": "many"}`},
+		{name: "未対応の型", rc: `{"skip-dir": ["build"]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, rcFileName), []byte(tt.rc), 0o644); err != nil {
+				t.Fatalf("設定ファイルの作成に失敗: %v", err)
+			}
+			if _, err := parseArgs([]string{dir}); err == nil {
+				t.Error("エラーが返されませんでした")
+			}
+		})
+	}
+}
+
+func TestParseArgsRCFileRefusesFlags(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, ".git"), 0o755); err != nil {
+		t.Fatalf("ディレクトリの作成に失敗: %v", err)
+	}
+	marker := filepath.Join(t.TempDir(), "ran")
+
+	tests := []struct {
+		name string
+		rc   string
+	}{
+		{name: "コマンドの実行", rc: `{"skip-command": "touch ` + marker + `"}`},
+		{name: "修正", rc: `{"fix": true}`},
+		{name: "末尾の空白の修正", rc: `{"fix-whitespace": true}`},
+		{name: "レポートファイル", rc: `{"report-file": "out.txt"}`},
+		{name: "退避", rc: `{"quarantine": "q"}`},
+		{name: "パッチ", rc: `{"output-patch": "fix.diff"}`},
+		{name: "キャッシュ", rc: `{"cache": "cache.json"}`},
+		{name: "プロファイル", rc: `{"cpuprofile": "cpu.out"}`},
+		{name: "未コミットの変更", rc: `{"allow-dirty": true}`},
+		{name: "強制", rc: `{"force": true}`},
+		{name: "標準入力", rc: `{"stdin": true}`},
+		{name: "初期化", rc: `{"init": true}`},
+		{name: "セルフチェック", rc: `{"self-check": true}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.WriteFile(filepath.Join(dir, rcFileName), []byte(tt.rc), 0o644); err != nil {
+				t.Fatalf("設定ファイルの作成に失敗: %v", err)
+			}
+
+			_, err := parseArgs([]string{dir})
+			if err == nil || !strings.Contains(err.Error(), "can only be given on the command line") {
+				t.Errorf("設定ファイルのフラグが拒否されませんでした: %v", err)
+			}
+		})
+	}
+
+	// ルートごとの設定ファイルでも拒否する
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatalf("ディレクトリの作成に失敗: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, rcFileName), []byte("{}"), 0o644); err != nil {
+		t.Fatalf("設定ファイルの作成に失敗: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(sub, rcFileName), []byte(`{"fix": true}`), 0o644); err != nil {
+		t.Fatalf("設定ファイルの作成に失敗: %v", err)
+	}
+	t.Chdir(dir)
+	if _, err := parseArgs([]string{"sub", "."}); err == nil {
+		t.Error("ルートの設定ファイルのfixが拒否されませんでした")
+	}
+
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("設定ファイルのコマンドが実行されました")
+	}
+}
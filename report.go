@@ -0,0 +1,383 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"text/template"
+)
+
+// Supported report formats
+const (
+	formatText  = "text"
+	formatJSON  = "json"
+	formatJUnit = "junit"
+	formatSARIF = "sarif"
+	formatCSV   = "csv"
+	formatTAP   = "tap"
+	// formatList prints only the paths of the problematic files, one
+	// per line, for xargs and the like
+	formatList = "list"
+)
+
+// isValidFormat reports whether format names a supported report format
+func isValidFormat(format string) bool {
+	switch format {
+	case "", formatText, formatJSON, formatJUnit, formatSARIF, formatCSV, formatTAP, formatList:
+		return true
+	}
+	return false
+}
+
+// Encodings of the report file for -output-encoding
+const (
+	outputLF      = "lf"
+	outputCRLF    = "crlf"
+	outputLFBOM   = "lf-bom"
+	outputCRLFBOM = "crlf-bom"
+)
+
+// isValidOutputEncoding reports whether encoding is a supported
+// -output-encoding value
+func isValidOutputEncoding(encoding string) bool {
+	switch encoding {
+	case "", outputLF, outputCRLF, outputLFBOM, outputCRLFBOM:
+		return true
+	}
+	return false
+}
+
+// encodeOutput converts a report written with LF line endings to the
+// given -output-encoding
+func encodeOutput(data []byte, encoding string) []byte {
+	if encoding == outputCRLF || encoding == outputCRLFBOM {
+		data = bytes.ReplaceAll(data, []byte("\n"), []byte("\r\n"))
+	}
+	if encoding == outputLFBOM || encoding == outputCRLFBOM {
+		data = append(slices.Clip(utf8BOM), data...)
+	}
+	return data
+}
+
+// writeResult renders the result according to the options.
+// With a report file, the full report goes to the file in ReportFormat,
+// or in Format when ReportFormat is empty. Stdout then gets the full
+// report in Format if ReportFormat was given, and only the text summary
+// otherwise.
+func writeResult(result *RepoResult, opts Options) error {
+	if opts.ReportFile == "" {
+		return writeReport(os.Stdout, result, opts)
+	}
+
+	// The report file always lists every file and has its own summary
+	fileOpts := opts
+	fileOpts.MaxReport = 0
+	fileOpts.SummaryStream = ""
+	if opts.ReportFormat != "" {
+		fileOpts.Format = opts.ReportFormat
+	}
+	if err := writeReportFile(opts.ReportFile, result, fileOpts); err != nil {
+		return err
+	}
+
+	if opts.ReportFormat != "" {
+		return writeReport(os.Stdout, result, opts)
+	}
+
+	out := io.Writer(os.Stdout)
+	if opts.SummaryStream == streamStderr {
+		out = os.Stderr
+	}
+	writeTextSummary(out, result, opts)
+	return nil
+}
+
+// writeReportFile writes the report to path in opts.OutputEncoding,
+// creating parent directories if needed
+func writeReportFile(path string, result *RepoResult, opts Options) error {
+	var buf bytes.Buffer
+	if err := writeReport(&buf, result, opts); err != nil {
+		return err
+	}
+
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create report directory: %w", err)
+		}
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fileModeFor(path, opts))
+	if err != nil {
+		return fmt.Errorf("failed to create report file: %w", err)
+	}
+
+	if _, err := f.Write(encodeOutput(buf.Bytes(), opts.OutputEncoding)); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write report file: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write report file: %w", err)
+	}
+
+	return nil
+}
+
+// Streams for -summary-stream
+const (
+	streamStdout = "stdout"
+	streamStderr = "stderr"
+)
+
+// isValidSummaryStream reports whether stream is a supported
+// -summary-stream value
+func isValidSummaryStream(stream string) bool {
+	return stream == streamStdout || stream == streamStderr
+}
+
+// writeReport writes the full report in the configured format. With
+// -summary-stream stderr the text summary goes to stderr, and the other
+// formats, which have no summary of their own, get one there too.
+func writeReport(w io.Writer, result *RepoResult, opts Options) error {
+	var err error
+	switch opts.Format {
+	case formatJSON:
+		err = writeJSONReport(w, result)
+	case formatJUnit:
+		err = writeJUnitReport(w, result)
+	case formatSARIF:
+		err = writeSARIFReport(w, result, opts)
+	case formatCSV:
+		err = writeCSVReport(w, result)
+	case formatTAP:
+		err = writeTAPReport(w, result)
+	case formatList:
+		for _, file := range result.Problematic {
+			fmt.Fprintln(w, file)
+		}
+	default:
+		writeTextReport(w, result, opts)
+		return nil
+	}
+
+	if err == nil && opts.SummaryStream == streamStderr {
+		writeSummarySection(os.Stderr, result, opts)
+	}
+	return err
+}
+
+// writeJSONReport writes the result as indented JSON
+func writeJSONReport(w io.Writer, result *RepoResult) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(result); err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+	return nil
+}
+
+// writeTextReport writes per-file messages followed by the summary
+func writeTextReport(w io.Writer, result *RepoResult, opts Options) {
+	for _, fileErr := range result.Errors {
+		fmt.Fprintf(w, "Error processing %s: %s\n", fileErr.Path, fileErr.Err)
+	}
+
+	for _, file := range result.Fixed {
+		fmt.Fprintf(w, "Fixed: %s\n", file)
+	}
+
+	for _, file := range result.Patched {
+		fmt.Fprintf(w, "Patched: %s\n", file)
+	}
+
+	for _, file := range result.Quarantined {
+		fmt.Fprintf(w, "Quarantined: %s\n", file)
+	}
+
+	if opts.SummaryStream == streamStderr {
+		w = os.Stderr
+	}
+	writeSummarySection(w, result, opts)
+}
+
+// writeSummarySection writes the summaries chosen by -summary followed by
+// the -timing and -count-lines-added sections
+func writeSummarySection(w io.Writer, result *RepoResult, opts Options) {
+	switch {
+	case opts.Summary == summaryPerRoot && len(result.Roots) > 0:
+		writeRootSummaries(w, result, opts, true)
+	case opts.Summary == summaryBoth:
+		writeRootSummaries(w, result, opts, false)
+		writeTextSummary(w, result, opts)
+	default:
+		writeTextSummary(w, result, opts)
+	}
+
+	if result.EOLMajority != nil {
+		writeEOLMajority(w, result.EOLMajority, opts)
+	}
+
+	if opts.Timing {
+		writeSlowest(w, result)
+	}
+
+	if opts.CountLinesAdded {
+		// A single line that pastes straight into a commit message
+		fmt.Fprintf(w, "\nFix diff stat:\n\n    files changed: %d, lines added: %d\n", result.FilesChanged, result.LinesAdded)
+	}
+}
+
+// parseSummaryTemplate parses a -summary-template and executes it once
+// against an empty result, so that unknown fields are reported up front
+func parseSummaryTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("summary").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -summary-template: %w", err)
+	}
+	if err := tmpl.Execute(io.Discard, &RepoResult{}); err != nil {
+		return nil, fmt.Errorf("invalid -summary-template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// writeTemplateSummary writes the summary from SummaryTemplate, ending it
+// with a newline if the template doesn't. An execution error goes to
+// stderr, leaving w without a summary.
+func writeTemplateSummary(w io.Writer, result *RepoResult, tmpl *template.Template) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, result); err != nil {
+		fmt.Fprintf(os.Stderr, "Error executing -summary-template: %v\n", err)
+		return
+	}
+	if buf.Len() > 0 && !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+	w.Write(buf.Bytes())
+}
+
+// writeTextSummary writes the human-readable summary section
+func writeTextSummary(w io.Writer, result *RepoResult, opts Options) {
+	if opts.SummaryTemplate != nil {
+		writeTemplateSummary(w, result, opts.SummaryTemplate)
+		return
+	}
+
+	fmt.Fprintf(w, "\n=== Summary ===\n")
+	fmt.Fprintf(w, "Total files checked: %d\n", result.Total)
+	fmt.Fprintf(w, "Files skipped: %d\n", result.Skipped)
+	if result.Cached > 0 {
+		fmt.Fprintf(w, "Files unchanged since last run (cached): %d\n", result.Cached)
+	}
+	if len(result.Generated) > 0 {
+		fmt.Fprintf(w, "Generated files skipped: %d\n", len(result.Generated))
+	}
+
+	fmt.Fprintf(w, "Compliance: %.1f%% of checked files are compliant\n", result.compliancePercent())
+
+	if len(result.MissingRequired) > 0 {
+		fmt.Fprintf(w, "Required files missing: %d\n", len(result.MissingRequired))
+		for _, file := range result.MissingRequired {
+			fmt.Fprintf(w, "  - %s\n", file)
+		}
+	}
+
+	writeTailList(w, "Files with trailing blank lines", result.TrailingBlankLines, opts.FixBlankLines)
+	writeTailList(w, "Files with trailing whitespace", result.TrailingWhitespace, opts.FixWhitespace)
+	writeTailList(w, "Files starting with a UTF-8 BOM", result.UTF8BOM, opts.Fix)
+
+	if opts.Verbose {
+		fmt.Fprintf(w, "Files with no line terminators: %d\n", len(result.NoLineTerminators))
+		if len(result.Confined) > 0 {
+			fmt.Fprintf(w, "Symlinks outside the root skipped: %d\n", len(result.Confined))
+			for _, file := range result.Confined {
+				fmt.Fprintf(w, "  - %s\n", file)
+			}
+		}
+	}
+
+	if result.Mode == modeFix {
+		fmt.Fprintf(w, "%s: %d\n", fixedLabel(opts), len(result.Fixed)+len(result.Patched))
+		if len(result.Problematic) > 0 {
+			// Fixes declined in -interactive mode
+			fmt.Fprintf(w, "Files left unfixed: %d\n", len(result.Problematic))
+			files, more := limitList(result.Problematic, opts.MaxReport)
+			for _, file := range files {
+				fmt.Fprintf(w, "  - %s\n", file)
+			}
+			writeMore(w, more)
+		} else if !result.changed() {
+			fmt.Fprintln(w, "All files already end with newline!")
+		}
+		return
+	}
+
+	fmt.Fprintf(w, "Files missing newline: %d\n", len(result.Problematic))
+	if len(result.Problematic) > 0 {
+		fmt.Fprintln(w, "\nFiles that don't end with newline:")
+		files, more := limitList(result.Problematic, opts.MaxReport)
+		for _, file := range files {
+			if !opts.Verbose {
+				fmt.Fprintf(w, "  - %s\n", file)
+				continue
+			}
+			// Point at the unterminated last line so editors can jump there
+			location := file
+			if line := result.LastLines[file]; line > 0 {
+				location = fmt.Sprintf("%s:%d", file, line)
+			}
+			if slices.Contains(result.NoLineTerminators, file) {
+				fmt.Fprintf(w, "  - %s (no line terminators)\n", location)
+				continue
+			}
+			fmt.Fprintf(w, "  - %s\n", location)
+		}
+		writeMore(w, more)
+		fmt.Fprintln(w, "\nRun with -fix flag to automatically add newlines")
+	} else {
+		fmt.Fprintln(w, "All files end with newline!")
+	}
+
+	if opts.ReportClean && len(result.Clean) > 0 {
+		fmt.Fprintf(w, "\nFiles that end with newline: %d\n", len(result.Clean))
+		for _, file := range result.Clean {
+			fmt.Fprintf(w, "  - %s\n", file)
+		}
+	}
+}
+
+// limitList returns the first limit files, or all of them when limit is
+// 0, and how many were left out
+func limitList(files []string, limit int) ([]string, int) {
+	if limit == 0 || len(files) <= limit {
+		return files, 0
+	}
+	return files[:limit], len(files) - limit
+}
+
+// writeMore notes the files a limited list left out
+func writeMore(w io.Writer, more int) {
+	if more > 0 {
+		fmt.Fprintf(w, "  ... and %d more\n", more)
+	}
+}
+
+// writeTailList writes the count and paths of one end-of-file category,
+// noting whether the files were fixed
+func writeTailList(w io.Writer, title string, files []string, fixed bool) {
+	if len(files) == 0 {
+		return
+	}
+
+	note := ""
+	if fixed {
+		note = " (fixed)"
+	}
+	fmt.Fprintf(w, "%s: %d%s\n", title, len(files), note)
+	for _, file := range files {
+		fmt.Fprintf(w, "  - %s\n", file)
+	}
+}
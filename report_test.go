@@ -0,0 +1,570 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestIsValidFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		expected bool
+	}{
+		{name: "未指定", format: "", expected: true},
+		{name: "テキスト", format: "text", expected: true},
+		{name: "JSON", format: "json", expected: true},
+		{name: "SARIF", format: "sarif", expected: true},
+		{name: "CSV", format: "csv", expected: true},
+		{name: "TAP", format: "tap", expected: true},
+		{name: "一覧", format: "list", expected: true},
+		{name: "未対応の形式", format: "xml", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := isValidFormat(tt.format); result != tt.expected {
+				t.Errorf("isValidFormat(%q) = %v, expected %v", tt.format, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWriteJSONReport(t *testing.T) {
+	result := &RepoResult{
+		Mode:        modeCheck,
+		Total:       2,
+		Skipped:     1,
+		Fixed:       []string{},
+		Problematic: []string{"a.txt"},
+		Errors:      []FileError{},
+	}
+
+	var buf bytes.Buffer
+	if err := writeJSONReport(&buf, result); err != nil {
+		t.Fatalf("writeJSONReport()でエラーが発生: %v", err)
+	}
+
+	var decoded RepoResult
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("JSONのデコードに失敗: %v", err)
+	}
+
+	if decoded.Total != 2 || decoded.Skipped != 1 {
+		t.Errorf("件数が期待値と異なります: total=%d, skipped=%d", decoded.Total, decoded.Skipped)
+	}
+	if len(decoded.Problematic) != 1 || decoded.Problematic[0] != "a.txt" {
+		t.Errorf("problematicが期待値と異なります: %v", decoded.Problematic)
+	}
+}
+
+func TestProcessRepositoryReportFile(t *testing.T) {
+	tempDir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(tempDir, "file.txt"), []byte("no newline"), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+
+	// 存在しない親ディレクトリを含むパスに出力
+	reportPath := filepath.Join(t.TempDir(), "out", "nested", "results.json")
+
+	opts := Options{Format: formatJSON, ReportFile: reportPath}
+	result, err := processRepository(tempDir, opts)
+	if err != nil {
+		t.Fatalf("processRepository()でエラーが発生: %v", err)
+	}
+	if err := writeResult(result, opts); err != nil {
+		t.Fatalf("writeResult()でエラーが発生: %v", err)
+	}
+
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatalf("レポートファイルの読み込みに失敗: %v", err)
+	}
+
+	var decoded RepoResult
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("レポートファイルがJSONとして不正です: %v", err)
+	}
+
+	if len(decoded.Problematic) != 1 || decoded.Problematic[0] != "file.txt" {
+		t.Errorf("problematicが期待値と異なります: %v", decoded.Problematic)
+	}
+}
+
+func TestOutputEncoding(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte("no newline"), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		encoding string
+		expected string
+	}{
+		{name: "デフォルト", encoding: "", expected: "a.txt\nb.txt\n"},
+		{name: "LF", encoding: outputLF, expected: "a.txt\nb.txt\n"},
+		{name: "CRLF", encoding: outputCRLF, expected: "a.txt\r\nb.txt\r\n"},
+		{name: "LFとBOM", encoding: outputLFBOM, expected: "\xEF\xBB\xBFa.txt\nb.txt\n"},
+		{name: "CRLFとBOM", encoding: outputCRLFBOM, expected: "\xEF\xBB\xBFa.txt\r\nb.txt\r\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reportPath := filepath.Join(t.TempDir(), "report.txt")
+			opts := Options{Format: formatList, ReportFile: reportPath, ReportFormat: formatList, OutputEncoding: tt.encoding}
+			result, err := processRepository(tempDir, opts)
+			if err != nil {
+				t.Fatalf("processRepository()でエラーが発生: %v", err)
+			}
+			if err := writeReportFile(reportPath, result, opts); err != nil {
+				t.Fatalf("writeReportFile()でエラーが発生: %v", err)
+			}
+
+			data, err := os.ReadFile(reportPath)
+			if err != nil {
+				t.Fatalf("レポートファイルの読み込みに失敗: %v", err)
+			}
+			if string(data) != tt.expected {
+				t.Errorf("レポートファイルの内容 = %q, expected %q", data, tt.expected)
+			}
+
+			// チェック対象のファイルは変更されない
+			content, err := os.ReadFile(filepath.Join(tempDir, "a.txt"))
+			if err != nil {
+				t.Fatalf("ファイルの読み込みに失敗: %v", err)
+			}
+			if string(content) != "no newline" {
+				t.Errorf("チェック対象のファイルが変更されました: %q", content)
+			}
+		})
+	}
+}
+
+// runJSONReport runs processRepository, writes its result as a JSON
+// report file and decodes it
+func runJSONReport(t *testing.T, root string, opts Options) RepoResult {
+	t.Helper()
+
+	opts.Format = formatJSON
+	opts.ReportFile = filepath.Join(t.TempDir(), "report.json")
+	processed, err := processRepository(root, opts)
+	if err != nil {
+		t.Fatalf("processRepository()でエラーが発生: %v", err)
+	}
+	if err := writeResult(processed, opts); err != nil {
+		t.Fatalf("writeResult()でエラーが発生: %v", err)
+	}
+
+	data, err := os.ReadFile(opts.ReportFile)
+	if err != nil {
+		t.Fatalf("レポートファイルの読み込みに失敗: %v", err)
+	}
+
+	var result RepoResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatalf("レポートファイルがJSONとして不正です: %v", err)
+	}
+
+	return result
+}
+
+func TestWriteListReport(t *testing.T) {
+	result := &RepoResult{
+		Total:       3,
+		Problematic: []string{"a.txt", "sub/b.txt"},
+		Errors:      []FileError{{Path: "c.txt", Err: "boom"}},
+	}
+
+	var buf bytes.Buffer
+	if err := writeReport(&buf, result, Options{Format: formatList}); err != nil {
+		t.Fatalf("writeReport()でエラーが発生: %v", err)
+	}
+	if expected := "a.txt\nsub/b.txt\n"; buf.String() != expected {
+		t.Errorf("出力が期待値と異なります: got %q, expected %q", buf.String(), expected)
+	}
+}
+
+func TestWriteTextSummaryMaxReport(t *testing.T) {
+	result := &RepoResult{
+		Mode:        modeCheck,
+		Total:       4,
+		Problematic: []string{"a.txt", "b.txt", "c.txt"},
+	}
+
+	tests := []struct {
+		name     string
+		max      int
+		expected []string
+		absent   []string
+	}{
+		{name: "制限なし", max: 0, expected: []string{"- a.txt", "- c.txt", "Files missing newline: 3"}, absent: []string{"more"}},
+		{name: "2件まで", max: 2, expected: []string{"- a.txt", "- b.txt", "... and 1 more", "Files missing newline: 3"}, absent: []string{"- c.txt"}},
+		{name: "件数以上", max: 3, expected: []string{"- c.txt"}, absent: []string{"more"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			writeTextSummary(&buf, result, Options{MaxReport: tt.max})
+			output := buf.String()
+
+			for _, want := range tt.expected {
+				if !strings.Contains(output, want) {
+					t.Errorf("出力に %q が含まれていません: %q", want, output)
+				}
+			}
+			for _, unwanted := range tt.absent {
+				if strings.Contains(output, unwanted) {
+					t.Errorf("出力に %q が含まれています: %q", unwanted, output)
+				}
+			}
+		})
+	}
+
+	if code := exitCode(result, Options{MaxReport: 1}); code != 1 {
+		t.Errorf("終了コードが期待値と異なります: got %d, expected 1", code)
+	}
+}
+
+// captureStderr returns what fn writes to os.Stderr
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("パイプの作成に失敗: %v", err)
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	fn()
+	w.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("標準エラー出力の読み込みに失敗: %v", err)
+	}
+	return string(data)
+}
+
+func TestSummaryStream(t *testing.T) {
+	result := &RepoResult{
+		Mode:        modeCheck,
+		Total:       2,
+		Problematic: []string{"a.txt"},
+		Errors:      []FileError{{Path: "b.txt", Err: "boom"}},
+	}
+
+	tests := []struct {
+		name           string
+		opts           Options
+		stdout         []string
+		stderr         []string
+		absentInStdout string
+	}{
+		{name: "テキストで標準出力", opts: Options{SummaryStream: streamStdout}, stdout: []string{"Error processing b.txt", "=== Summary ==="}},
+		{name: "テキストで標準エラー出力", opts: Options{SummaryStream: streamStderr}, stdout: []string{"Error processing b.txt"}, stderr: []string{"=== Summary ===", "- a.txt"}, absentInStdout: "Summary"},
+		{name: "一覧で標準出力", opts: Options{Format: formatList, SummaryStream: streamStdout}, stdout: []string{"a.txt\n"}, absentInStdout: "Summary"},
+		{name: "一覧で標準エラー出力", opts: Options{Format: formatList, SummaryStream: streamStderr}, stdout: []string{"a.txt\n"}, stderr: []string{"=== Summary ==="}, absentInStdout: "Summary"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			stderr := captureStderr(t, func() {
+				if err := writeReport(&buf, result, tt.opts); err != nil {
+					t.Errorf("writeReport()でエラーが発生: %v", err)
+				}
+			})
+
+			for _, want := range tt.stdout {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("標準出力に %q が含まれていません: %q", want, buf.String())
+				}
+			}
+			for _, want := range tt.stderr {
+				if !strings.Contains(stderr, want) {
+					t.Errorf("標準エラー出力に %q が含まれていません: %q", want, stderr)
+				}
+			}
+			if len(tt.stderr) == 0 && stderr != "" {
+				t.Errorf("標準エラー出力に書き込まれました: %q", stderr)
+			}
+			if tt.absentInStdout != "" && strings.Contains(buf.String(), tt.absentInStdout) {
+				t.Errorf("標準出力に %q が含まれています: %q", tt.absentInStdout, buf.String())
+			}
+		})
+	}
+}
+
+func TestWriteTextReport(t *testing.T) {
+	result := &RepoResult{
+		Mode:   modeFix,
+		Total:  1,
+		Fixed:  []string{"a.txt"},
+		Errors: []FileError{{Path: "b.txt", Err: "boom"}},
+	}
+
+	var buf bytes.Buffer
+	writeTextReport(&buf, result, Options{})
+	output := buf.String()
+
+	for _, want := range []string{"Fixed: a.txt", "Error processing b.txt: boom", "Files fixed: 1"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("出力に %q が含まれていません: %q", want, output)
+		}
+	}
+}
+
+func TestWriteTextSummaryVerbose(t *testing.T) {
+	result := &RepoResult{
+		Mode:              modeCheck,
+		Total:             2,
+		Problematic:       []string{"a.min.js", "b.txt"},
+		NoLineTerminators: []string{"a.min.js"},
+		LastLines:         map[string]int{"a.min.js": 1, "b.txt": 12},
+	}
+
+	var buf bytes.Buffer
+	writeTextSummary(&buf, result, Options{Verbose: true})
+	output := buf.String()
+
+	for _, want := range []string{"Files with no line terminators: 1", "  - a.min.js:1 (no line terminators)\n", "  - b.txt:12\n"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("出力に %q が含まれていません: %q", want, output)
+		}
+	}
+}
+
+func TestCompliancePercent(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   RepoResult
+		expected float64
+	}{
+		{name: "ファイルなし", result: RepoResult{}, expected: 100},
+		{name: "すべて改行あり", result: RepoResult{Total: 4}, expected: 100},
+		{name: "改行なしが1件", result: RepoResult{Total: 8, nonCompliant: 1}, expected: 87.5},
+		{name: "端数は丸める", result: RepoResult{Total: 3, nonCompliant: 1}, expected: 66.7},
+		{name: "エラーは除く", result: RepoResult{Total: 5, nonCompliant: 1, Errors: []FileError{{Path: "b.txt"}}}, expected: 75},
+		{name: "すべてエラー", result: RepoResult{Total: 1, Errors: []FileError{{Path: "b.txt"}}}, expected: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.result.compliancePercent(); got != tt.expected {
+				t.Errorf("compliancePercent() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestJSONReportCompliance(t *testing.T) {
+	tempDir := t.TempDir()
+
+	files := map[string]string{"a.txt": "ok\n", "b.txt": "ok\n", "c.txt": "ok\n", "d.txt": "missing"}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	result := runJSONReport(t, tempDir, Options{})
+	if result.Compliance != 75 {
+		t.Errorf("compliance_percentが期待値と異なります: got %v, expected 75", result.Compliance)
+	}
+}
+
+func TestJSONReportComplianceSameInFixMode(t *testing.T) {
+	files := map[string]string{
+		"a.txt": "ok\n",
+		"b.txt": "missing",
+		"c.txt": "space  \n",
+		"d.txt": "blank\n\n\n",
+	}
+
+	// 末尾の空白や空行だけの修正は準拠率に影響しない
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{name: "チェックモード", opts: Options{}},
+		{name: "修正モード", opts: Options{Fix: true}},
+		{name: "末尾も修正", opts: Options{Fix: true, FixWhitespace: true, FixBlankLines: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			for name, content := range files {
+				if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0o644); err != nil {
+					t.Fatalf("テストファイルの作成に失敗: %v", err)
+				}
+			}
+
+			result := runJSONReport(t, tempDir, tt.opts)
+			if result.Compliance != 75 {
+				t.Errorf("compliance_percentが期待値と異なります: got %v, expected 75", result.Compliance)
+			}
+		})
+	}
+}
+
+func TestProcessRepositoryDoesNotWrite(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "file.txt"), []byte("no newline"), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+
+	// 結果の出力は呼び出し側が行う
+	reportPath := filepath.Join(t.TempDir(), "report.json")
+	result, err := processRepository(tempDir, Options{Format: formatJSON, ReportFile: reportPath})
+	if err != nil {
+		t.Fatalf("processRepository()でエラーが発生: %v", err)
+	}
+	if _, err := os.Stat(reportPath); !os.IsNotExist(err) {
+		t.Errorf("processRepository()がレポートファイルを書き出しました: %v", err)
+	}
+
+	if result.Total != 1 || len(result.Problematic) != 1 || len(result.Files) != 1 {
+		t.Errorf("結果が期待値と異なります: %+v", result)
+	}
+}
+
+func TestReportClean(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     Options
+		expected []string
+	}{
+		{name: "デフォルトでは無効", opts: Options{}, expected: nil},
+		{name: "チェックモード", opts: Options{ReportClean: true}, expected: []string{"a.txt", "c.txt"}},
+		{name: "修正モードでは無効", opts: Options{ReportClean: true, Fix: true}, expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			files := map[string]string{"a.txt": "ok\n", "b.txt": "missing", "c.txt": "ok\n"}
+			for name, content := range files {
+				if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0o644); err != nil {
+					t.Fatalf("テストファイルの作成に失敗: %v", err)
+				}
+			}
+
+			result, err := processRepository(tempDir, tt.opts)
+			if err != nil {
+				t.Fatalf("processRepository()でエラーが発生: %v", err)
+			}
+			if !slices.Equal(result.Clean, tt.expected) {
+				t.Errorf("cleanが期待値と異なります: got %v, expected %v", result.Clean, tt.expected)
+			}
+
+			var buf bytes.Buffer
+			writeTextSummary(&buf, result, tt.opts)
+			listed := strings.Contains(buf.String(), "Files that end with newline: 2")
+			if listed != (tt.expected != nil) {
+				t.Errorf("準拠ファイル一覧の出力が期待値と異なります:\n%s", buf.String())
+			}
+		})
+	}
+}
+
+func TestSummaryTemplate(t *testing.T) {
+	result := &RepoResult{Total: 3, Problematic: []string{"a.txt"}}
+
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+		wantErr  bool
+	}{
+		{name: "件数", text: "{{.Total}} checked, {{len .Problematic}} bad", expected: "3 checked, 1 bad\n"},
+		{name: "末尾の改行を維持", text: "{{.Total}}\n", expected: "3\n"},
+		{name: "一覧", text: "{{range .Problematic}}bad: {{.}}\n{{end}}", expected: "bad: a.txt\n"},
+		{name: "構文エラー", text: "{{.Total", wantErr: true},
+		{name: "未知のフィールド", text: "{{.Missing}}", wantErr: true},
+		{name: "実行時のエラー", text: "{{range .Problematic}}{{.Path}}{{end}}", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := parseSummaryTemplate(tt.text)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("エラーが期待されましたが発生しませんでした")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseSummaryTemplate()でエラーが発生: %v", err)
+			}
+
+			var buf bytes.Buffer
+			stderr := captureStderr(t, func() {
+				writeTextSummary(&buf, result, Options{SummaryTemplate: tmpl})
+			})
+			if buf.String() != tt.expected {
+				t.Errorf("サマリーが期待値と異なります: got %q, expected %q", buf.String(), tt.expected)
+			}
+			// 実行時のエラーは標準エラー出力に書く
+			if failed := strings.Contains(stderr, "Error executing -summary-template"); failed != (tt.expected == "") {
+				t.Errorf("標準エラー出力が期待値と異なります: %q", stderr)
+			}
+		})
+	}
+}
+
+func TestCountLinesAdded(t *testing.T) {
+	files := map[string]string{
+		"ok.txt":      "ok\n",
+		"missing.txt": "missing",
+		"crlf.txt":    "crlf\r\n",
+		"extra.txt":   "extra\n\n\n",
+		"blank.txt":   "blank\n\n",
+	}
+
+	tests := []struct {
+		name    string
+		opts    Options
+		changed int
+		added   int
+	}{
+		{name: "無効", opts: Options{}, changed: 0, added: 0},
+		{name: "チェックモード", opts: Options{CountLinesAdded: true}, changed: 1, added: 1},
+		{name: "修正モード", opts: Options{CountLinesAdded: true, Fix: true}, changed: 1, added: 1},
+		{name: "改行コードを強制", opts: Options{CountLinesAdded: true, Fix: true, EOL: eolLF}, changed: 2, added: 2},
+		{name: "連続する改行を削除", opts: Options{CountLinesAdded: true, Fix: true, SingleFinalNewline: true}, changed: 3, added: 1},
+		{name: "空行を削除", opts: Options{CountLinesAdded: true, FixBlankLines: true}, changed: 3, added: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			for name, content := range files {
+				if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0o644); err != nil {
+					t.Fatalf("テストファイルの作成に失敗: %v", err)
+				}
+			}
+
+			result, err := processRepository(tempDir, tt.opts)
+			if err != nil {
+				t.Fatalf("processRepository()でエラーが発生: %v", err)
+			}
+			if result.FilesChanged != tt.changed || result.LinesAdded != tt.added {
+				t.Errorf("差分の集計が期待値と異なります: got files %d, lines %d, expected files %d, lines %d",
+					result.FilesChanged, result.LinesAdded, tt.changed, tt.added)
+			}
+		})
+	}
+}
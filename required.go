@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// parseRequired splits a -require list such as "LICENSE,README.md" into
+// clean slash-separated paths relative to the checked directory
+func parseRequired(list string) ([]string, error) {
+	var required []string
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name := path.Clean(filepath.ToSlash(entry))
+		if path.IsAbs(name) || filepath.IsAbs(entry) || name == ".." || strings.HasPrefix(name, "../") || name == "." {
+			return nil, fmt.Errorf("invalid -require entry %q: must be a file path relative to the checked directory", entry)
+		}
+		required = append(required, name)
+	}
+	return required, nil
+}
+
+// checkRequired records the Required files missing from the directory at
+// root, displaying them under prefix like the walked files. Files that
+// exist are checked by the walk itself.
+func checkRequired(root, prefix string, opts Options, result *RepoResult) {
+	for _, name := range opts.Required {
+		relPath := name
+		if prefix != "" {
+			relPath = prefix + "/" + name
+		}
+
+		path := filepath.Join(root, filepath.FromSlash(name))
+		_, err := os.Stat(path)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			result.rememberPath(relPath, path, opts)
+			result.MissingRequired = append(result.MissingRequired, relPath)
+		case err != nil:
+			result.addError(relPath, err)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParseRequired(t *testing.T) {
+	tests := []struct {
+		name      string
+		list      string
+		expected  []string
+		expectErr bool
+	}{
+		{name: "複数のファイル", list: "LICENSE, README.md", expected: []string{"LICENSE", "README.md"}},
+		{name: "サブディレクトリ", list: "./docs//index.md,", expected: []string{"docs/index.md"}},
+		{name: "親ディレクトリ", list: "../LICENSE", expectErr: true},
+		{name: "絶対パス", list: "/etc/passwd", expectErr: true},
+		{name: "カレントディレクトリ", list: ".", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			required, err := parseRequired(tt.list)
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("エラーが期待されましたが、nilが返されました: %v", required)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseRequired()でエラーが発生: %v", err)
+			}
+			if !slices.Equal(required, tt.expected) {
+				t.Errorf("parseRequired(%q) = %v, expected %v", tt.list, required, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRequiredFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("readme"), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+
+	opts := Options{Required: []string{"LICENSE", "README.md", "docs/index.md"}}
+	result, err := processRepository(dir, opts)
+	if err != nil {
+		t.Fatalf("processRepository()でエラーが発生: %v", err)
+	}
+
+	// 存在するファイルは通常どおり改行をチェックする
+	if expected := []string{"LICENSE", "docs/index.md"}; !slices.Equal(result.MissingRequired, expected) {
+		t.Errorf("missing_requiredが期待値と異なります: got %v, expected %v", result.MissingRequired, expected)
+	}
+	if expected := []string{"README.md"}; !slices.Equal(result.Problematic, expected) {
+		t.Errorf("problematicが期待値と異なります: got %v, expected %v", result.Problematic, expected)
+	}
+
+	if code := exitCode(result, opts); code != exitMissingRequired {
+		t.Errorf("終了コード = %d, expected %d", code, exitMissingRequired)
+	}
+	opts.DetailedExit = true
+	if code, expected := exitCode(result, opts), exitDetailedBase+exitMissingBit+exitRequiredBit; code != expected {
+		t.Errorf("詳細な終了コード = %d, expected %d", code, expected)
+	}
+}
+
+func TestRequiredFilesPerRoot(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Chdir(tempDir)
+	for _, dir := range []string{"a", "b"} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("ディレクトリの作成に失敗: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join("a", "LICENSE"), []byte("license\n"), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+
+	result, err := processPaths([]string{"a", "b"}, Options{Required: []string{"LICENSE"}})
+	if err != nil {
+		t.Fatalf("processPaths()でエラーが発生: %v", err)
+	}
+	if expected := []string{"b/LICENSE"}; !slices.Equal(result.MissingRequired, expected) {
+		t.Errorf("missing_requiredが期待値と異なります: got %v, expected %v", result.MissingRequired, expected)
+	}
+}
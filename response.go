@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// isResponseFile reports whether arg names an "@file" path list
+func isResponseFile(arg string) bool {
+	return strings.HasPrefix(arg, "@") && arg != "@"
+}
+
+// expandResponseFiles replaces each "@file" argument with the paths listed
+// in that file. Other arguments are kept as they are.
+func expandResponseFiles(args []string) ([]string, error) {
+	var expanded []string
+	for _, arg := range args {
+		if !isResponseFile(arg) {
+			expanded = append(expanded, arg)
+			continue
+		}
+
+		paths, err := readResponseFile(arg[1:])
+		if err != nil {
+			return nil, err
+		}
+		expanded = append(expanded, paths...)
+	}
+	return expanded, nil
+}
+
+// readResponseFile reads one path per line, ignoring blank lines and lines
+// starting with "#". Relative paths are resolved against the working
+// directory, as they would be on the command line.
+func readResponseFile(name string) ([]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open response file: %w", err)
+	}
+	defer f.Close()
+
+	var paths []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		paths = append(paths, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read response file %s: %w", name, err)
+	}
+
+	return paths, nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestExpandResponseFiles(t *testing.T) {
+	listFile := filepath.Join(t.TempDir(), "files.txt")
+	content := "# 対象ファイル\nsrc/a.go\n\n  docs/b.md  \n# コメント\nc.txt\n"
+	if err := os.WriteFile(listFile, []byte(content), 0o644); err != nil {
+		t.Fatalf("レスポンスファイルの作成に失敗: %v", err)
+	}
+
+	args, err := expandResponseFiles([]string{"first.txt", "@" + listFile, "last.txt"})
+	if err != nil {
+		t.Fatalf("expandResponseFiles()でエラーが発生: %v", err)
+	}
+
+	expected := []string{"first.txt", "src/a.go", "docs/b.md", "c.txt", "last.txt"}
+	if !slices.Equal(args, expected) {
+		t.Errorf("expandResponseFiles() = %v, expected %v", args, expected)
+	}
+}
+
+func TestExpandResponseFilesMissing(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.txt")
+	if _, err := expandResponseFiles([]string{"@" + missing}); err == nil {
+		t.Errorf("存在しないレスポンスファイルでエラーが発生しませんでした")
+	}
+}
+
+func TestRunResponseFile(t *testing.T) {
+	tempDir := t.TempDir()
+	for name, content := range map[string]string{"a.txt": "ok\n", "b.txt": "missing"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	listFile := filepath.Join(tempDir, "files.lst")
+	if err := os.WriteFile(listFile, []byte("a.txt\nb.txt\n"), 0o644); err != nil {
+		t.Fatalf("レスポンスファイルの作成に失敗: %v", err)
+	}
+	t.Chdir(tempDir)
+
+	cfg, err := parseArgs([]string{"-fix", "-report-file", filepath.Join(t.TempDir(), "report.txt"), "@files.lst"})
+	if err != nil {
+		t.Fatalf("parseArgs()でエラーが発生: %v", err)
+	}
+	if code := run(cfg); code != 0 {
+		t.Errorf("終了コードが期待値と異なります: got %d, expected 0", code)
+	}
+
+	data, err := os.ReadFile(filepath.Join(tempDir, "b.txt"))
+	if err != nil {
+		t.Fatalf("ファイルの読み込みに失敗: %v", err)
+	}
+	if string(data) != "missing\n" {
+		t.Errorf("レスポンスファイルで指定したファイルが修正されていません: %q", data)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// defaultRetries is how many times -retries retries a transient I/O error
+const defaultRetries = 2
+
+// retryBackoff is the delay before the first retry; it doubles after each
+// attempt. Tests shorten it.
+var retryBackoff = 50 * time.Millisecond
+
+// isTransient reports whether err may go away when the operation is
+// repeated, as network filesystems sometimes fail with EIO or EAGAIN.
+// Errors such as ENOENT and EACCES are permanent.
+func isTransient(err error) bool {
+	return errors.Is(err, syscall.EIO) || errors.Is(err, syscall.EAGAIN) || errors.Is(err, syscall.EINTR)
+}
+
+// withRetries runs op, repeating it up to retries more times with
+// exponential backoff while it fails with a transient error
+func withRetries(retries int, op func() error) error {
+	delay := retryBackoff
+	for attempt := 0; ; attempt++ {
+		err := op()
+		if err == nil || attempt >= retries || !isTransient(err) {
+			return err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// readFile reads path, retrying transient errors
+func readFile(path string, retries int) ([]byte, error) {
+	var data []byte
+	err := withRetries(retries, func() error {
+		var err error
+		data, err = os.ReadFile(path)
+		return err
+	})
+	return data, err
+}
+
+// writeFile replaces the content of path, retrying transient errors. The
+// data goes to a temporary file next to path that is renamed over it, so
+// a run abandoned by -timeout mid-write never leaves the file truncated.
+// A symlink is followed so that its target is replaced rather than the
+// link. A file with other hard links is written in place instead, since
+// the rename would detach it from them.
+func writeFile(path string, data []byte, mode os.FileMode, retries int) error {
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	}
+	return withRetries(retries, func() error {
+		info, err := os.Stat(path)
+		if err != nil {
+			return os.WriteFile(path, data, mode)
+		}
+		if _, linked := hardLinkID(info); linked {
+			return os.WriteFile(path, data, mode)
+		}
+		return replaceFile(path, data, mode, info)
+	})
+}
+
+// replaceFile atomically replaces path, described by info, with data. The
+// replacement gets the owner and group of the original; when they can't
+// be carried over, such as for another user's file, path is written in
+// place so that its ownership is kept. The temporary file is hidden, so
+// one left behind by a crash is not checked by later runs.
+func replaceFile(path string, data []byte, mode os.FileMode, info os.FileInfo) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	name := tmp.Name()
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(name, mode)
+	}
+	if err == nil && copyOwner(name, info) != nil {
+		os.Remove(name)
+		return os.WriteFile(path, data, mode)
+	}
+	if err == nil {
+		err = os.Rename(name, path)
+	}
+	if err != nil {
+		os.Remove(name)
+	}
+	return err
+}
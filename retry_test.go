@@ -0,0 +1,137 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestWithRetries(t *testing.T) {
+	original := retryBackoff
+	retryBackoff = time.Millisecond
+	t.Cleanup(func() { retryBackoff = original })
+
+	transient := &fs.PathError{Op: "read", Path: "file.txt", Err: syscall.EIO}
+
+	tests := []struct {
+		name    string
+		retries int
+		errs    []error
+		calls   int
+		wantErr bool
+	}{
+		{name: "成功", retries: 2, errs: []error{nil}, calls: 1},
+		{name: "一時的なエラーの後に成功", retries: 2, errs: []error{transient, fmt.Errorf("wrapped: %w", syscall.EAGAIN), nil}, calls: 3},
+		{name: "リトライ回数を超過", retries: 1, errs: []error{transient, transient, nil}, calls: 2, wantErr: true},
+		{name: "リトライなし", retries: 0, errs: []error{transient, nil}, calls: 1, wantErr: true},
+		{name: "存在しないファイルはリトライしない", retries: 2, errs: []error{os.ErrNotExist, nil}, calls: 1, wantErr: true},
+		{name: "権限エラーはリトライしない", retries: 2, errs: []error{&fs.PathError{Op: "open", Path: "x", Err: syscall.EACCES}, nil}, calls: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := withRetries(tt.retries, func() error {
+				err := tt.errs[calls]
+				calls++
+				return err
+			})
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("エラーが期待値と異なります: got %v, wantErr %v", err, tt.wantErr)
+			}
+			if calls != tt.calls {
+				t.Errorf("呼び出し回数が期待値と異なります: got %d, expected %d", calls, tt.calls)
+			}
+		})
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	if isTransient(errors.New("other")) {
+		t.Errorf("一般的なエラーが一時的と判定されました")
+	}
+	if !isTransient(&fs.PathError{Op: "write", Path: "x", Err: syscall.EIO}) {
+		t.Errorf("EIOが一時的と判定されませんでした")
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(path, []byte("old content that is longer"), 0o600); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+
+	if err := writeFile(path, []byte("new\n"), 0o640, 0); err != nil {
+		t.Fatalf("writeFile()でエラーが発生: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ファイルの読み込みに失敗: %v", err)
+	}
+	if string(data) != "new\n" {
+		t.Errorf("ファイルの内容が期待値と異なります: %q", data)
+	}
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("ファイル情報の取得に失敗: %v", err)
+		}
+		if info.Mode().Perm() != 0o640 {
+			t.Errorf("パーミッションが期待値と異なります: %o", info.Mode().Perm())
+		}
+	}
+
+	// 一時ファイルは残らない
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ディレクトリの読み込みに失敗: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("一時ファイルが残っています: %v", entries)
+	}
+}
+
+func TestWriteFileSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target.txt")
+	if err := os.WriteFile(target, []byte("no newline"), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+	link := filepath.Join(dir, "link.txt")
+	if err := os.Symlink("target.txt", link); err != nil {
+		t.Skipf("シンボリックリンクを作成できません: %v", err)
+	}
+
+	// 明示したリンクはリンク先を修正し、リンクはそのまま残す
+	result, err := processPaths([]string{link}, Options{Fix: true})
+	if err != nil {
+		t.Fatalf("processPaths()でエラーが発生: %v", err)
+	}
+	if len(result.Fixed) != 1 {
+		t.Errorf("修正したファイル数 = %d, expected 1", len(result.Fixed))
+	}
+
+	info, err := os.Lstat(link)
+	if err != nil {
+		t.Fatalf("リンクの情報の取得に失敗: %v", err)
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		t.Error("シンボリックリンクが通常のファイルに置き換えられました")
+	}
+	data, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatalf("ファイルの読み込みに失敗: %v", err)
+	}
+	if string(data) != "no newline\n" {
+		t.Errorf("リンク先の内容が期待値と異なります: %q", data)
+	}
+}
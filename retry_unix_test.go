@@ -0,0 +1,40 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestWriteFileKeepsOwner(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("所有者を変更するにはrootで実行する必要があります")
+	}
+
+	path := filepath.Join(t.TempDir(), "a.txt")
+	if err := os.WriteFile(path, []byte("no newline"), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+	if err := os.Chown(path, 1234, 5678); err != nil {
+		t.Fatalf("所有者の変更に失敗: %v", err)
+	}
+
+	if err := writeFile(path, []byte("no newline\n"), 0o644, 0); err != nil {
+		t.Fatalf("writeFile()でエラーが発生: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("ファイル情報の取得に失敗: %v", err)
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		t.Skip("所有者の情報を取得できません")
+	}
+	if stat.Uid != 1234 || stat.Gid != 5678 {
+		t.Errorf("所有者が引き継がれていません: uid=%d, gid=%d", stat.Uid, stat.Gid)
+	}
+}
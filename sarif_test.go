@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteSARIFReport(t *testing.T) {
+	result := &RepoResult{
+		Mode:   modeCheck,
+		Errors: []FileError{{Path: "d.txt", Err: "permission denied"}},
+		Files: []FileResult{
+			{Path: "a.txt", Status: statusOK},
+			{Path: "b.txt", Status: statusMissing, Reason: "missing final newline"},
+			{Path: "c.bin", Status: statusBinary, Reason: "content looks binary"},
+			{Path: "d.txt", Status: statusError, Reason: "permission denied"},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		opts  Options
+		level string
+	}{
+		{name: "通常", opts: Options{}, level: "error"},
+		{name: "警告のみ", opts: Options{WarnOnly: true}, level: "warning"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeSARIFReport(&buf, result, tt.opts); err != nil {
+				t.Fatalf("writeSARIFReport()でエラーが発生: %v", err)
+			}
+
+			var decoded sarifLog
+			if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+				t.Fatalf("SARIFのデコードに失敗: %v\n%s", err, buf.String())
+			}
+
+			if decoded.Version != sarifVersion || len(decoded.Runs) != 1 {
+				t.Fatalf("SARIFの構造が期待値と異なります: %+v", decoded)
+			}
+
+			run := decoded.Runs[0]
+			if len(run.Results) != 1 {
+				t.Fatalf("結果の件数が期待値と異なります: %+v", run.Results)
+			}
+			got := run.Results[0]
+			if got.RuleID != sarifRuleID || got.Level != tt.level || got.Locations[0].PhysicalLocation.ArtifactLocation.URI != "b.txt" {
+				t.Errorf("結果が期待値と異なります: %+v", got)
+			}
+
+			if len(run.Invocations) != 1 || run.Invocations[0].ExecutionSuccessful || len(run.Invocations[0].Notifications) != 1 {
+				t.Errorf("実行情報が期待値と異なります: %+v", run.Invocations)
+			}
+		})
+	}
+}
+
+func TestProcessRepositoryReportFormat(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "file.txt"), []byte("no newline"), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+
+	// 標準出力はテキスト、レポートファイルはSARIF
+	reportPath := filepath.Join(t.TempDir(), "report.sarif")
+	opts := Options{Format: formatText, ReportFile: reportPath, ReportFormat: formatSARIF}
+	result, err := processRepository(tempDir, opts)
+	if err != nil {
+		t.Fatalf("processRepository()でエラーが発生: %v", err)
+	}
+	if err := writeResult(result, opts); err != nil {
+		t.Fatalf("writeResult()でエラーが発生: %v", err)
+	}
+
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatalf("レポートファイルの読み込みに失敗: %v", err)
+	}
+
+	var decoded sarifLog
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("レポートファイルがSARIFとして不正です: %v", err)
+	}
+	if len(decoded.Runs) != 1 || len(decoded.Runs[0].Results) != 1 {
+		t.Errorf("SARIFの結果が期待値と異なります: %s", data)
+	}
+}
@@ -0,0 +1,13 @@
+package main
+
+// defaultSelfCheckDir is the directory -self-check verifies unless
+// -self-check-dir names another: the checkout the tool is run from, as in
+// the project's CI
+const defaultSelfCheckDir = "."
+
+// runSelfCheck checks the tool's own sources and testdata in
+// -self-check-dir, exiting non-zero when any file is non-compliant
+func runSelfCheck(cfg *cliConfig) int {
+	result, err := processRepository(cfg.selfCheckDir, cfg.opts)
+	return report(result, err, cfg.opts)
+}
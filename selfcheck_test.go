@@ -0,0 +1,35 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunSelfCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected int
+	}{
+		{name: "準拠している", content: "package main\n", expected: 0},
+		{name: "改行がない", content: "package main", expected: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(tt.content), 0o644); err != nil {
+				t.Fatalf("テストファイルの作成に失敗: %v", err)
+			}
+
+			cfg, err := parseArgs([]string{"-no-rc", "-self-check", "-self-check-dir", dir})
+			if err != nil {
+				t.Fatalf("parseArgs()でエラーが発生: %v", err)
+			}
+			if code := runSelfCheck(cfg); code != tt.expected {
+				t.Errorf("runSelfCheck() = %d, expected %d", code, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// sizeUnits maps human-readable size suffixes to their multiplier.
+// Decimal-looking suffixes are treated as binary units, as most tools do.
+var sizeUnits = map[string]int64{
+	"":    1,
+	"b":   1,
+	"k":   1 << 10,
+	"kb":  1 << 10,
+	"kib": 1 << 10,
+	"m":   1 << 20,
+	"mb":  1 << 20,
+	"mib": 1 << 20,
+	"g":   1 << 30,
+	"gb":  1 << 30,
+	"gib": 1 << 30,
+}
+
+// parseSize parses a byte count such as "512", "4K" or "1.5MB"
+func parseSize(s string) (int64, error) {
+	trimmed := strings.TrimSpace(s)
+	i := strings.IndexFunc(trimmed, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(trimmed)
+	}
+
+	unit, ok := sizeUnits[strings.ToLower(strings.TrimSpace(trimmed[i:]))]
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q: unknown unit", s)
+	}
+
+	value, err := strconv.ParseFloat(trimmed[:i], 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size %q: must be a non-negative number with an optional unit such as K, MB or GiB", s)
+	}
+
+	return int64(value * float64(unit)), nil
+}
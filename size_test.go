@@ -0,0 +1,39 @@
+package main
+
+import "testing"
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected int64
+		wantErr  bool
+	}{
+		{name: "単位なし", input: "512", expected: 512},
+		{name: "バイト", input: "16B", expected: 16},
+		{name: "キロバイト", input: "4K", expected: 4 << 10},
+		{name: "小数", input: "1.5MB", expected: 3 << 19},
+		{name: "大文字小文字と空白", input: " 2 gib ", expected: 2 << 30},
+		{name: "不明な単位", input: "3TB", wantErr: true},
+		{name: "数値なし", input: "KB", wantErr: true},
+		{name: "負の値", input: "-1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			size, err := parseSize(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseSize(%q)でエラーが発生しませんでした", tt.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseSize(%q)でエラーが発生: %v", tt.input, err)
+			}
+			if size != tt.expected {
+				t.Errorf("parseSize(%q) = %d, expected %d", tt.input, size, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// skipCommand delegates skip decisions to an external command, which is
+// run with the file path as its last argument. Exit status 0 skips the
+// file and 1 checks it; anything else is an error.
+type skipCommand struct {
+	args []string
+
+	mu      sync.Mutex
+	results map[string]bool
+}
+
+// newSkipCommand parses a command line such as "./should-skip.sh" or
+// "python3 filter.py --strict". Arguments are split on whitespace.
+func newSkipCommand(command string) (*skipCommand, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, errors.New("-skip-command must not be empty")
+	}
+	return &skipCommand{args: args, results: map[string]bool{}}, nil
+}
+
+// skip reports whether the command says path should be skipped. Answers
+// are cached per path for the rest of the run.
+func (c *skipCommand) skip(path string) (bool, error) {
+	c.mu.Lock()
+	skip, ok := c.results[path]
+	c.mu.Unlock()
+	if ok {
+		return skip, nil
+	}
+
+	cmd := exec.Command(c.args[0], append(c.args[1:], path)...)
+	err := cmd.Run()
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		skip = true
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		skip = false
+	case errors.As(err, &exitErr):
+		return false, fmt.Errorf("skip command exited with status %d", exitErr.ExitCode())
+	default:
+		return false, fmt.Errorf("failed to run skip command: %w", err)
+	}
+
+	c.mu.Lock()
+	c.results[path] = skip
+	c.mu.Unlock()
+	return skip, nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// writeSkipScript creates a shell script that skips paths containing
+// "skip", fails for paths containing "fail" and logs every call
+func writeSkipScript(t *testing.T) (script, log string) {
+	t.Helper()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("シェルスクリプトを実行できないプラットフォームです")
+	}
+
+	dir := t.TempDir()
+	script = filepath.Join(dir, "should-skip.sh")
+	log = filepath.Join(dir, "calls.log")
+	content := "#!/bin/sh\necho \"$1\" >> " + log + "\ncase \"$1\" in\n*skip*) exit 0 ;;\n*fail*) exit 3 ;;\nesac\nexit 1\n"
+	if err := os.WriteFile(script, []byte(content), 0o755); err != nil {
+		t.Fatalf("スクリプトの作成に失敗: %v", err)
+	}
+	return script, log
+}
+
+func TestSkipCommand(t *testing.T) {
+	script, log := writeSkipScript(t)
+
+	command, err := newSkipCommand(script)
+	if err != nil {
+		t.Fatalf("newSkipCommand()でエラーが発生: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected bool
+		wantErr  bool
+	}{
+		{name: "スキップ", path: "a-skip.txt", expected: true},
+		{name: "チェック", path: "b.txt", expected: false},
+		{name: "コマンドの失敗", path: "c-fail.txt", wantErr: true},
+		{name: "キャッシュ済み", path: "a-skip.txt", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			skip, err := command.skip(tt.path)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("skip(%q)でエラーが発生しませんでした", tt.path)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("skip(%q)でエラーが発生: %v", tt.path, err)
+			}
+			if skip != tt.expected {
+				t.Errorf("skip(%q) = %v, expected %v", tt.path, skip, tt.expected)
+			}
+		})
+	}
+
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatalf("ログの読み込みに失敗: %v", err)
+	}
+	if calls := strings.Count(string(data), "a-skip.txt"); calls != 1 {
+		t.Errorf("同じパスでコマンドが%d回実行されました", calls)
+	}
+}
+
+func TestNewSkipCommandErrors(t *testing.T) {
+	if _, err := newSkipCommand("  "); err == nil {
+		t.Errorf("空のコマンドでエラーが発生しませんでした")
+	}
+
+	command, err := newSkipCommand(filepath.Join(t.TempDir(), "missing"))
+	if err != nil {
+		t.Fatalf("newSkipCommand()でエラーが発生: %v", err)
+	}
+	if _, err := command.skip("a.txt"); err == nil {
+		t.Errorf("存在しないコマンドでエラーが発生しませんでした")
+	}
+}
+
+func TestProcessRepositorySkipCommand(t *testing.T) {
+	script, _ := writeSkipScript(t)
+	tempDir := t.TempDir()
+
+	for _, name := range []string{"keep.txt", "to-skip.txt", "will-fail.txt"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte("no newline"), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	command, err := newSkipCommand(script)
+	if err != nil {
+		t.Fatalf("newSkipCommand()でエラーが発生: %v", err)
+	}
+
+	opts := Options{SkipCommand: command, ReportFile: filepath.Join(t.TempDir(), "report.txt")}
+	result, err := processRepository(tempDir, opts)
+	if err != nil {
+		t.Fatalf("processRepository()でエラーが発生: %v", err)
+	}
+
+	if result.Skipped != 1 || len(result.Errors) != 1 || result.Errors[0].Path != "will-fail.txt" {
+		t.Errorf("件数が期待値と異なります: skipped=%d, errors=%v", result.Skipped, result.Errors)
+	}
+	if len(result.Problematic) != 1 || result.Problematic[0] != "keep.txt" {
+		t.Errorf("problematicが期待値と異なります: %v", result.Problematic)
+	}
+}
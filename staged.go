@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// stagedFile is a file added or modified in the git index
+type stagedFile struct {
+	mode string
+	path string
+}
+
+// git runs a git command in dir, returning its stdout. Stderr is included
+// in the error so that git's own message reaches the user.
+func git(dir string, stdin []byte, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return out, nil
+}
+
+// gitTopLevel returns the root of the work tree containing dir
+func gitTopLevel(dir string) (string, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return "", errors.New("-staged requires git to be installed")
+	}
+
+	out, err := git(dir, nil, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", fmt.Errorf("-staged requires a git repository: %s is not inside a work tree", dir)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// stagedFiles lists the files whose staged content differs from HEAD,
+// ignoring deletions
+func stagedFiles(top string) ([]stagedFile, error) {
+	out, err := git(top, nil, "diff", "--cached", "--raw", "-z", "--no-renames", "--diff-filter=ACMT")
+	if err != nil {
+		return nil, err
+	}
+
+	// Each entry is ":<old mode> <new mode> <old sha> <new sha> <status>"
+	// followed by the path, NUL separated
+	var files []stagedFile
+	fields := strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00")
+	for i := 0; i+1 < len(fields); i += 2 {
+		meta := strings.Fields(fields[i])
+		if len(meta) < 2 {
+			return nil, fmt.Errorf("unexpected git diff output %q", fields[i])
+		}
+
+		// Skip symlinks and submodules, whose blobs are not file content
+		mode := meta[1]
+		if mode != "100644" && mode != "100755" {
+			continue
+		}
+		files = append(files, stagedFile{mode: mode, path: fields[i+1]})
+	}
+	return files, nil
+}
+
+// processStaged checks the staged content of files in the git index
+// rather than the working tree. In fix mode the fixed content is staged,
+// and the working tree file is updated too when it matched the index.
+func processStaged(dir string, opts Options) (*RepoResult, error) {
+	top, err := gitTopLevel(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := stagedFiles(top)
+	if err != nil {
+		return nil, err
+	}
+
+	result := newRepoResult(opts)
+	for _, file := range files {
+		processStagedFile(top, file, opts, result)
+		if err := result.stopReason(opts); err != nil {
+			return partialResult(result, err, opts)
+		}
+	}
+
+	result.finish(opts)
+
+	return result, nil
+}
+
+// processStagedFile checks one staged blob, recording the outcome under
+// its repository-relative path
+func processStagedFile(top string, file stagedFile, opts Options, result *RepoResult) {
+	relPath := file.path
+	defer result.timeFile(relPath, opts)()
+	path := filepath.Join(top, filepath.FromSlash(relPath))
+	result.rememberPath(relPath, path, opts)
+
+	if !applyGitAttributes(path, relPath, &opts, result) {
+		return
+	}
+	applyExtEOL(relPath, &opts)
+
+	if reason := opts.pathSkipReason(relPath); reason != "" {
+		result.addSkipped(relPath, reason)
+		return
+	}
+
+	if !applySkipCommand(path, relPath, opts, result) {
+		return
+	}
+
+	data, err := git(top, nil, "show", ":"+relPath)
+	if err != nil {
+		result.addError(relPath, err)
+		return
+	}
+
+	check, fixed := checkBytes(data, opts)
+	if check.generated {
+		result.addSkipped(relPath, "generated-code marker")
+		result.Generated = append(result.Generated, relPath)
+		return
+	}
+	if check.skippedContent {
+		result.addSkipped(relPath, skipContentReason)
+		return
+	}
+
+	if check.changes(opts) && !opts.confirmFix(path, data) {
+		check.declined = true
+	}
+	if check.changes(opts) {
+		if err := restage(top, file, data, fixedContent(data, fixed, check, opts), opts); err != nil {
+			result.addError(relPath, err)
+			return
+		}
+	}
+
+	check.size = int64(len(data))
+	recordCheck(relPath, check, opts, result)
+}
+
+// restage writes fixed into the index in place of the staged blob. The
+// working tree copy is only rewritten when it still matches what was
+// staged, so unstaged edits are never lost.
+func restage(top string, file stagedFile, staged, fixed []byte, opts Options) error {
+	out, err := git(top, fixed, "hash-object", "-w", "--stdin", "--no-filters")
+	if err != nil {
+		return err
+	}
+	sha := strings.TrimSpace(string(out))
+
+	if _, err := git(top, nil, "update-index", "--cacheinfo", file.mode+","+sha+","+file.path); err != nil {
+		return err
+	}
+
+	path := filepath.Join(top, filepath.FromSlash(file.path))
+	current, err := os.ReadFile(path)
+	if err != nil || !bytes.Equal(current, staged) {
+		return nil
+	}
+
+	if err := writeFile(path, fixed, fileModeFor(path, opts), opts.Retries); err != nil {
+		return &WriteError{Path: path, Err: err}
+	}
+	return nil
+}
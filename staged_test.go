@@ -0,0 +1,130 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// initGitRepo creates a git repository in a temporary directory
+func initGitRepo(t *testing.T) string {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("gitがインストールされていません")
+	}
+
+	dir := t.TempDir()
+	if _, err := git(dir, nil, "init", "-q"); err != nil {
+		t.Fatalf("git initに失敗: %v", err)
+	}
+	return dir
+}
+
+// stageFile writes content to name in dir and stages it
+func stageFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+	if _, err := git(dir, nil, "add", name); err != nil {
+		t.Fatalf("git addに失敗: %v", err)
+	}
+}
+
+func TestProcessStagedUsesIndexContent(t *testing.T) {
+	dir := initGitRepo(t)
+
+	// ステージ済みの内容は改行なし、作業ツリーは改行あり
+	stageFile(t, dir, "staged.txt", "no newline")
+	if err := os.WriteFile(filepath.Join(dir, "staged.txt"), []byte("no newline\n"), 0o644); err != nil {
+		t.Fatalf("テストファイルの更新に失敗: %v", err)
+	}
+	stageFile(t, dir, "ok.txt", "ok\n")
+
+	// ステージされていないファイルは対象外
+	if err := os.WriteFile(filepath.Join(dir, "untracked.txt"), []byte("untracked"), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+
+	result, err := processStaged(dir, Options{ReportFile: filepath.Join(t.TempDir(), "report.txt")})
+	if err != nil {
+		t.Fatalf("processStaged()でエラーが発生: %v", err)
+	}
+
+	if result.Total != 2 {
+		t.Errorf("チェック数が期待値と異なります: got %d, expected 2", result.Total)
+	}
+	if !slices.Equal(result.Problematic, []string{"staged.txt"}) {
+		t.Errorf("problematicが期待値と異なります: %v", result.Problematic)
+	}
+}
+
+func TestProcessStagedFixRestages(t *testing.T) {
+	dir := initGitRepo(t)
+
+	// 作業ツリーがステージ内容と同じファイル
+	stageFile(t, dir, "clean.txt", "clean")
+
+	// ステージ後に作業ツリーを編集したファイル
+	stageFile(t, dir, "edited.txt", "staged")
+	if err := os.WriteFile(filepath.Join(dir, "edited.txt"), []byte("unstaged edit"), 0o644); err != nil {
+		t.Fatalf("テストファイルの更新に失敗: %v", err)
+	}
+
+	opts := Options{Fix: true, ReportFile: filepath.Join(t.TempDir(), "report.txt")}
+	result, err := processStaged(dir, opts)
+	if err != nil {
+		t.Fatalf("processStaged()でエラーが発生: %v", err)
+	}
+	if !slices.Equal(result.Fixed, []string{"clean.txt", "edited.txt"}) {
+		t.Errorf("fixedが期待値と異なります: %v", result.Fixed)
+	}
+
+	tests := []struct {
+		name     string
+		index    string
+		worktree string
+	}{
+		{name: "clean.txt", index: "clean\n", worktree: "clean\n"},
+		{name: "edited.txt", index: "staged\n", worktree: "unstaged edit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			staged, err := git(dir, nil, "show", ":"+tt.name)
+			if err != nil {
+				t.Fatalf("git showに失敗: %v", err)
+			}
+			if string(staged) != tt.index {
+				t.Errorf("ステージ内容が期待値と異なります: got %q, expected %q", staged, tt.index)
+			}
+
+			data, err := os.ReadFile(filepath.Join(dir, tt.name))
+			if err != nil {
+				t.Fatalf("ファイルの読み込みに失敗: %v", err)
+			}
+			if string(data) != tt.worktree {
+				t.Errorf("作業ツリーの内容が期待値と異なります: got %q, expected %q", data, tt.worktree)
+			}
+		})
+	}
+}
+
+func TestProcessStagedOutsideRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("gitがインストールされていません")
+	}
+
+	dir := t.TempDir()
+	t.Setenv("GIT_CEILING_DIRECTORIES", filepath.Dir(dir))
+
+	_, err := processStaged(dir, Options{})
+	if err == nil || !strings.Contains(err.Error(), "requires a git repository") {
+		t.Errorf("gitリポジトリ外でのエラーが期待値と異なります: %v", err)
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Exit codes for -stdin-fix
+const (
+	stdinUnchanged = 0
+	stdinChanged   = 1
+	stdinFailed    = 2
+)
+
+// runStdinFix reads all of r, writes the fixed content to w and returns
+// the exit code, which tells whether the content had to be changed
+func runStdinFix(r io.Reader, w io.Writer, opts Options) int {
+	changed, err := stdinFix(r, w, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return stdinFailed
+	}
+
+	if changed {
+		return stdinChanged
+	}
+	return stdinUnchanged
+}
+
+// stdinFix copies r to w, applying the same fix as checkAndFixFile
+func stdinFix(r io.Reader, w io.Writer, opts Options) (bool, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return false, fmt.Errorf("failed to read stdin: %w", err)
+	}
+
+	check, fixed := checkBytes(data, opts)
+
+	if _, err := w.Write(fixed); err != nil {
+		return false, fmt.Errorf("failed to write stdout: %w", err)
+	}
+
+	return !check.ok || check.cleaned, nil
+}
+
+// stdinCheckResult is what -stdin-check prints, for editor integrations
+// placing a diagnostic on the last line
+type stdinCheckResult struct {
+	EndsWithNewline bool `json:"ends_with_newline"`
+	LastLine        int  `json:"last_line"`
+}
+
+// runStdinCheck reads all of r and writes whether it ends with newline,
+// and the number of its last line, to w as JSON. The exit code is the
+// same as with -stdin-fix.
+func runStdinCheck(r io.Reader, w io.Writer, opts Options) int {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to read stdin: %v\n", err)
+		return stdinFailed
+	}
+
+	check, _ := checkBytes(data, opts)
+	out := stdinCheckResult{EndsWithNewline: check.ok, LastLine: lineCount(data)}
+	if err := json.NewEncoder(w).Encode(out); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to write stdout: %v\n", err)
+		return stdinFailed
+	}
+
+	if !check.ok || check.cleaned {
+		return stdinChanged
+	}
+	return stdinUnchanged
+}
+
+// lineCount returns the number of lines in data, counting a last line
+// without terminator. Empty data has no lines.
+func lineCount(data []byte) int {
+	lines := bytes.Count(data, []byte("\n"))
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		lines++
+	}
+	return lines
+}
+
+// Path list formats for -stdin-format
+const (
+	stdinFormatLines = "lines"
+	stdinFormatJSON  = "json"
+)
+
+// runStdinPaths checks, and with -fix rewrites in place, the files and
+// directories listed on r, as in git diff --name-only | check-new-line
+// -stdin -fix. Fixes hold the lock of the working directory, which the
+// listed paths are relative to, taken by execute.
+func runStdinPaths(r io.Reader, cfg *cliConfig) int {
+	opts := cfg.opts
+	paths, err := readPaths(r, cfg.stdinFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	result, err := processPaths(paths, opts)
+	return report(result, err, opts)
+}
+
+// isValidStdinFormat reports whether format is a supported -stdin-format
+func isValidStdinFormat(format string) bool {
+	return format == stdinFormatLines || format == stdinFormatJSON
+}
+
+// readPaths reads the -stdin path list in the given format
+func readPaths(r io.Reader, format string) ([]string, error) {
+	if format == stdinFormatJSON {
+		return readJSONPathList(r)
+	}
+	return readPathList(r)
+}
+
+// readJSONPathList reads a JSON array whose entries are paths or objects
+// with a "path" field, as emitted by some orchestration tools
+func readJSONPathList(r io.Reader) ([]string, error) {
+	var entries []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("invalid JSON path list on stdin: %w", err)
+	}
+
+	paths := make([]string, 0, len(entries))
+	for i, entry := range entries {
+		var path string
+		if err := json.Unmarshal(entry, &path); err != nil {
+			var object struct {
+				Path *string `json:"path"`
+			}
+			if err := json.Unmarshal(entry, &object); err != nil || object.Path == nil {
+				return nil, fmt.Errorf("invalid JSON path list on stdin: entry %d must be a string or an object with a \"path\" string", i)
+			}
+			path = *object.Path
+		}
+		if path == "" {
+			return nil, fmt.Errorf("invalid JSON path list on stdin: entry %d is an empty path", i)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// readPathList reads newline-separated paths, ignoring blank lines
+func readPathList(r io.Reader) ([]string, error) {
+	var paths []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		path := strings.TrimSpace(scanner.Text())
+		if path == "" {
+			continue
+		}
+		paths = append(paths, path)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read paths from stdin: %w", err)
+	}
+
+	return paths, nil
+}
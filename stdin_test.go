@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStdinFix(t *testing.T) {
+	tests := []struct {
+		name            string
+		input           string
+		expectedOutput  string
+		expectedChanged bool
+	}{
+		{
+			name:            "改行で終わる入力",
+			input:           "Hello\n",
+			expectedOutput:  "Hello\n",
+			expectedChanged: false,
+		},
+		{
+			name:            "改行で終わらない入力",
+			input:           "Hello",
+			expectedOutput:  "Hello\n",
+			expectedChanged: true,
+		},
+		{
+			name:            "空の入力",
+			input:           "",
+			expectedOutput:  "",
+			expectedChanged: false,
+		},
+		{
+			name:            "バイナリの入力",
+			input:           string([]byte{0x00, 0x01, 0x02}),
+			expectedOutput:  string([]byte{0x00, 0x01, 0x02}),
+			expectedChanged: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			changed, err := stdinFix(strings.NewReader(tt.input), &out, Options{})
+			if err != nil {
+				t.Fatalf("stdinFix()でエラーが発生: %v", err)
+			}
+
+			if changed != tt.expectedChanged {
+				t.Errorf("stdinFix() = %v, expected %v", changed, tt.expectedChanged)
+			}
+			if out.String() != tt.expectedOutput {
+				t.Errorf("出力が期待値と異なります。actual: %q, expected: %q", out.String(), tt.expectedOutput)
+			}
+		})
+	}
+}
+
+func TestRunStdinFixExitCode(t *testing.T) {
+	var out bytes.Buffer
+	if code := runStdinFix(strings.NewReader("no newline"), &out, Options{}); code != stdinChanged {
+		t.Errorf("runStdinFix() = %d, expected %d", code, stdinChanged)
+	}
+
+	out.Reset()
+	if code := runStdinFix(strings.NewReader("newline\n"), &out, Options{}); code != stdinUnchanged {
+		t.Errorf("runStdinFix() = %d, expected %d", code, stdinUnchanged)
+	}
+}
+
+func TestReadPathList(t *testing.T) {
+	input := "src\n\n  docs/README.md  \r\nmain.go"
+
+	paths, err := readPathList(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("readPathList()でエラーが発生: %v", err)
+	}
+
+	expected := []string{"src", "docs/README.md", "main.go"}
+	if strings.Join(paths, ",") != strings.Join(expected, ",") {
+		t.Errorf("readPathList() = %v, expected %v", paths, expected)
+	}
+}
+
+func TestReadJSONPathList(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+		wantErr  bool
+	}{
+		{name: "パスの配列", input: `["src", "docs/README.md"]`, expected: []string{"src", "docs/README.md"}},
+		{name: "オブジェクトの配列", input: `[{"path": "main.go", "size": 10}, "src"]`, expected: []string{"main.go", "src"}},
+		{name: "空の配列", input: `[]`, expected: []string{}},
+		{name: "不正なJSON", input: `["src"`, wantErr: true},
+		{name: "配列以外", input: `{"path": "src"}`, wantErr: true},
+		{name: "pathのないオブジェクト", input: `[{"file": "src"}]`, wantErr: true},
+		{name: "数値の要素", input: `[1]`, wantErr: true},
+		{name: "空のパス", input: `[""]`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paths, err := readPaths(strings.NewReader(tt.input), stdinFormatJSON)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("エラーが返されませんでした: %v", paths)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readPaths()でエラーが発生: %v", err)
+			}
+			if strings.Join(paths, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("readPaths() = %v, expected %v", paths, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRunStdinCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		code     int
+	}{
+		{name: "改行なし", input: "a\nb\nc", expected: `{"ends_with_newline":false,"last_line":3}`, code: stdinChanged},
+		{name: "改行あり", input: "a\nb\n", expected: `{"ends_with_newline":true,"last_line":2}`, code: stdinUnchanged},
+		{name: "空", input: "", expected: `{"ends_with_newline":true,"last_line":0}`, code: stdinUnchanged},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if code := runStdinCheck(strings.NewReader(tt.input), &out, Options{}); code != tt.code {
+				t.Errorf("runStdinCheck() = %d, expected %d", code, tt.code)
+			}
+			if strings.TrimSpace(out.String()) != tt.expected {
+				t.Errorf("出力 = %q, expected %q", out.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestRunStdinPathsFix(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	files := map[string]struct {
+		content string
+		mode    os.FileMode
+	}{
+		"a.txt":     {content: "a", mode: 0o644},
+		"run.sh":    {content: "#!/bin/sh", mode: 0o755},
+		"sub/b.txt": {content: "b\n", mode: 0o600},
+	}
+	for name, f := range files {
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatalf("ディレクトリの作成に失敗: %v", err)
+		}
+		if err := os.WriteFile(name, []byte(f.content), f.mode); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+		if err := os.Chmod(name, f.mode); err != nil {
+			t.Fatalf("パーミッションの設定に失敗: %v", err)
+		}
+	}
+
+	// Feed the path list through a pipe as git diff --name-only would
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("パイプの作成に失敗: %v", err)
+	}
+	go func() {
+		w.WriteString("a.txt\nrun.sh\n\nsub/b.txt\n")
+		w.Close()
+	}()
+	defer r.Close()
+
+	cfg := &cliConfig{stdinFormat: stdinFormatLines, opts: Options{Fix: true}}
+	if code := runStdinPaths(r, cfg); code != 0 {
+		t.Errorf("終了コードが期待値と異なります: got %d, expected 0", code)
+	}
+
+	for name, f := range files {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatalf("ファイルの読み込みに失敗: %v", err)
+		}
+		expected := strings.TrimSuffix(f.content, "\n") + "\n"
+		if string(data) != expected {
+			t.Errorf("%s: 内容が期待値と異なります: got %q, expected %q", name, data, expected)
+		}
+
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatalf("ファイル情報の取得に失敗: %v", err)
+		}
+		if info.Mode().Perm() != f.mode {
+			t.Errorf("%s: パーミッションが保持されていません: got %o, expected %o", name, info.Mode().Perm(), f.mode)
+		}
+	}
+
+	if _, err := os.Stat(lockFileName); !os.IsNotExist(err) {
+		t.Errorf("ロックファイルが残っています: %v", err)
+	}
+}
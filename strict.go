@@ -0,0 +1,40 @@
+package main
+
+import "flag"
+
+// strictCheckFlags are turned on by -strict in check and fix mode alike
+var strictCheckFlags = []string{"single-final-newline"}
+
+// strictFixFlags are turned on by -strict only when fixing, since they
+// make a run write files
+var strictFixFlags = []string{"fix-blank-lines", "fix-whitespace"}
+
+// applyStrict turns on the flags bundled by -strict, leaving any that
+// were given on the command line or in .newlinerc as they are
+func applyStrict(fs *flag.FlagSet, opts *Options) error {
+	given := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { given[f.Name] = true })
+
+	names := strictCheckFlags
+	if opts.Fix {
+		names = append(names[:len(names):len(names)], strictFixFlags...)
+	}
+	for _, name := range names {
+		if given[name] {
+			continue
+		}
+		if err := fs.Set(name, "true"); err != nil {
+			return err
+		}
+	}
+
+	opts.Strict = true
+	return nil
+}
+
+// unfixedTails reports whether the run found trailing whitespace or blank
+// lines it didn't remove
+func unfixedTails(result *RepoResult, opts Options) bool {
+	return (len(result.TrailingWhitespace) > 0 && !opts.FixWhitespace) ||
+		(len(result.TrailingBlankLines) > 0 && !opts.FixBlankLines)
+}
@@ -0,0 +1,58 @@
+package main
+
+import "testing"
+
+func TestParseArgsStrict(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		singleNewline bool
+		blankLines    bool
+		whitespace    bool
+	}{
+		{name: "チェックモード", args: []string{"-strict", "."}, singleNewline: true},
+		{name: "修正モード", args: []string{"-strict", "-fix", "."}, singleNewline: true, blankLines: true, whitespace: true},
+		{name: "個別フラグで無効化", args: []string{"-strict", "-fix", "-fix-whitespace=false", "."}, singleNewline: true, blankLines: true},
+		{name: "フラグの順序に依存しない", args: []string{"-single-final-newline=false", "-strict", "."}},
+		{name: "strictなし", args: []string{"-fix", "."}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			cfg, err := parseArgs(tt.args)
+			if err != nil {
+				t.Fatalf("parseArgs()でエラーが発生: %v", err)
+			}
+
+			opts := cfg.opts
+			if opts.SingleFinalNewline != tt.singleNewline || opts.FixBlankLines != tt.blankLines || opts.FixWhitespace != tt.whitespace {
+				t.Errorf("オプションが期待値と異なります: single-final-newline=%v fix-blank-lines=%v fix-whitespace=%v",
+					opts.SingleFinalNewline, opts.FixBlankLines, opts.FixWhitespace)
+			}
+		})
+	}
+}
+
+func TestExitCodeStrict(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   *RepoResult
+		opts     Options
+		expected int
+	}{
+		{name: "strictなしでは末尾の空白は成功", result: &RepoResult{TrailingWhitespace: []string{"a.txt"}}, expected: 0},
+		{name: "末尾の空白", result: &RepoResult{TrailingWhitespace: []string{"a.txt"}}, opts: Options{Strict: true}, expected: 1},
+		{name: "末尾の空行", result: &RepoResult{TrailingBlankLines: []string{"a.txt"}}, opts: Options{Strict: true}, expected: 1},
+		{name: "修正済み", result: &RepoResult{TrailingWhitespace: []string{"a.txt"}}, opts: Options{Strict: true, FixWhitespace: true}, expected: 0},
+		{name: "警告のみ", result: &RepoResult{TrailingBlankLines: []string{"a.txt"}}, opts: Options{Strict: true, WarnOnly: true}, expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := exitCode(tt.result, tt.opts); code != tt.expected {
+				t.Errorf("exitCode() = %d, expected %d", code, tt.expected)
+			}
+		})
+	}
+}
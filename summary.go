@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+)
+
+// Summary modes for -summary
+const (
+	summaryCombined = "combined"
+	summaryPerRoot  = "per-root"
+	summaryBoth     = "both"
+)
+
+// isValidSummaryMode reports whether mode is a supported -summary value
+func isValidSummaryMode(mode string) bool {
+	switch mode {
+	case summaryCombined, summaryPerRoot, summaryBoth:
+		return true
+	}
+	return false
+}
+
+// RootSummary is the share of one path named on the command line in the
+// totals of a run
+type RootSummary struct {
+	Path        string   `json:"path"`
+	Total       int      `json:"total_files"`
+	Skipped     int      `json:"skipped_files"`
+	Fixed       int      `json:"fixed_files"`
+	Patched     int      `json:"patched_files"`
+	Missing     int      `json:"missing_files"`
+	Errors      int      `json:"errors"`
+	Problematic []string `json:"problematic"`
+}
+
+// rootCounts returns the running totals of the run so far
+func (r *RepoResult) rootCounts() RootSummary {
+	return RootSummary{
+		Total:   r.Total,
+		Skipped: r.Skipped,
+		Fixed:   len(r.Fixed),
+		Patched: len(r.Patched),
+		Missing: len(r.Problematic),
+		Errors:  len(r.Errors),
+	}
+}
+
+// rootSummary returns what was added to the totals since before, when
+// the root at path started
+func (r *RepoResult) rootSummary(path string, before RootSummary) RootSummary {
+	return RootSummary{
+		Path:        path,
+		Total:       r.Total - before.Total,
+		Skipped:     r.Skipped - before.Skipped,
+		Fixed:       len(r.Fixed) - before.Fixed,
+		Patched:     len(r.Patched) - before.Patched,
+		Missing:     len(r.Problematic) - before.Missing,
+		Errors:      len(r.Errors) - before.Errors,
+		Problematic: slices.Clone(r.Problematic[before.Missing:]),
+	}
+}
+
+// writeRootSummaries writes a short summary of each root, listing its
+// files missing a newline when listFiles is set
+func writeRootSummaries(w io.Writer, result *RepoResult, opts Options, listFiles bool) {
+	for _, root := range result.Roots {
+		fmt.Fprintf(w, "\n=== Summary: %s ===\n", root.Path)
+		fmt.Fprintf(w, "Total files checked: %d\n", root.Total)
+		fmt.Fprintf(w, "Files skipped: %d\n", root.Skipped)
+		if root.Errors > 0 {
+			fmt.Fprintf(w, "Errors: %d\n", root.Errors)
+		}
+		if result.Mode == modeFix {
+			fmt.Fprintf(w, "%s: %d\n", fixedLabel(opts), root.Fixed+root.Patched)
+			continue
+		}
+		fmt.Fprintf(w, "Files missing newline: %d\n", root.Missing)
+		if listFiles {
+			for _, file := range root.Problematic {
+				fmt.Fprintf(w, "  - %s\n", file)
+			}
+		}
+	}
+}
+
+// fixedLabel names the count of fixed files in the summaries, which are
+// only patched with -output-patch
+func fixedLabel(opts Options) string {
+	if opts.Patch != nil {
+		return "Files fixed in the patch"
+	}
+	return "Files fixed"
+}
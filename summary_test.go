@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProcessPathsRootSummaries(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Chdir(tempDir)
+
+	files := map[string]string{
+		"frontend/a.js": "a",
+		"frontend/b.js": "b\n",
+		"backend/a.go":  "a",
+		"backend/b.go":  "b",
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatalf("ディレクトリの作成に失敗: %v", err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		summary  string
+		roots    int
+		expected []string
+		absent   []string
+	}{
+		{
+			name:     "まとめたサマリー",
+			summary:  summaryCombined,
+			roots:    0,
+			expected: []string{"=== Summary ===\nTotal files checked: 4"},
+			absent:   []string{"=== Summary: frontend ==="},
+		},
+		{
+			name:     "パスごとのサマリー",
+			summary:  summaryPerRoot,
+			roots:    4,
+			expected: []string{"=== Summary: frontend ===\nTotal files checked: 2", "=== Summary: backend ===\nTotal files checked: 2\nFiles skipped: 0\nFiles missing newline: 2\n  - backend/a.go"},
+			absent:   []string{"=== Summary ===\n"},
+		},
+		{
+			name:     "両方",
+			summary:  summaryBoth,
+			roots:    4,
+			expected: []string{"=== Summary: missing.txt ===\nTotal files checked: 0\nFiles skipped: 0\nErrors: 1", "=== Summary ===\nTotal files checked: 4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{Summary: tt.summary}
+			result, err := processPaths([]string{"frontend", "backend", "missing.txt", "frontend/a.js"}, opts)
+			if err != nil {
+				t.Fatalf("processPaths()でエラーが発生: %v", err)
+			}
+			if len(result.Roots) != tt.roots {
+				t.Fatalf("パスごとのサマリー数 = %d", len(result.Roots))
+			}
+
+			// パスごとの件数の合計はまとめた件数と一致する
+			total, missing := 0, 0
+			for _, root := range result.Roots {
+				total += root.Total
+				missing += root.Missing
+			}
+			if len(result.Roots) > 0 && (total != result.Total || missing != len(result.Problematic)) {
+				t.Errorf("パスごとの合計 %d/%d がまとめた件数 %d/%d と一致しません", total, missing, result.Total, len(result.Problematic))
+			}
+
+			var buf bytes.Buffer
+			writeTextReport(&buf, result, opts)
+			for _, want := range tt.expected {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("出力に %q が含まれていません:\n%s", want, buf.String())
+				}
+			}
+			for _, unwanted := range tt.absent {
+				if strings.Contains(buf.String(), unwanted) {
+					t.Errorf("出力に %q が含まれています:\n%s", unwanted, buf.String())
+				}
+			}
+		})
+	}
+}
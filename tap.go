@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// tapEscaper escapes the characters TAP gives a meaning to in test
+// descriptions
+var tapEscaper = strings.NewReplacer(`\`, `\\`, "#", `\#`, "\n", " ")
+
+// writeTAPReport writes the result in TAP version 13, one test point per
+// processed file. A missing final newline or an error fails the point;
+// skipped and binary files pass with a SKIP directive.
+func writeTAPReport(w io.Writer, result *RepoResult) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "TAP version 13\n1..%d\n", len(result.Files))
+
+	for i, file := range result.Files {
+		n, path := i+1, tapEscaper.Replace(file.Path)
+		reason := tapEscaper.Replace(file.Reason)
+		switch file.Status {
+		case statusMissing:
+			fmt.Fprintf(&b, "not ok %d - %s (%s)\n", n, path, reason)
+		case statusError:
+			fmt.Fprintf(&b, "not ok %d - %s (error: %s)\n", n, path, reason)
+		case statusFixed, statusPatched:
+			fmt.Fprintf(&b, "ok %d - %s (%s: %s)\n", n, path, file.Status, reason)
+		case statusSkipped, statusBinary:
+			fmt.Fprintf(&b, "ok %d - %s # SKIP %s\n", n, path, reason)
+		default:
+			fmt.Fprintf(&b, "ok %d - %s\n", n, path)
+		}
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
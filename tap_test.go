@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteTAPReport(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"ok.txt":      "ok\n",
+		"missing.txt": "missing",
+		"a#b.txt":     "hash\n",
+		"image.png":   "\x89PNG",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	result, err := processRepository(dir, Options{})
+	if err != nil {
+		t.Fatalf("processRepository()でエラーが発生: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := writeReport(&buf, result, Options{Format: formatTAP}); err != nil {
+		t.Fatalf("writeReport()でエラーが発生: %v", err)
+	}
+
+	expected := `TAP version 13
+1..4
+ok 1 - a\#b.txt
+ok 2 - image.png # SKIP binary extension .png
+not ok 3 - missing.txt (missing final newline)
+ok 4 - ok.txt
+`
+	if buf.String() != expected {
+		t.Errorf("出力が期待値と異なります:\ngot:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+}
+
+func TestWriteTAPReportFixed(t *testing.T) {
+	result := &RepoResult{Files: []FileResult{
+		{Path: "a.txt", Status: statusFixed, Reason: "missing final newline"},
+		{Path: "b.txt", Status: statusError, Reason: "permission denied"},
+	}}
+
+	var buf bytes.Buffer
+	if err := writeTAPReport(&buf, result); err != nil {
+		t.Fatalf("writeTAPReport()でエラーが発生: %v", err)
+	}
+
+	expected := "TAP version 13\n1..2\nok 1 - a.txt (fixed: missing final newline)\nnot ok 2 - b.txt (error: permission denied)\n"
+	if buf.String() != expected {
+		t.Errorf("出力が期待値と異なります:\ngot:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+}
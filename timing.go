@@ -0,0 +1,62 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+)
+
+// defaultTimingTop is how many files -timing lists by default
+const defaultTimingTop = 10
+
+// FileTiming is how long one file took to process
+type FileTiming struct {
+	Path     string        `json:"path"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// timeFile starts timing relPath when Options.Timing is set. The returned
+// function records the elapsed time; with timing off it does nothing.
+func (r *RepoResult) timeFile(relPath string, opts Options) func() {
+	if !opts.Timing {
+		return func() {}
+	}
+
+	start := time.Now()
+	return func() {
+		r.Slowest = append(r.Slowest, FileTiming{Path: relPath, Duration: time.Since(start)})
+	}
+}
+
+// keepSlowest orders the timings from slowest to fastest and keeps the
+// first TimingTop of them
+func (r *RepoResult) keepSlowest(opts Options) {
+	slices.SortStableFunc(r.Slowest, func(a, b FileTiming) int {
+		return cmp.Compare(b.Duration, a.Duration)
+	})
+	if opts.TimingTop > 0 && len(r.Slowest) > opts.TimingTop {
+		r.Slowest = r.Slowest[:opts.TimingTop]
+	}
+}
+
+// recordThroughput sets the elapsed time since the run started and the
+// checked files per second
+func (r *RepoResult) recordThroughput() {
+	elapsed := time.Since(r.started)
+	r.DurationMS = elapsed.Milliseconds()
+	if elapsed > 0 {
+		r.FilesPerSec = float64(r.Total) / elapsed.Seconds()
+	}
+}
+
+// writeSlowest writes the run time and the slowest files recorded by
+// -timing
+func writeSlowest(w io.Writer, result *RepoResult) {
+	fmt.Fprintf(w, "\nElapsed: %s (%.1f files/s)\n", time.Duration(result.DurationMS)*time.Millisecond, result.FilesPerSec)
+	fmt.Fprintf(w, "Slowest files:\n")
+	for _, timing := range result.Slowest {
+		fmt.Fprintf(w, "  %10s  %s\n", timing.Duration.Round(time.Microsecond), timing.Path)
+	}
+}
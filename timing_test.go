@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKeepSlowest(t *testing.T) {
+	result := &RepoResult{Slowest: []FileTiming{
+		{Path: "fast.txt", Duration: time.Millisecond},
+		{Path: "slow.txt", Duration: time.Second},
+		{Path: "medium.txt", Duration: 10 * time.Millisecond},
+	}}
+
+	result.keepSlowest(Options{TimingTop: 2})
+
+	if len(result.Slowest) != 2 || result.Slowest[0].Path != "slow.txt" || result.Slowest[1].Path != "medium.txt" {
+		t.Errorf("遅いファイルの一覧が期待値と異なります: %v", result.Slowest)
+	}
+}
+
+func TestProcessRepositoryTiming(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "c.png"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte("content\n"), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	result, err := processRepository(tempDir, Options{})
+	if err != nil {
+		t.Fatalf("processRepository()でエラーが発生: %v", err)
+	}
+	if len(result.Slowest) != 0 {
+		t.Errorf("-timingなしで時間が記録されました: %v", result.Slowest)
+	}
+
+	// スキップしたファイルも含めて記録する
+	result, err = processRepository(tempDir, Options{Timing: true})
+	if err != nil {
+		t.Fatalf("processRepository()でエラーが発生: %v", err)
+	}
+	if len(result.Slowest) != 3 {
+		t.Errorf("記録されたファイル数 = %d, expected 3", len(result.Slowest))
+	}
+
+	var buf bytes.Buffer
+	writeTextReport(&buf, result, Options{Timing: true})
+	if !strings.Contains(buf.String(), "Slowest files:") || !strings.Contains(buf.String(), "a.txt") {
+		t.Errorf("遅いファイルの一覧が出力されていません: %q", buf.String())
+	}
+	if !strings.Contains(buf.String(), "Elapsed: ") || !strings.Contains(buf.String(), " files/s)") {
+		t.Errorf("経過時間とスループットが出力されていません: %q", buf.String())
+	}
+	if result.FilesPerSec <= 0 {
+		t.Errorf("スループット = %f, expected > 0", result.FilesPerSec)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Outcomes of one step of a -trace-path trace
+const (
+	traceOff        = "off"
+	tracePass       = "pass"
+	traceSkip       = "skip"
+	traceNotReached = "not reached"
+)
+
+// traceSteps are the walk's pruning of directories followed by the
+// decisions of processFile
+var traceSteps = append([]fileStep{
+	{name: walkPruneStep, enabled: func(o Options) bool { return len(o.SkipDirs) > 0 || o.Ignore != nil }},
+}, fileSteps...)
+
+// TraceStep is the outcome of one decision in a PathTrace
+type TraceStep struct {
+	Step    string `json:"step"`
+	Outcome string `json:"outcome"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+// PathTrace is how -trace-path decided about one file
+type PathTrace struct {
+	Path    string      `json:"path"`
+	Steps   []TraceStep `json:"steps"`
+	Verdict string      `json:"verdict"`
+	Reason  string      `json:"reason,omitempty"`
+}
+
+// tracePath processes path alone without changing anything and traces the
+// decisions made about it. The verdict comes from the real processing;
+// the steps before the deciding one passed and the later ones were never
+// reached.
+func tracePath(path string, opts Options) (PathTrace, error) {
+	trace := PathTrace{Path: filepath.ToSlash(filepath.Clean(path))}
+
+	file, err := examineFile(path, "-trace-path", opts)
+	if err != nil {
+		return trace, err
+	}
+	if file.Status == statusError {
+		trace.Verdict, trace.Reason = statusError, file.Reason
+		return trace, nil
+	}
+
+	decided := false
+	for _, step := range traceSteps {
+		outcome := TraceStep{Step: step.name, Outcome: tracePass}
+		switch {
+		case decided:
+			outcome.Outcome = traceNotReached
+		case step.name == file.Step:
+			outcome.Outcome, outcome.Detail = traceSkip, file.Reason
+			decided = true
+		case !step.enabled(opts):
+			outcome.Outcome = traceOff
+		}
+		trace.Steps = append(trace.Steps, outcome)
+	}
+
+	trace.Verdict, trace.Reason = "checked", file.Reason
+	if decided || file.Status == statusSkipped {
+		trace.Verdict = "skipped"
+	}
+	return trace, nil
+}
+
+// runTracePath writes the trace of path to w, as JSON with -format json,
+// and returns 0 when the file would be checked, 1 when it would be
+// skipped and 2 when it can't be examined, as -explain-skip does
+func runTracePath(path string, opts Options, w io.Writer) int {
+	trace, err := tracePath(path, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	if opts.Format == formatJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(trace); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to encode trace: %v\n", err)
+			return 2
+		}
+	} else {
+		for _, step := range trace.Steps {
+			fmt.Fprintf(w, "%-16s %s", step.Step, step.Outcome)
+			if step.Detail != "" {
+				fmt.Fprintf(w, " (%s)", step.Detail)
+			}
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%s: %s", trace.Path, trace.Verdict)
+		if trace.Reason != "" {
+			fmt.Fprintf(w, " (%s)", trace.Reason)
+		}
+		fmt.Fprintln(w)
+	}
+
+	switch trace.Verdict {
+	case statusError:
+		return 2
+	case "skipped":
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestTracePath(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"ok.txt":       "ok\n",
+		"image.png":    "\x89PNG",
+		"data.txt":     "\x00\x01\x02",
+		"gen.txt":      "// Code generated by tool. DO NOT EDIT.\n",
+		".hidden/a.go": "package a",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("ディレクトリの作成に失敗: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		opts     Options
+		verdict  string
+		skipStep string
+		offStep  string
+	}{
+		{name: "チェック対象", path: "ok.txt", verdict: "checked", offStep: "generated"},
+		{name: "バイナリ拡張子", path: "image.png", verdict: "skipped", skipStep: "binary-extension"},
+		{name: "拡張子スキップ無効", path: "image.png", opts: Options{NoExtSkip: true}, verdict: "checked", offStep: "binary-extension"},
+		{name: "バイナリの内容", path: "data.txt", verdict: "skipped", skipStep: "binary-content"},
+		{name: "隠しディレクトリ", path: ".hidden/a.go", verdict: "skipped", skipStep: "hidden"},
+		{name: "生成コード", path: "gen.txt", opts: Options{GeneratedPattern: regexp.MustCompile(`DO NOT EDIT`)}, verdict: "skipped", skipStep: "generated"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trace, err := tracePath(filepath.Join(dir, filepath.FromSlash(tt.path)), tt.opts)
+			if err != nil {
+				t.Fatalf("トレースに失敗: %v", err)
+			}
+			if trace.Verdict != tt.verdict {
+				t.Errorf("判定が期待値と異なります: got %q, expected %q", trace.Verdict, tt.verdict)
+			}
+			if len(trace.Steps) != len(traceSteps) {
+				t.Fatalf("ステップ数が期待値と異なります: got %d, expected %d", len(trace.Steps), len(traceSteps))
+			}
+
+			reached := true
+			for _, step := range trace.Steps {
+				switch {
+				case step.Step == tt.skipStep:
+					if step.Outcome != traceSkip {
+						t.Errorf("%s: スキップになっていません: %q", step.Step, step.Outcome)
+					}
+					reached = false
+				case step.Step == tt.offStep:
+					if step.Outcome != traceOff {
+						t.Errorf("%s: 無効になっていません: %q", step.Step, step.Outcome)
+					}
+				case !reached:
+					if step.Outcome != traceNotReached {
+						t.Errorf("%s: スキップ後のステップが評価されています: %q", step.Step, step.Outcome)
+					}
+				case step.Outcome == traceSkip:
+					t.Errorf("%s: 予期しないスキップ", step.Step)
+				}
+			}
+		})
+	}
+}
+
+func TestRunTracePathJSON(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "missing.txt")
+	if err := os.WriteFile(path, []byte("missing"), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if code := runTracePath(path, Options{Fix: true, Format: formatJSON}, &buf); code != 0 {
+		t.Errorf("終了コードが期待値と異なります: got %d, expected 0", code)
+	}
+
+	var trace PathTrace
+	if err := json.Unmarshal(buf.Bytes(), &trace); err != nil {
+		t.Fatalf("JSONの解析に失敗: %v\n%s", err, buf.String())
+	}
+	if trace.Verdict != "checked" || trace.Reason != "missing final newline" {
+		t.Errorf("トレースが期待値と異なります: %+v", trace)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ファイルの読み込みに失敗: %v", err)
+	}
+	if string(data) != "missing" {
+		t.Errorf("-trace-pathでファイルが変更されました: %q", data)
+	}
+
+	if code := runTracePath(dir, Options{}, &buf); code != 2 {
+		t.Errorf("ディレクトリの終了コードが期待値と異なります: got %d, expected 2", code)
+	}
+}
+
+func TestTracePathWalkDecisions(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	if err := os.MkdirAll("vendor", 0o755); err != nil {
+		t.Fatalf("ディレクトリの作成に失敗: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join("vendor", "a.txt"), []byte("a"), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+	writeEmptyZip(t, "empty.zip")
+
+	trace, err := tracePath(filepath.Join("vendor", "a.txt"), Options{SkipDirs: skipDirSet(true, "")})
+	if err != nil {
+		t.Fatalf("トレースに失敗: %v", err)
+	}
+	if trace.Verdict != "skipped" || trace.Steps[0].Step != walkPruneStep || trace.Steps[0].Outcome != traceSkip {
+		t.Errorf("トレースが期待値と異なります: %+v", trace)
+	}
+
+	if _, err := tracePath("empty.zip", Options{Archive: true}); err == nil {
+		t.Error("アーカイブでエラーが返されませんでした")
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// errNotGitRepo is returned by trackedFiles when dir is not in a work tree
+var errNotGitRepo = errors.New("not a git repository")
+
+// trackedFiles lists the files git tracks under dir, relative to dir
+func trackedFiles(dir string) ([]string, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("%w: git is not installed", errNotGitRepo)
+	}
+	if _, err := git(dir, nil, "rev-parse", "--is-inside-work-tree"); err != nil {
+		return nil, fmt.Errorf("%w: %s is not inside a work tree", errNotGitRepo, dir)
+	}
+
+	out, err := git(dir, nil, "ls-files", "-z")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, file := range strings.Split(string(out), "\x00") {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// processTracked checks the files git tracks under repoPath instead of
+// walking it, so untracked build output is never looked at. Outside a git
+// repository it warns and walks repoPath as usual.
+func processTracked(repoPath string, opts Options) (*RepoResult, error) {
+	files, err := trackedFiles(repoPath)
+	if errors.Is(err, errNotGitRepo) {
+		fmt.Fprintf(os.Stderr, "Warning: -tracked-only: %v; checking every file\n", err)
+		return processRepository(repoPath, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := newRepoResult(opts)
+	for _, relPath := range files {
+		if inSkippedDir(relPath, opts) {
+			continue
+		}
+
+		path := filepath.Join(repoPath, filepath.FromSlash(relPath))
+		info, err := os.Lstat(path)
+		if errors.Is(err, os.ErrNotExist) {
+			// Deleted from the work tree but not yet from the index
+			continue
+		}
+		if err != nil {
+			result.addError(relPath, err)
+		} else if info.IsDir() {
+			// Submodules are listed as a single entry
+			continue
+		} else if confineSymlink(repoPath, path, relPath, info, opts, result) {
+			processFile(path, relPath, relPath, info, opts, result)
+		}
+
+		if err := result.stopReason(opts); err != nil {
+			return partialResult(result, err, opts)
+		}
+	}
+	checkRequired(repoPath, "", opts, result)
+
+	result.finish(opts)
+
+	return result, nil
+}
+
+// inSkippedDir reports whether relPath lies in a directory the walk would
+// not enter because of Options.SkipDirs
+func inSkippedDir(relPath string, opts Options) bool {
+	dirs := strings.Split(relPath, "/")
+	for _, dir := range dirs[:len(dirs)-1] {
+		if opts.skipsDir(dir) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestProcessTracked(t *testing.T) {
+	dir := initGitRepo(t)
+
+	if err := os.Mkdir(filepath.Join(dir, "src"), 0o755); err != nil {
+		t.Fatalf("ディレクトリの作成に失敗: %v", err)
+	}
+	stageFile(t, dir, "src/main.txt", "no newline")
+	stageFile(t, dir, "ok.txt", "ok\n")
+	stageFile(t, dir, "deleted.txt", "deleted")
+	if err := os.Remove(filepath.Join(dir, "deleted.txt")); err != nil {
+		t.Fatalf("テストファイルの削除に失敗: %v", err)
+	}
+
+	// 追跡されていないビルド成果物は対象外
+	if err := os.WriteFile(filepath.Join(dir, "build.out"), []byte("untracked"), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+
+	result, err := processTracked(dir, Options{})
+	if err != nil {
+		t.Fatalf("processTracked()でエラーが発生: %v", err)
+	}
+	if result.Total != 2 {
+		t.Errorf("チェック数が期待値と異なります: got %d, expected 2", result.Total)
+	}
+	if !slices.Equal(result.Problematic, []string{"src/main.txt"}) {
+		t.Errorf("problematicが期待値と異なります: %v", result.Problematic)
+	}
+}
+
+func TestProcessTrackedOutsideRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("gitがインストールされていません")
+	}
+
+	dir := t.TempDir()
+	t.Setenv("GIT_CEILING_DIRECTORIES", filepath.Dir(dir))
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("no newline"), 0o644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗: %v", err)
+	}
+
+	// gitリポジトリ外では通常の走査に戻る
+	result, err := processTracked(dir, Options{})
+	if err != nil {
+		t.Fatalf("processTracked()でエラーが発生: %v", err)
+	}
+	if !slices.Equal(result.Problematic, []string{"a.txt"}) {
+		t.Errorf("problematicが期待値と異なります: %v", result.Problematic)
+	}
+}
+
+func TestInSkippedDir(t *testing.T) {
+	opts := Options{SkipDirs: map[string]bool{"vendor": true}}
+
+	for path, expected := range map[string]bool{
+		"vendor/lib/a.go": true,
+		"src/vendor/a.go": true,
+		"vendor":          false,
+		"src/a.go":        false,
+	} {
+		if got := inSkippedDir(path, opts); got != expected {
+			t.Errorf("inSkippedDir(%q) = %v, expected %v", path, got, expected)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"unicode/utf16"
+)
+
+// newlines holds the encoded line terminators of a text encoding
+type newlines struct {
+	lf, crlf []byte
+}
+
+// Line terminators for ASCII-compatible encodings and for UTF-16
+var (
+	asciiNewlines   = newlines{lf: []byte("\n"), crlf: []byte("\r\n")}
+	utf16LENewlines = newlines{lf: []byte{'\n', 0}, crlf: []byte{'\r', 0, '\n', 0}}
+	utf16BENewlines = newlines{lf: []byte{0, '\n'}, crlf: []byte{0, '\r', 0, '\n'}}
+)
+
+// UTF-16 byte order marks
+var (
+	utf16LEBOM = []byte{0xFF, 0xFE}
+	utf16BEBOM = []byte{0xFE, 0xFF}
+)
+
+// utf16Newlines returns the line terminators for data when it starts with
+// a UTF-16 byte order mark. Content with an odd number of bytes can't be
+// UTF-16 and is left to the usual checks.
+func utf16Newlines(data []byte) (newlines, bool) {
+	if len(data)%2 != 0 {
+		return newlines{}, false
+	}
+
+	switch {
+	case bytes.HasPrefix(data, utf16LEBOM):
+		return utf16LENewlines, true
+	case bytes.HasPrefix(data, utf16BEBOM):
+		return utf16BENewlines, true
+	}
+	return newlines{}, false
+}
+
+// decodeUTF16 converts UTF-16 content after its byte order mark to UTF-8
+// so it can go through the same binary detection as other text. data
+// must be accepted by utf16Newlines.
+func decodeUTF16(data []byte) []byte {
+	var order binary.ByteOrder = binary.LittleEndian
+	if bytes.HasPrefix(data, utf16BEBOM) {
+		order = binary.BigEndian
+	}
+
+	units := make([]uint16, 0, len(data)/2-1)
+	for i := 2; i < len(data); i += 2 {
+		units = append(units, order.Uint16(data[i:]))
+	}
+	return []byte(string(utf16.Decode(units)))
+}
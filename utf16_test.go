@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// encodeUTF16 encodes ASCII text as UTF-16 with a byte order mark
+func encodeUTF16(text string, bigEndian bool) []byte {
+	data := []byte{0xFF, 0xFE}
+	if bigEndian {
+		data = []byte{0xFE, 0xFF}
+	}
+	for _, b := range []byte(text) {
+		if bigEndian {
+			data = append(data, 0, b)
+		} else {
+			data = append(data, b, 0)
+		}
+	}
+	return data
+}
+
+func TestCheckBytesUTF16(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		bigEndian bool
+		eol       string
+		ok        bool
+		fixed     string
+	}{
+		{name: "LE 改行あり", text: "hello\n", ok: true, fixed: "hello\n"},
+		{name: "LE 改行なし", text: "hello", fixed: "hello\n"},
+		{name: "BE 改行あり", text: "hello\n", bigEndian: true, ok: true, fixed: "hello\n"},
+		{name: "BE 改行なし", text: "hello", bigEndian: true, fixed: "hello\n"},
+		{name: "LE CRLFを要求", text: "a\r\nb", eol: eolCRLF, fixed: "a\r\nb\r\n"},
+		{name: "BE LFへ変換", text: "a\r\n", bigEndian: true, eol: eolLF, fixed: "a\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check, fixed := checkBytes(encodeUTF16(tt.text, tt.bigEndian), Options{EOL: tt.eol})
+			if check.binary {
+				t.Fatalf("UTF-16のファイルがバイナリと判定されました")
+			}
+			if check.ok != tt.ok {
+				t.Errorf("ok = %v, expected %v", check.ok, tt.ok)
+			}
+			if expected := encodeUTF16(tt.fixed, tt.bigEndian); !bytes.Equal(fixed, expected) {
+				t.Errorf("修正後の内容が期待値と異なります: got %x, expected %x", fixed, expected)
+			}
+		})
+	}
+}
+
+func TestCheckBytesUTF16BOMBinary(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "LE BOMで始まるバイナリ", data: []byte("\xff\xfe\x00\x01\x02\x03\x00\x00\x07\x08")},
+		{name: "BE BOMで始まるバイナリ", data: []byte("\xfe\xff\x01\x00\x03\x02\x00\x00\x08\x07")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check, fixed := checkBytes(tt.data, Options{Fix: true})
+			if !check.binary {
+				t.Errorf("バイナリと判定されませんでした")
+			}
+			if !bytes.Equal(fixed, tt.data) {
+				t.Errorf("バイナリの内容が変更されました: %x", fixed)
+			}
+		})
+	}
+}
+
+func TestProcessRepositoryFixUTF16(t *testing.T) {
+	tempDir := t.TempDir()
+
+	files := map[string]bool{"le.txt": false, "be.txt": true}
+	for name, bigEndian := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), encodeUTF16("no newline", bigEndian), 0o644); err != nil {
+			t.Fatalf("テストファイルの作成に失敗: %v", err)
+		}
+	}
+
+	result, err := processRepository(tempDir, Options{Fix: true})
+	if err != nil {
+		t.Fatalf("processRepository()でエラーが発生: %v", err)
+	}
+	if len(result.Fixed) != 2 || len(result.Binary) != 0 {
+		t.Errorf("結果が期待値と異なります: fixed=%v, binary=%v", result.Fixed, result.Binary)
+	}
+
+	for name, bigEndian := range files {
+		data, err := os.ReadFile(filepath.Join(tempDir, name))
+		if err != nil {
+			t.Fatalf("ファイルの読み込みに失敗: %v", err)
+		}
+		if expected := encodeUTF16("no newline\n", bigEndian); !bytes.Equal(data, expected) {
+			t.Errorf("%sの内容が期待値と異なります: got %x, expected %x", name, data, expected)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// Build information, injected at build time via
+// -ldflags "-X main.version=... -X main.commit=... -X main.date=..."
+var (
+	version = "dev"
+	commit  = "none"
+	date    = "unknown"
+)
+
+// versionString formats the build information for -version
+func versionString() string {
+	return fmt.Sprintf("check-new-line %s (commit %s, built %s, %s)", version, commit, date, runtime.Version())
+}
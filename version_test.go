@@ -0,0 +1,17 @@
+package main
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestVersionString(t *testing.T) {
+	output := versionString()
+
+	for _, want := range []string{version, commit, date, runtime.Version()} {
+		if !strings.Contains(output, want) {
+			t.Errorf("バージョン文字列に %q が含まれていません: %q", want, output)
+		}
+	}
+}